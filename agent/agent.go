@@ -0,0 +1,388 @@
+package agent
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/logutils"
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/logging"
+	"github.com/openebs/mtest/mtest"
+)
+
+const (
+	// Statuses of a run of the agent
+	RUN_STATUS_RUNNING  = "running"
+	RUN_STATUS_COMPLETE = "complete"
+	RUN_STATUS_FAILED   = "failed"
+
+	// No of the last log lines of a run that are sent to a client that
+	// streams the logs of the run
+	DEFAULT_RUN_LOG_BUFFER = 1024
+
+	// No of the finished runs that the agent retains
+	DEFAULT_RUN_RETENTION = 50
+)
+
+// RunRequest is a request to the agent to start a run
+type RunRequest struct {
+	// Runner is the name of the runner, which defaults to the
+	// mserver.runner
+	Runner string
+
+	// Config is the mtest config in HCL, e.g. the scenarios to run. It
+	// is merged over the config of the agent.
+	Config string
+
+	// Run, Skip & Tags select the use cases to run
+	Run  string
+	Skip string
+	Tags []string
+}
+
+// Run is the status of a run of the agent
+type Run struct {
+	ID     string
+	Runner string
+	Status string
+
+	Started time.Time
+	Ended   time.Time `json:",omitempty"`
+
+	// Error, if any, due to which the run failed to complete
+	Error string `json:",omitempty"`
+
+	// Schedule, if any, that started the run
+	Schedule string `json:",omitempty"`
+
+	// Summary of the reports of the use cases that completed so far
+	Summary *mtest.Summary
+}
+
+// run is a run of the agent along with its logs
+type run struct {
+	Run
+
+	// Mtest of the run & its handle, which provides the reports of the
+	// use cases that completed so far
+	mt     *mtest.Mtest
+	handle *mtest.RunHandle
+	logs   *logging.LogWriter
+
+	// done is closed once the run finishes
+	done chan struct{}
+}
+
+// Agent is a long running mtest that starts runs on request & on its
+// schedules. The runs are started one at a time, as the drivers of a
+// node are shared by the runs.
+//
+// Agent is safe to use across multiple goroutines.
+type Agent struct {
+	// The agent's config, over which the config of each run is merged
+	mconfig *config.MtestConfig
+
+	// logOutput gets the logs of all the runs
+	logOutput io.Writer
+	logger    *log.Logger
+
+	m      sync.Mutex
+	runs   map[string]*run
+	order  []string
+	active *run
+
+	// The schedules of the recurring runs & their random jitters
+	schedules []*schedule
+	rand      *rand.Rand
+	started   bool
+	stopCh    chan struct{}
+	wg        sync.WaitGroup
+}
+
+// NewAgent creates an Agent whose runs are based on the config & log to
+// the log output
+func NewAgent(mconfig *config.MtestConfig, logOutput io.Writer) *Agent {
+	if mconfig == nil {
+		mconfig = config.DefaultMtestConfig()
+	}
+
+	return &Agent{
+		mconfig:   mconfig,
+		logOutput: logOutput,
+		logger:    log.New(logOutput, "", log.LstdFlags),
+		runs:      make(map[string]*run),
+		rand:      rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// StartRun starts a run in the background. An error is returned if a
+// run is already in progress or if the run can not be built from the
+// request.
+func (a *Agent) StartRun(req *RunRequest) (*Run, error) {
+	return a.startRun(req, "")
+}
+
+// startRun starts the run of the request that is started by the
+// schedule, if any
+func (a *Agent) startRun(req *RunRequest, schedule string) (*Run, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	// A run is in progress while its Mtest is running. The active run
+	// may still be waited for after its Mtest returns.
+	if a.active != nil && a.active.mt.IsRunning() {
+		return nil, &RunInProgressError{ID: a.active.ID}
+	}
+
+	mconfig, err := a.runConfig(req)
+	if err != nil {
+		return nil, err
+	}
+
+	runner := req.Runner
+	if runner == "" {
+		runner = mtest.MTEST_MSERVER_RUNNER_NAME
+	}
+
+	// The logs of the run are filtered by its log level & are
+	// buffered for the clients that stream them
+	logs := logging.NewLogWriter(DEFAULT_RUN_LOG_BUFFER)
+	filter := logging.LevelFilter()
+	filter.MinLevel = logutils.LogLevel(strings.ToUpper(mconfig.LogLevel))
+	filter.Writer = io.MultiWriter(a.logOutput, logs)
+
+	maker, err := mtest.GetRunner(runner, filter, mconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		return nil, err
+	}
+
+	// The reports are collected by the handle as the use cases
+	// complete, so that the run's progress can be queried
+	h, err := mt.StartAsync()
+	if err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	r := &run{
+		Run: Run{
+			ID:       mtest.NewRunID(started),
+			Runner:   runner,
+			Status:   RUN_STATUS_RUNNING,
+			Started:  started,
+			Schedule: schedule,
+		},
+		mt:     mt,
+		handle: h,
+		logs:   logs,
+		done:   make(chan struct{}),
+	}
+
+	a.runs[r.ID] = r
+	a.order = append(a.order, r.ID)
+	a.active = r
+
+	go a.wait(r)
+
+	return r.status(), nil
+}
+
+// runConfig provides the config of the run of the request
+func (a *Agent) runConfig(req *RunRequest) (*config.MtestConfig, error) {
+	mconfig := a.mconfig
+
+	if req.Config != "" {
+		rconfig, err := config.ParseMtestConfig(strings.NewReader(req.Config))
+		if err != nil {
+			return nil, fmt.Errorf("Error parsing the run's config: %s", err)
+		}
+		mconfig = mconfig.Merge(rconfig)
+	}
+
+	return mconfig.Merge(&config.MtestConfig{
+		Run:  req.Run,
+		Skip: req.Skip,
+		Tags: req.Tags,
+	}), nil
+}
+
+// wait waits for the run to return & records its outcome
+func (a *Agent) wait(r *run) {
+	defer close(r.done)
+
+	_, err := r.handle.Wait()
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	r.Ended = time.Now()
+	r.Status = RUN_STATUS_COMPLETE
+	if err != nil {
+		r.Status = RUN_STATUS_FAILED
+		r.Error = err.Error()
+	}
+
+	if a.active == r {
+		a.active = nil
+	}
+	a.prune()
+}
+
+// prune removes the oldest finished runs beyond their retention, which
+// is per schedule for the scheduled runs. The caller must hold the lock.
+func (a *Agent) prune() {
+	finished := make(map[string]int)
+	for i := len(a.order) - 1; i >= 0; i-- {
+		r := a.runs[a.order[i]]
+		if r == a.active {
+			continue
+		}
+
+		finished[r.Schedule]++
+		if finished[r.Schedule] > a.retention(r.Schedule) {
+			delete(a.runs, r.ID)
+			a.order = append(a.order[:i], a.order[i+1:]...)
+		}
+	}
+}
+
+// status provides a copy of the status of the run. The caller must hold
+// the lock.
+func (r *run) status() *Run {
+	s := r.Run
+	s.Summary = mtest.Summarize(r.handle.Reports())
+	return &s
+}
+
+// Runs provides the status of the runs, the latest first
+func (a *Agent) Runs() []*Run {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	runs := make([]*Run, 0, len(a.runs))
+	for _, r := range a.runs {
+		runs = append(runs, r.status())
+	}
+
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Started.After(runs[j].Started)
+	})
+	return runs
+}
+
+// Run provides the status of the run of the ID
+func (a *Agent) Run(id string) (*Run, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	r, err := a.run(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.status(), nil
+}
+
+// Reports provides the reports of the use cases of the run that
+// completed so far
+func (a *Agent) Reports(id string) ([]*mtest.Report, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	r, err := a.run(id)
+	if err != nil {
+		return nil, err
+	}
+	return r.handle.Reports(), nil
+}
+
+// Logs provides the log writer of the run along with a channel that is
+// closed once the run finishes. The logs are streamed by registering a
+// handler with the log writer.
+func (a *Agent) Logs(id string) (*logging.LogWriter, <-chan struct{}, error) {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	r, err := a.run(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.logs, r.done, nil
+}
+
+// StopRun stops the run & waits for it to finish
+func (a *Agent) StopRun(id string) (*Run, error) {
+	a.m.Lock()
+	r, err := a.run(id)
+	a.m.Unlock()
+
+	if err != nil {
+		return nil, err
+	}
+
+	r.handle.Cancel()
+	<-r.done
+
+	return a.Run(id)
+}
+
+// Shutdown stops the schedules & the run in progress, if any
+func (a *Agent) Shutdown() {
+	a.m.Lock()
+	if a.started {
+		a.started = false
+		close(a.stopCh)
+	}
+	a.m.Unlock()
+
+	// The schedules are stopped first, so that no run is started
+	// after the run in progress is stopped
+	a.wg.Wait()
+
+	a.m.Lock()
+	r := a.active
+	a.m.Unlock()
+
+	if r != nil {
+		r.handle.Cancel()
+		<-r.done
+	}
+}
+
+// run provides the run of the ID. The caller must hold the lock.
+func (a *Agent) run(id string) (*run, error) {
+	r, ok := a.runs[id]
+	if !ok {
+		return nil, &RunNotFoundError{ID: id}
+	}
+	return r, nil
+}
+
+// RunNotFoundError is the error of a run that is not known to the agent
+type RunNotFoundError struct {
+	ID string
+}
+
+func (e *RunNotFoundError) Error() string {
+	return fmt.Sprintf("Run '%s' not found", e.ID)
+}
+
+// RunInProgressError is the error of a run that is started while another
+// run is in progress
+type RunInProgressError struct {
+	ID string
+}
+
+func (e *RunInProgressError) Error() string {
+	return fmt.Sprintf("Run '%s' is already in progress", e.ID)
+}
@@ -0,0 +1,135 @@
+package agent
+
+import (
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/driver/mock"
+	"github.com/openebs/mtest/mtest"
+)
+
+// waitForRun waits for the run to finish
+func waitForRun(t *testing.T, a *Agent, id string) *Run {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		run, err := a.Run(id)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if run.Status != RUN_STATUS_RUNNING {
+			return run
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("run %s did not finish", id)
+	return nil
+}
+
+// waitForReports waits till the run has the no of reports
+func waitForReports(t *testing.T, a *Agent, id string, n int) {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if rpts, _ := a.Reports(id); len(rpts) >= n {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	t.Fatalf("run %s has less than %d reports", id, n)
+}
+
+func TestAgent_StartRun(t *testing.T) {
+	a := NewAgent(nil, ioutil.Discard)
+
+	run, err := a.StartRun(&RunRequest{Config: mock.Config("ok", "fail")})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if run.Runner != mtest.MTEST_MSERVER_RUNNER_NAME || run.Status != RUN_STATUS_RUNNING {
+		t.Fatalf("bad run: %#v", run)
+	}
+
+	run = waitForRun(t, a, run.ID)
+	if run.Status != RUN_STATUS_COMPLETE || run.Ended.IsZero() {
+		t.Fatalf("bad run: %#v", run)
+	}
+	if run.Summary.Total != 2 || run.Summary.Passed != 1 || run.Summary.Failed != 1 {
+		t.Fatalf("bad summary: %#v", run.Summary)
+	}
+
+	rpts, err := a.Reports(run.ID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(rpts) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(rpts))
+	}
+
+	// The use cases are selected by the request
+	run, err = a.StartRun(&RunRequest{Config: mock.Config("ok", "fail"), Skip: "fail"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if run = waitForRun(t, a, run.ID); run.Summary.Total != 1 || run.Summary.Passed != 1 {
+		t.Fatalf("bad summary: %#v", run.Summary)
+	}
+
+	if runs := a.Runs(); len(runs) != 2 || runs[0].ID != run.ID {
+		t.Fatalf("bad runs: %v", runs)
+	}
+}
+
+func TestAgent_StartRun_Invalid(t *testing.T) {
+	a := NewAgent(nil, ioutil.Discard)
+
+	if _, err := a.StartRun(&RunRequest{Config: "scenario {"}); err == nil {
+		t.Fatalf("expected an error of the config")
+	}
+
+	if _, err := a.StartRun(&RunRequest{Runner: "unknown.runner"}); err == nil {
+		t.Fatalf("expected an error of the runner")
+	}
+
+	if _, err := a.Run("unknown"); err == nil {
+		t.Fatalf("expected an error of the run")
+	}
+
+	if len(a.Runs()) != 0 {
+		t.Fatalf("expected no runs, got %v", a.Runs())
+	}
+}
+
+func TestAgent_StopRun(t *testing.T) {
+	a := NewAgent(nil, ioutil.Discard)
+
+	run, err := a.StartRun(&RunRequest{Config: mock.Config("ok", "block")})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// One run is in progress at a time
+	_, err = a.StartRun(&RunRequest{Config: mock.Config("ok")})
+	if _, ok := err.(*RunInProgressError); !ok {
+		t.Fatalf("expected run in progress error, got %v", err)
+	}
+
+	waitForReports(t, a, run.ID, 1)
+
+	run, err = a.StopRun(run.ID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if run.Status == RUN_STATUS_RUNNING || run.Summary.Passed != 1 || run.Summary.Cancelled != 1 {
+		t.Fatalf("bad run: %#v: %#v", run, run.Summary)
+	}
+
+	// A new run can be started once the run is stopped
+	if _, err := a.StartRun(&RunRequest{Config: mock.Config("ok")}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	a.Shutdown()
+}
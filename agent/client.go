@@ -0,0 +1,128 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/openebs/mtest/mtest"
+)
+
+const (
+	// Deadline of each request to an agent, including the wait of a
+	// stop for its run to finish
+	DEFAULT_CLIENT_TIMEOUT = 30 * time.Second
+)
+
+// Client is a client of the API of an mtest agent
+type Client struct {
+	addr       string
+	token      string
+	httpClient *http.Client
+}
+
+// NewClient returns a client of the agent's API at the address, e.g.
+// 10.0.0.2:5757 or http://10.0.0.2:5757. The requests carry the token,
+// if set. Each request is aborted if it does not complete within
+// DEFAULT_CLIENT_TIMEOUT or when its context is done.
+func NewClient(addr, token string) *Client {
+	if !strings.Contains(addr, "://") {
+		addr = "http://" + addr
+	}
+
+	return &Client{
+		addr:       strings.TrimSuffix(addr, "/"),
+		token:      token,
+		httpClient: &http.Client{Timeout: DEFAULT_CLIENT_TIMEOUT},
+	}
+}
+
+// Addr provides the address of the agent's API
+func (c *Client) Addr() string {
+	return c.addr
+}
+
+// StartRun starts a run on the agent
+func (c *Client) StartRun(ctx context.Context, req *RunRequest) (*Run, error) {
+	var run Run
+	if err := c.write(ctx, "POST", "/v1/runs", req, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Run provides the status of the agent's run of the ID
+func (c *Client) Run(ctx context.Context, id string) (*Run, error) {
+	var run Run
+	if err := c.query(ctx, "/v1/runs/"+url.PathEscape(id), &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// Reports provides the reports of the use cases of the run that
+// completed so far
+func (c *Client) Reports(ctx context.Context, id string) ([]*mtest.Report, error) {
+	var reports []*mtest.Report
+	if err := c.query(ctx, "/v1/runs/"+url.PathEscape(id)+"/reports", &reports); err != nil {
+		return nil, err
+	}
+	return reports, nil
+}
+
+// StopRun stops the run & waits for it to finish
+func (c *Client) StopRun(ctx context.Context, id string) (*Run, error) {
+	var run Run
+	if err := c.write(ctx, "DELETE", "/v1/runs/"+url.PathEscape(id), nil, &run); err != nil {
+		return nil, err
+	}
+	return &run, nil
+}
+
+// query is used to do a GET request against an endpoint & deserialize
+// the response into the out object
+func (c *Client) query(ctx context.Context, endpoint string, out interface{}) error {
+	return c.write(ctx, "GET", endpoint, nil, out)
+}
+
+// write is used to do a request with the JSON of the in object, if any,
+// against an endpoint & deserialize the response into the out object.
+// The request is aborted when the context is done.
+func (c *Client) write(ctx context.Context, method, endpoint string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		buf, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(buf)
+	}
+
+	req, err := http.NewRequest(method, c.addr+endpoint, body)
+	if err != nil {
+		return err
+	}
+	if c.token != "" {
+		req.Header.Set(TOKEN_HEADER, c.token)
+	}
+
+	resp, err := c.httpClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("Unexpected response code: %d (%s)", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,45 @@
+package agent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestClient_Context(t *testing.T) {
+	// The agent never responds till the test is done
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		select {
+		case <-done:
+		case <-req.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(done)
+
+	client := NewClient(srv.URL, "")
+	if client.httpClient.Timeout != DEFAULT_CLIENT_TIMEOUT {
+		t.Fatalf("bad timeout: %v", client.httpClient.Timeout)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.Run(ctx, "1")
+		errCh <- err
+	}()
+
+	select {
+	case err := <-errCh:
+		if err == nil {
+			t.Fatalf("expected an error")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("the request was not aborted by its context")
+	}
+}
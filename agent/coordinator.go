@@ -0,0 +1,552 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+	"github.com/openebs/mtest/mtest"
+)
+
+const (
+	// Name of this mtest runner
+	MTEST_COORDINATOR_RUNNER_NAME = "coordinator.runner"
+
+	// Default interval at which the workers are polled for the
+	// progress of their runs
+	DEFAULT_COORDINATOR_POLL_INTERVAL = 2 * time.Second
+
+	// No of consecutive failures to poll a worker after which its run
+	// is given up
+	DEFAULT_COORDINATOR_POLL_FAILURES = 5
+)
+
+// CoordinatorRunner splits the use cases across the mtest agents of
+// several nodes i.e. its workers, gathers the reports of their runs &
+// merges them into a single result.
+//
+// Each worker runs its share of the use cases with its own config, e.g.
+// the EBS driver of a worker is bound to the instance of its node. This
+// lets the use cases exercise several nodes in a single run.
+//
+// The steps of a use case may be placed on different workers, e.g. to
+// attach a volume on one node & then on another. The coordinator then
+// runs each step of the use case on its worker & passes the outputs of
+// the steps, e.g. the EBS IDs of the volumes, on to the later steps.
+type CoordinatorRunner struct {
+	logger *log.Logger
+	mtest.Progress
+
+	// Addresses of the APIs of the workers' agents
+	workers []string
+
+	// Runner that runs the use cases on the workers
+	runner string
+
+	// Token of the APIs of the workers' agents, if any
+	token string
+
+	// Interval at which the workers are polled
+	pollInterval time.Duration
+
+	// Deadline of the entire run, no deadline if 0
+	runTimeout time.Duration
+
+	// The use cases that are split across the workers
+	scenarios []*config.Scenario
+
+	// handler, if set, handles the report of each use case
+	handler mtest.ReportHandler
+}
+
+func init() {
+	// Register by passing the name of this runner
+	// and its factory function definition.
+	mtest.RegisterRunner(MTEST_COORDINATOR_RUNNER_NAME, NewCoordinatorRunMaker)
+}
+
+// NewCoordinatorRunMaker returns an instance of MtestMake that aligns to
+// MtestMaker interface.
+//
+// The use cases are the selected scenarios of the mtest config, the
+// default scenarios if the config does not have any, while the workers
+// & their token are those of the config's coordinator.
+func NewCoordinatorRunMaker(logWriter io.Writer, mconfig *config.MtestConfig) (mtest.MtestMaker, error) {
+
+	if logWriter == nil {
+		return nil, fmt.Errorf("Log writer is required to create a CoordinatorRunner")
+	}
+
+	if mconfig == nil || mconfig.Coordinator == nil || len(mconfig.Coordinator.Workers) == 0 {
+		return nil, fmt.Errorf("At least one worker is required to create a CoordinatorRunner")
+	}
+
+	runner := &CoordinatorRunner{
+		logger:       log.New(logWriter, "", log.LstdFlags|log.Lmicroseconds),
+		workers:      mconfig.Coordinator.Workers,
+		runner:       mconfig.Coordinator.Runner,
+		token:        mconfig.Coordinator.Token,
+		pollInterval: DEFAULT_COORDINATOR_POLL_INTERVAL,
+		scenarios:    mtest.DefaultScenarios(),
+	}
+
+	if runner.runner == "" {
+		runner.runner = mtest.MTEST_MSERVER_RUNNER_NAME
+	}
+	if runner.token == "" {
+		runner.token = os.Getenv(TOKEN_ENV)
+	}
+
+	var err error
+	if mconfig.Coordinator.PollInterval != "" {
+		if runner.pollInterval, err = time.ParseDuration(mconfig.Coordinator.PollInterval); err != nil {
+			return nil, fmt.Errorf("Invalid poll interval '%s': %s", mconfig.Coordinator.PollInterval, err)
+		}
+	}
+
+	if mconfig.RunTimeout != "" {
+		if runner.runTimeout, err = time.ParseDuration(mconfig.RunTimeout); err != nil {
+			return nil, fmt.Errorf("Invalid timeout '%s': %s", mconfig.RunTimeout, err)
+		}
+	}
+
+	if len(mconfig.Scenarios) > 0 {
+		runner.scenarios = mconfig.Scenarios
+	}
+	runner.scenarios = config.ExpandScenarios(runner.scenarios)
+
+	selector, err := mtest.NewSelector(mconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if runner.scenarios = selector.Scenarios(runner.scenarios); len(runner.scenarios) == 0 {
+		return nil, fmt.Errorf("No use cases match the selection %s", selector)
+	}
+
+	for _, s := range runner.scenarios {
+		for _, step := range s.Steps {
+			if step.Worker > len(runner.workers) {
+				return nil, fmt.Errorf("Step '%s' of use case '%s' is placed on worker %d of %d worker(s)",
+					step.Name, s.Name, step.Worker, len(runner.workers))
+			}
+		}
+	}
+
+	return mtest.NewMtestMake(runner), nil
+}
+
+func (r *CoordinatorRunner) Name() string {
+	return MTEST_COORDINATOR_RUNNER_NAME
+}
+
+func (r *CoordinatorRunner) Logger() *log.Logger {
+	return r.logger
+}
+
+// IsParallel is true, as the workers run their use cases at the same
+// time
+func (r *CoordinatorRunner) IsParallel() bool {
+	return true
+}
+
+// SetReportHandler sets the handler which gets the report of each
+// use case as soon as a worker reports it
+func (r *CoordinatorRunner) SetReportHandler(h mtest.ReportHandler) {
+	r.handler = h
+}
+
+// Run runs the use cases on the workers
+func (r *CoordinatorRunner) Run() ([]*mtest.Report, error) {
+	return r.RunContext(context.Background())
+}
+
+// RunContext runs the use cases on the workers. The use cases are split
+// across the workers in their order, i.e. the first use case is run by
+// the first worker, the second by the second & so on. The runs of the
+// workers are stopped when the context is done.
+//
+// The use cases whose steps are placed on the workers are run after the
+// others, one at a time, as an agent runs one run at a time.
+//
+// The reports are placed in the order of the use cases, irrespective of
+// the workers that ran them. A use case is reported as failed if its
+// worker could not run it.
+func (r *CoordinatorRunner) RunContext(ctx context.Context) ([]*mtest.Report, error) {
+	r.Start()
+	defer r.Stop()
+
+	if r.runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.runTimeout)
+		defer cancel()
+	}
+
+	// The indexes of the use cases of each worker & of the use cases
+	// whose steps are placed
+	shards := make([][]int, len(r.workers))
+	var placed []int
+	for i, s := range r.scenarios {
+		if isPlaced(s) {
+			placed = append(placed, i)
+			continue
+		}
+
+		w := i % len(r.workers)
+		shards[w] = append(shards[w], i)
+	}
+
+	r.logger.Printf("[INFO] coordinator: Running %d use case(s) on %d worker(s)", len(r.scenarios), len(r.workers))
+
+	// The handler is invoked from the goroutines of the workers
+	var m sync.Mutex
+	handle := func(rpt *mtest.Report) {
+		if r.handler != nil {
+			m.Lock()
+			defer m.Unlock()
+			r.handler(rpt)
+		}
+	}
+
+	reports := make([]*mtest.Report, len(r.scenarios))
+	var wg sync.WaitGroup
+	for w, shard := range shards {
+		if len(shard) == 0 {
+			continue
+		}
+
+		wg.Add(1)
+		go func(addr string, shard []int) {
+			defer wg.Done()
+
+			scenarios := make([]*config.Scenario, 0, len(shard))
+			for _, i := range shard {
+				scenarios = append(scenarios, r.scenarios[i])
+			}
+
+			for j, rpt := range r.runOn(ctx, addr, scenarios, handle) {
+				reports[shard[j]] = rpt
+			}
+		}(r.workers[w], shard)
+	}
+	wg.Wait()
+
+	for _, i := range placed {
+		reports[i] = r.runPlaced(ctx, r.scenarios[i], r.workers[i%len(r.workers)])
+		handle(reports[i])
+	}
+
+	return reports, nil
+}
+
+// runPlaced runs the use case whose steps are placed on the workers. Its
+// steps are executed in their order, each as a run of the step alone on
+// its worker, else on the worker of the address.
+func (r *CoordinatorRunner) runPlaced(ctx context.Context, s *config.Scenario, addr string) *mtest.Report {
+	var used []string
+	exec := mtest.NewScenarioExec(r.runner, r.logger)
+	exec.StepExecutor = func(step *config.Step) (driver.Executor, error) {
+		w := addr
+		if step.Worker > 0 {
+			w = r.workers[step.Worker-1]
+		}
+
+		if len(used) == 0 || used[len(used)-1] != w {
+			used = append(used, w)
+		}
+
+		return &stepExecutor{
+			r:       r,
+			addr:    w,
+			client:  NewClient(w, r.token),
+			usecase: s.Name,
+			step:    step,
+		}, nil
+	}
+
+	r.logger.Printf("[INFO] coordinator: Running use case '%s' step by step on the workers", s.Name)
+	rpt := exec.Run(ctx, s)
+	rpt.Worker = strings.Join(used, ",")
+
+	return rpt
+}
+
+// runOn runs the use cases on the worker of the address. The reports are
+// provided in the order of the use cases.
+func (r *CoordinatorRunner) runOn(ctx context.Context, addr string, scenarios []*config.Scenario, handle mtest.ReportHandler) []*mtest.Report {
+	started := time.Now()
+	client := NewClient(addr, r.token)
+
+	run, err := client.StartRun(ctx, &RunRequest{
+		Runner: r.runner,
+		Config: config.FormatScenarios(scenarios),
+		Run:    usecasesPattern(scenarios),
+	})
+	if err != nil {
+		r.logger.Printf("[ERR] coordinator: Failed to start the run on worker %s: %v", addr, err)
+		return r.unreported(addr, scenarios, nil, started, mtest.STATUS_FAILED,
+			fmt.Sprintf("Failed to start the run on worker %s: %v", addr, err))
+	}
+
+	id := run.ID
+	r.logger.Printf("[INFO] coordinator: Started run '%s' of %d use case(s) on worker %s", id, len(scenarios), addr)
+
+	// The reports of the use cases by their names. The reports that
+	// were handled are tracked, as the reports of a run may be
+	// reordered once it completes.
+	byName := make(map[string]*mtest.Report)
+	handled := make(map[string]bool)
+	collect := func(rpts []*mtest.Report) {
+		for _, rpt := range rpts {
+			rpt.Worker = addr
+			byName[rpt.Usecase] = rpt
+
+			key := rpt.Usecase + "@" + rpt.Started.String()
+			if !handled[key] {
+				handled[key] = true
+				handle(rpt)
+			}
+		}
+	}
+
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	// The requests once the run is stopped are made with a context of
+	// their own, as the run's context is done by then. They are bound
+	// by the client's timeout.
+	reqCtx := ctx
+
+	var runErr string
+	stopped := false
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			r.logger.Printf("[WARN] coordinator: Stopping run '%s' on worker %s", id, addr)
+			stopped = true
+			reqCtx = context.Background()
+			run, err = client.StopRun(reqCtx, id)
+		case <-ticker.C:
+			run, err = client.Run(reqCtx, id)
+		}
+
+		if err != nil {
+			// A poll that is aborted by the context is not a
+			// failure of the worker, as the run is stopped next
+			if !stopped && ctx.Err() != nil {
+				continue
+			}
+
+			failures++
+			r.logger.Printf("[WARN] coordinator: Failed to poll worker %s: %v", addr, err)
+			if stopped || failures >= DEFAULT_COORDINATOR_POLL_FAILURES {
+				runErr = fmt.Sprintf("Lost the run on worker %s: %v", addr, err)
+				break
+			}
+			continue
+		}
+		failures = 0
+
+		// The reports are fetched after the status, so that all
+		// the reports of a finished run are collected
+		if rpts, err := client.Reports(reqCtx, id); err != nil {
+			r.logger.Printf("[WARN] coordinator: Failed to get the reports of worker %s: %v", addr, err)
+		} else {
+			collect(rpts)
+		}
+
+		if run.Status != RUN_STATUS_RUNNING {
+			runErr = run.Error
+			break
+		}
+	}
+
+	status := mtest.STATUS_FAILED
+	if stopped {
+		status = mtest.STATUS_CANCELLED
+	}
+	if runErr == "" {
+		runErr = fmt.Sprintf("Worker %s did not report the use case", addr)
+	}
+
+	reports := r.unreported(addr, scenarios, byName, started, status, runErr)
+	r.logger.Printf("[INFO] coordinator: Worker %s: %s", addr, mtest.Summarize(reports))
+
+	for _, rpt := range reports {
+		if byName[rpt.Usecase] != rpt {
+			handle(rpt)
+		}
+	}
+
+	return reports
+}
+
+// unreported provides the reports of the use cases, where a use case
+// that the worker did not report gets a report of the status & message.
+// Such a report is of the workers' runner, as are the reports of the
+// workers, so that the reports of a use case are grouped as one across
+// the runs.
+func (r *CoordinatorRunner) unreported(addr string, scenarios []*config.Scenario, byName map[string]*mtest.Report,
+	started time.Time, status, msg string) []*mtest.Report {
+
+	reports := make([]*mtest.Report, 0, len(scenarios))
+	for _, s := range scenarios {
+		if rpt, ok := byName[s.Name]; ok {
+			reports = append(reports, rpt)
+			continue
+		}
+
+		ended := time.Now()
+		reports = append(reports, &mtest.Report{
+			Runner:   r.runner,
+			Usecase:  s.Name,
+			Message:  msg,
+			Status:   status,
+			Started:  started,
+			Ended:    ended,
+			Duration: ended.Sub(started),
+			Worker:   addr,
+		})
+	}
+
+	return reports
+}
+
+// isPlaced is true if any step of the use case is placed on a worker
+func isPlaced(s *config.Scenario) bool {
+	for _, step := range s.Steps {
+		if step.Worker > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// stepExecutor is a driver.Executor that executes the step of a use case
+// as a run of the step alone on a worker. The step's request is resolved
+// by the coordinator, while its attempts, deadline & assertions are
+// taken care of by the coordinator's scenario executor.
+type stepExecutor struct {
+	r       *CoordinatorRunner
+	addr    string
+	client  *Client
+	usecase string
+	step    *config.Step
+}
+
+func (e *stepExecutor) Exec(req driver.Request) (*driver.Response, error) {
+	return e.ExecContext(context.Background(), req)
+}
+
+// ExecContext runs the step on the worker & provides the outputs of the
+// step as the values of the response. The run is stopped when the context
+// is done.
+func (e *stepExecutor) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
+	addr := e.addr
+	s := &config.Scenario{
+		Name: e.usecase + "/" + e.step.Name,
+		Steps: []*config.Step{
+			{
+				Name:     e.step.Name,
+				Driver:   e.step.Driver,
+				Executor: e.step.Executor,
+				Request:  req.Name,
+				Options:  req.Options,
+			},
+		},
+	}
+
+	run, err := e.client.StartRun(ctx, &RunRequest{
+		Runner: e.r.runner,
+		Config: config.FormatScenarios([]*config.Scenario{s}),
+		Run:    usecasesPattern([]*config.Scenario{s}),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start the run on worker %s: %v", addr, err)
+	}
+
+	ticker := time.NewTicker(e.r.pollInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	for run.Status == RUN_STATUS_RUNNING {
+		select {
+		case <-ctx.Done():
+			// The run is stopped with a context of its own, as the
+			// step's context is done by then
+			if _, err := e.client.StopRun(context.Background(), run.ID); err != nil {
+				e.r.logger.Printf("[WARN] coordinator: Failed to stop run '%s' on worker %s: %v", run.ID, addr, err)
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		latest, err := e.client.Run(ctx, run.ID)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+
+			failures++
+			e.r.logger.Printf("[WARN] coordinator: Failed to poll worker %s: %v", addr, err)
+			if failures >= DEFAULT_COORDINATOR_POLL_FAILURES {
+				return nil, fmt.Errorf("Lost the run on worker %s: %v", addr, err)
+			}
+			continue
+		}
+		failures = 0
+		run = latest
+	}
+
+	rpts, err := e.client.Reports(ctx, run.ID)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to get the reports of worker %s: %v", addr, err)
+	}
+	if len(rpts) != 1 {
+		if run.Error != "" {
+			return nil, fmt.Errorf("worker %s: %s", addr, run.Error)
+		}
+		return nil, fmt.Errorf("Worker %s did not report the step", addr)
+	}
+
+	// The failure of the step is reported by the scenario executor
+	// as that of the step, hence its prefix is dropped
+	rpt := rpts[0]
+	if !rpt.Success {
+		msg := strings.TrimPrefix(fmt.Sprint(rpt.Message), fmt.Sprintf("step '%s': ", e.step.Name))
+		return nil, fmt.Errorf("worker %s: %s", addr, msg)
+	}
+
+	// The output of the step less its request name, which is added
+	// back by the scenario executor
+	outputs, _ := rpt.Message.(map[string]interface{})
+	out, _ := outputs[e.step.Name].(map[string]interface{})
+
+	values := make(map[string]interface{}, len(out))
+	for k, v := range out {
+		if k != "Name" {
+			values[k] = v
+		}
+	}
+
+	return &driver.Response{Values: values}, nil
+}
+
+// usecasesPattern provides the run pattern that selects exactly the use
+// cases of the scenarios
+func usecasesPattern(scenarios []*config.Scenario) string {
+	names := make([]string, 0, len(scenarios))
+	for _, s := range scenarios {
+		names = append(names, regexp.QuoteMeta(s.Name))
+	}
+	return "^(?:" + strings.Join(names, "|") + ")$"
+}
@@ -0,0 +1,360 @@
+package agent
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver/mock"
+	"github.com/openebs/mtest/mtest"
+)
+
+// newWorkers serves the API of an agent per worker on the loopback &
+// provides the agents along with their addresses. The APIs require the
+// token, if set.
+func newWorkers(t *testing.T, n int, token string) ([]*Agent, []string) {
+	var agents []*Agent
+	var addrs []string
+	for i := 0; i < n; i++ {
+		a := NewAgent(nil, ioutil.Discard)
+		srv, err := NewHTTPServer(a, "127.0.0.1:0", token, log.New(ioutil.Discard, "", 0))
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		t.Cleanup(func() {
+			srv.Shutdown()
+			a.Shutdown()
+		})
+
+		agents = append(agents, a)
+		addrs = append(addrs, srv.Addr)
+	}
+
+	return agents, addrs
+}
+
+// newCoordinator provides the mtest of a coordinator of the mock
+// scenarios & the workers
+func newCoordinator(t *testing.T, workers []string, hints ...string) *mtest.Mtest {
+	return newCoordinatorOf(t, mock.Config(hints...)+coordinatorConfig(workers, ""))
+}
+
+// coordinatorConfig provides the coordinator block of the workers & the
+// token, if set
+func coordinatorConfig(workers []string, token string) string {
+	quoted := make([]string, 0, len(workers))
+	for _, w := range workers {
+		quoted = append(quoted, fmt.Sprintf("%q", w))
+	}
+
+	block := fmt.Sprintf("coordinator {\n  workers = [%s]\n  poll_interval = \"10ms\"\n", strings.Join(quoted, ", "))
+	if token != "" {
+		block += fmt.Sprintf("  token = %q\n", token)
+	}
+	return block + "}\n"
+}
+
+// newCoordinatorOf provides the mtest of a coordinator of the config
+func newCoordinatorOf(t *testing.T, hcl string) *mtest.Mtest {
+	mconfig, err := config.ParseMtestConfig(strings.NewReader(hcl))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	maker, err := mtest.GetRunner(MTEST_COORDINATOR_RUNNER_NAME, ioutil.Discard, mconfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	return mt
+}
+
+func TestCoordinatorRunner(t *testing.T) {
+	agents, workers := newWorkers(t, 2, "")
+	mt := newCoordinator(t, workers, "ok", "fail", "ok2", "ok3", "ok4")
+
+	var m sync.Mutex
+	var handled []*mtest.Report
+	mt.SetReportHandler(func(r *mtest.Report) {
+		m.Lock()
+		defer m.Unlock()
+		handled = append(handled, r)
+	})
+
+	reports, err := mt.Start()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The reports are merged in the order of the use cases, which are
+	// split across the workers in turns
+	expected := []struct {
+		Usecase string
+		Worker  string
+		Success bool
+	}{
+		{"ok", workers[0], true},
+		{"fail", workers[1], false},
+		{"ok2", workers[0], true},
+		{"ok3", workers[1], true},
+		{"ok4", workers[0], true},
+	}
+	if len(reports) != len(expected) {
+		t.Fatalf("expected %d reports, got %d", len(expected), len(reports))
+	}
+	for i, e := range expected {
+		r := reports[i]
+		if r.Usecase != e.Usecase || r.Worker != e.Worker || r.Success != e.Success || r.Runner != mtest.MTEST_MSERVER_RUNNER_NAME {
+			t.Fatalf("bad report %d: %#v", i, r)
+		}
+	}
+
+	if len(handled) != len(expected) {
+		t.Fatalf("expected %d reports to be handled, got %d", len(expected), len(handled))
+	}
+
+	// Each worker ran its share of the use cases
+	for i, a := range agents {
+		runs := a.Runs()
+		if len(runs) != 1 || runs[0].Status != RUN_STATUS_COMPLETE {
+			t.Fatalf("bad runs of worker %d: %#v", i, runs)
+		}
+		if total := runs[0].Summary.Total; total != 3-i {
+			t.Fatalf("expected worker %d to run %d use case(s), got %d", i, 3-i, total)
+		}
+	}
+}
+
+func TestCoordinatorRunner_UnreachableWorker(t *testing.T) {
+	_, workers := newWorkers(t, 1, "")
+
+	// Nothing listens on the address of the second worker
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	workers = append(workers, ln.Addr().String())
+	ln.Close()
+
+	reports, err := newCoordinator(t, workers, "ok", "ok2").Start()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reports[0].Success || reports[0].Worker != workers[0] {
+		t.Fatalf("bad report: %#v", reports[0])
+	}
+
+	r := reports[1]
+	if r.Success || r.Status != mtest.STATUS_FAILED || r.Runner != mtest.MTEST_MSERVER_RUNNER_NAME ||
+		r.Worker != workers[1] || !strings.Contains(fmt.Sprint(r.Message), "Failed to start the run on worker") {
+		t.Fatalf("bad report: %#v", r)
+	}
+}
+
+func TestCoordinatorRunner_Stop(t *testing.T) {
+	agents, workers := newWorkers(t, 2, "")
+	mt := newCoordinator(t, workers, "block", "ok")
+
+	type result struct {
+		reports []*mtest.Report
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		reports, err := mt.Start()
+		done <- result{reports, err}
+	}()
+
+	// The coordinator is stopped once the second worker is done & the
+	// first is blocked
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		if time.Now().After(deadline) {
+			t.Fatalf("the workers did not start their runs")
+		}
+		if runs := agents[1].Runs(); len(runs) == 1 && runs[0].Status == RUN_STATUS_COMPLETE && len(agents[0].Runs()) == 1 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	mt.Stop()
+
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("err: %s", res.err)
+	}
+
+	if r := res.reports[0]; r.Status != mtest.STATUS_CANCELLED || r.Worker != workers[0] {
+		t.Fatalf("expected the blocked use case to be cancelled, got %#v", r)
+	}
+	if !res.reports[1].Success {
+		t.Fatalf("bad report: %#v", res.reports[1])
+	}
+
+	// The run of the worker is stopped
+	if run := agents[0].Runs()[0]; run.Status != RUN_STATUS_COMPLETE || run.Summary.Cancelled != 1 {
+		t.Fatalf("expected the worker's run to be stopped, got %#v", run)
+	}
+}
+
+// placedConfig provides a use case that creates a volume on the first
+// worker & attaches it on the second via the step's executor
+func placedConfig(attach string) string {
+	return fmt.Sprintf(`
+scenario "span" {
+  step "create" {
+    driver   = %q
+    executor = "create"
+    name     = "vol1"
+    worker   = 1
+    options {
+      Zone = "a"
+    }
+  }
+
+  step "attach" {
+    driver   = %q
+    executor = %q
+    name     = "${create.Name}"
+    worker   = 2
+    options {
+      From = "${create.Hint}"
+      Zone = "${create.Zone}"
+    }
+  }
+}
+`, mock.DRIVER_NAME, mock.DRIVER_NAME, attach)
+}
+
+func TestCoordinatorRunner_PlacedSteps(t *testing.T) {
+	agents, workers := newWorkers(t, 2, "")
+
+	var handled []*mtest.Report
+	mt := newCoordinatorOf(t, placedConfig("attach")+mock.Config("ok")+coordinatorConfig(workers, ""))
+	mt.SetReportHandler(func(r *mtest.Report) {
+		handled = append(handled, r)
+	})
+
+	reports, err := mt.Start()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The use case ran its steps on both the workers, after the use
+	// case that was dealt to the second worker
+	r := reports[0]
+	if !r.Success || r.Usecase != "span" || r.Runner != mtest.MTEST_MSERVER_RUNNER_NAME || r.Worker != workers[0]+","+workers[1] {
+		t.Fatalf("bad report: %#v", r)
+	}
+	if !reports[1].Success || reports[1].Worker != workers[1] {
+		t.Fatalf("bad report: %#v", reports[1])
+	}
+	if len(handled) != 2 {
+		t.Fatalf("expected 2 reports to be handled, got %d", len(handled))
+	}
+
+	// The outputs of the step on the first worker were passed on to
+	// the step on the second
+	attach := r.Message.(map[string]interface{})["attach"].(map[string]interface{})
+	if attach["Name"] != "vol1" || attach["From"] != "create" || attach["Zone"] != "a" || attach["Hint"] != "attach" {
+		t.Fatalf("bad output: %#v", attach)
+	}
+
+	expected := [][]string{{"span/create"}, {"ok", "span/attach"}}
+	for i, a := range agents {
+		var usecases []string
+		for _, run := range a.Runs() {
+			rpts, err := a.Reports(run.ID)
+			if err != nil {
+				t.Fatalf("err: %s", err)
+			}
+			for _, rpt := range rpts {
+				usecases = append(usecases, rpt.Usecase)
+			}
+		}
+		sort.Strings(usecases)
+		if !reflect.DeepEqual(usecases, expected[i]) {
+			t.Fatalf("bad use cases of worker %d: %v", i, usecases)
+		}
+	}
+}
+
+func TestCoordinatorRunner_PlacedStepFails(t *testing.T) {
+	_, workers := newWorkers(t, 2, "")
+
+	reports, err := newCoordinatorOf(t, placedConfig("fail")+coordinatorConfig(workers, "")).Start()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	r := reports[0]
+	if r.Success || r.Status != mtest.STATUS_FAILED || r.Request.Name != "vol1" ||
+		!strings.Contains(fmt.Sprint(r.Message), "worker "+workers[1]) || !strings.Contains(fmt.Sprint(r.Message), "mock failure") {
+		t.Fatalf("bad report: %#v", r)
+	}
+}
+
+func TestCoordinatorRunner_Token(t *testing.T) {
+	_, workers := newWorkers(t, 1, "secret")
+
+	reports, err := newCoordinatorOf(t, mock.Config("ok")+coordinatorConfig(workers, "secret")).Start()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reports[0].Success {
+		t.Fatalf("bad report: %#v", reports[0])
+	}
+
+	// The worker refuses a coordinator without its token
+	reports, err = newCoordinatorOf(t, mock.Config("ok")+coordinatorConfig(workers, "")).Start()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if r := reports[0]; r.Success || !strings.Contains(fmt.Sprint(r.Message), "403") {
+		t.Fatalf("bad report: %#v", r)
+	}
+}
+
+func TestCoordinatorRunner_Invalid(t *testing.T) {
+	_, err := NewCoordinatorRunMaker(ioutil.Discard, config.DefaultMtestConfig())
+	if err == nil || !strings.Contains(err.Error(), "At least one worker") {
+		t.Fatalf("expected an error of the workers, got %v", err)
+	}
+
+	_, err = NewCoordinatorRunMaker(ioutil.Discard, &config.MtestConfig{
+		Coordinator: &config.Coordinator{Workers: []string{"a"}},
+		Run:         "^none$",
+	})
+	if err == nil || !strings.Contains(err.Error(), "No use cases match") {
+		t.Fatalf("expected an error of the selection, got %v", err)
+	}
+
+	mconfig, err := config.ParseMtestConfig(strings.NewReader(placedConfig("attach") + coordinatorConfig([]string{"a"}, "")))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	_, err = NewCoordinatorRunMaker(ioutil.Discard, mconfig)
+	if err == nil || !strings.Contains(err.Error(), "is placed on worker 2 of 1 worker(s)") {
+		t.Fatalf("expected an error of the placement, got %v", err)
+	}
+}
+
+func TestUsecasesPattern(t *testing.T) {
+	pattern := usecasesPattern([]*config.Scenario{{Name: "a"}, {Name: "vol[type=gp2]"}})
+	if pattern != `^(?:a|vol\[type=gp2\])$` {
+		t.Fatalf("bad pattern: %s", pattern)
+	}
+}
@@ -0,0 +1,315 @@
+package agent
+
+// This is an adaptation of Hashicorp's Nomad library
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strings"
+)
+
+const (
+	// DEFAULT_HTTP_ADDR is the local address on which the agent's API
+	// is served by default
+	DEFAULT_HTTP_ADDR = "127.0.0.1:5757"
+
+	// TOKEN_HEADER is the header of a request that carries the token
+	// of the agent's API
+	TOKEN_HEADER = "X-Mtest-Token"
+
+	// TOKEN_ENV is the environment variable of the token of the agent's
+	// API, if the token is not set otherwise
+	TOKEN_ENV = "MTEST_AGENT_TOKEN"
+
+	// Size of the channel of the log lines of a client that streams
+	// the logs of a run. The lines that overflow it are dropped, as a
+	// slow client should not block the run.
+	logStreamBuffer = 2 * DEFAULT_RUN_LOG_BUFFER
+)
+
+// HTTPServer serves the API of the agent
+//
+//	POST   /v1/runs               starts a run of a RunRequest
+//	GET    /v1/runs               lists the runs
+//	GET    /v1/runs/<id>          provides the status of a run
+//	DELETE /v1/runs/<id>          stops a run
+//	GET    /v1/runs/<id>/reports  provides the reports of a run
+//	GET    /v1/runs/<id>/logs     streams the logs of a run
+//	GET    /v1/schedules          lists the schedules & their runs
+//
+// A request is refused unless it carries the token of the server, if
+// the server has one, in its TOKEN_HEADER.
+type HTTPServer struct {
+	agent    *Agent
+	mux      *http.ServeMux
+	listener net.Listener
+	logger   *log.Logger
+
+	// token, if set, is required of every request
+	token string
+
+	// Addr is the address that the server listens on
+	Addr string
+}
+
+// NewHTTPServer starts serving the API of the agent on the address. The
+// requests must carry the token, unless it is empty.
+func NewHTTPServer(agent *Agent, addr, token string, logger *log.Logger) (*HTTPServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to start HTTP listener: %v", err)
+	}
+
+	mux := http.NewServeMux()
+	srv := &HTTPServer{
+		agent:    agent,
+		mux:      mux,
+		listener: ln,
+		logger:   logger,
+		token:    token,
+		Addr:     ln.Addr().String(),
+	}
+	srv.registerHandlers()
+
+	go http.Serve(ln, mux)
+
+	return srv, nil
+}
+
+// Shutdown is used to shutdown the HTTP server
+func (s *HTTPServer) Shutdown() {
+	if s != nil {
+		s.logger.Printf("[DEBUG] http: Shutting down http server")
+		s.listener.Close()
+	}
+}
+
+// registerHandlers is used to attach our handlers to the mux
+func (s *HTTPServer) registerHandlers() {
+	s.mux.HandleFunc("/v1/runs", s.wrap(s.RunsRequest))
+	s.mux.HandleFunc("/v1/runs/", s.wrap(s.RunSpecificRequest))
+	s.mux.HandleFunc("/v1/schedules", s.wrap(s.SchedulesRequest))
+}
+
+// IsLoopbackAddr is true if the address, i.e. host:port, is on the
+// loopback interface only. An address without a host is on all the
+// interfaces.
+func IsLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// HTTPCodedError is used to provide the HTTP error code
+type HTTPCodedError interface {
+	error
+	Code() int
+}
+
+// CodedError provides an error with the HTTP error code
+func CodedError(c int, s string) HTTPCodedError {
+	return &codedError{s, c}
+}
+
+type codedError struct {
+	s    string
+	code int
+}
+
+func (e *codedError) Error() string {
+	return e.s
+}
+
+func (e *codedError) Code() int {
+	return e.code
+}
+
+// wrap is used to wrap functions to make them more convenient
+func (s *HTTPServer) wrap(handler func(resp http.ResponseWriter, req *http.Request) (interface{}, error)) func(resp http.ResponseWriter, req *http.Request) {
+	f := func(resp http.ResponseWriter, req *http.Request) {
+		// Invoke the handler of an authorized request only
+		var obj interface{}
+		var err error
+		if s.authorized(req) {
+			obj, err = handler(resp, req)
+		} else {
+			err = CodedError(403, "Permission denied")
+		}
+
+		if err != nil {
+			s.logger.Printf("[ERR] http: Request %v %v, error: %v", req.Method, req.URL, err)
+
+			code := http.StatusInternalServerError
+			switch e := err.(type) {
+			case HTTPCodedError:
+				code = e.Code()
+			case *RunNotFoundError:
+				code = http.StatusNotFound
+			case *RunInProgressError:
+				code = http.StatusConflict
+			}
+
+			resp.WriteHeader(code)
+			resp.Write([]byte(err.Error()))
+			return
+		}
+
+		// Write out the JSON object
+		if obj != nil {
+			resp.Header().Set("Content-Type", "application/json")
+
+			enc := json.NewEncoder(resp)
+			if _, ok := req.URL.Query()["pretty"]; ok {
+				enc.SetIndent("", "    ")
+			}
+			enc.Encode(obj)
+		}
+	}
+	return f
+}
+
+// authorized is true if the request carries the server's token, or if
+// the server does not have one
+func (s *HTTPServer) authorized(req *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	return subtle.ConstantTimeCompare([]byte(req.Header.Get(TOKEN_HEADER)), []byte(s.token)) == 1
+}
+
+// RunsRequest lists the runs or starts a run
+func (s *HTTPServer) RunsRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	switch req.Method {
+	case "GET":
+		return s.agent.Runs(), nil
+	case "PUT", "POST":
+		return s.runStart(resp, req)
+	default:
+		return nil, CodedError(405, "Invalid method")
+	}
+}
+
+func (s *HTTPServer) runStart(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	var args RunRequest
+	if err := json.NewDecoder(req.Body).Decode(&args); err != nil {
+		return nil, CodedError(400, fmt.Sprintf("Invalid run request: %s", err))
+	}
+
+	run, err := s.agent.StartRun(&args)
+	if err != nil {
+		if _, ok := err.(*RunInProgressError); ok {
+			return nil, err
+		}
+		return nil, CodedError(400, err.Error())
+	}
+
+	return run, nil
+}
+
+// RunSpecificRequest serves the status, reports & logs of a run & stops
+// the run
+func (s *HTTPServer) RunSpecificRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	path := strings.TrimPrefix(req.URL.Path, "/v1/runs/")
+	switch {
+	case strings.HasSuffix(path, "/reports"):
+		if req.Method != "GET" {
+			return nil, CodedError(405, "Invalid method")
+		}
+		return s.agent.Reports(strings.TrimSuffix(path, "/reports"))
+
+	case strings.HasSuffix(path, "/logs"):
+		if req.Method != "GET" {
+			return nil, CodedError(405, "Invalid method")
+		}
+		return nil, s.runLogs(resp, req, strings.TrimSuffix(path, "/logs"))
+
+	case path == "" || strings.Contains(path, "/"):
+		return nil, CodedError(404, "Invalid path")
+	}
+
+	switch req.Method {
+	case "GET":
+		return s.agent.Run(path)
+	case "DELETE":
+		return s.agent.StopRun(path)
+	default:
+		return nil, CodedError(405, "Invalid method")
+	}
+}
+
+// runLogs streams the logs of the run as plain text, starting with its
+// last buffered logs. The stream ends once the run finishes or the
+// client goes away.
+func (s *HTTPServer) runLogs(resp http.ResponseWriter, req *http.Request, id string) error {
+	logs, done, err := s.agent.Logs(id)
+	if err != nil {
+		return err
+	}
+
+	resp.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	flusher, _ := resp.(http.Flusher)
+
+	stream := &logStream{
+		lines: make(chan string, logStreamBuffer),
+	}
+	logs.RegisterHandler(stream)
+	defer logs.DeregisterHandler(stream)
+
+	write := func(line string) {
+		resp.Write([]byte(line + "\n"))
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	for {
+		select {
+		case line := <-stream.lines:
+			write(line)
+		case <-done:
+			// Send the logs that are left
+			for {
+				select {
+				case line := <-stream.lines:
+					write(line)
+				default:
+					return nil
+				}
+			}
+		case <-req.Context().Done():
+			return nil
+		}
+	}
+}
+
+// logStream is a logging.LogHandler that passes the log lines to a
+// client that streams them
+type logStream struct {
+	lines chan string
+}
+
+// HandleLog passes the log line on, unless the client lags behind
+func (l *logStream) HandleLog(line string) {
+	select {
+	case l.lines <- line:
+	default:
+	}
+}
+
+// SchedulesRequest lists the schedules
+func (s *HTTPServer) SchedulesRequest(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if req.Method != "GET" {
+		return nil, CodedError(405, "Invalid method")
+	}
+	return s.agent.Schedules(), nil
+}
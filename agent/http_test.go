@@ -0,0 +1,212 @@
+package agent
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mtest/driver/mock"
+	"github.com/openebs/mtest/mtest"
+)
+
+func newTestHTTPServer(t *testing.T) (*HTTPServer, string) {
+	a := NewAgent(nil, ioutil.Discard)
+
+	srv, err := NewHTTPServer(a, "127.0.0.1:0", "", log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	t.Cleanup(func() {
+		srv.Shutdown()
+		a.Shutdown()
+	})
+
+	return srv, "http://" + srv.Addr
+}
+
+// request sends the request & decodes its JSON response into out, if
+// set. The status code of the response is returned.
+func request(t *testing.T, method, url string, in, out interface{}) int {
+	var body bytes.Buffer
+	if in != nil {
+		if err := json.NewEncoder(&body).Encode(in); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	req, err := http.NewRequest(method, url, &body)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if out != nil && resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	return resp.StatusCode
+}
+
+func TestHTTPServer_Runs(t *testing.T) {
+	srv, addr := newTestHTTPServer(t)
+
+	var run Run
+	if code := request(t, "POST", addr+"/v1/runs", &RunRequest{Config: mock.Config("ok", "fail")}, &run); code != 200 {
+		t.Fatalf("bad code: %d", code)
+	}
+
+	// The logs are streamed till the run finishes
+	resp, err := http.Get(addr + "/v1/runs/" + run.ID + "/logs")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	logs, err := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !strings.Contains(string(logs), "[INFO]") {
+		t.Fatalf("expected the logs of the run, got %q", logs)
+	}
+
+	if code := request(t, "GET", addr+"/v1/runs/"+run.ID, nil, &run); code != 200 {
+		t.Fatalf("bad code: %d", code)
+	}
+	if run.Status != RUN_STATUS_COMPLETE || run.Summary.Total != 2 || run.Summary.Failed != 1 {
+		t.Fatalf("bad run: %#v", run)
+	}
+
+	var rpts []*mtest.Report
+	if code := request(t, "GET", addr+"/v1/runs/"+run.ID+"/reports", nil, &rpts); code != 200 {
+		t.Fatalf("bad code: %d", code)
+	}
+	if len(rpts) != 2 {
+		t.Fatalf("expected 2 reports, got %d", len(rpts))
+	}
+
+	var runs []*Run
+	if code := request(t, "GET", addr+"/v1/runs?pretty", nil, &runs); code != 200 {
+		t.Fatalf("bad code: %d", code)
+	}
+	if len(runs) != 1 || runs[0].ID != run.ID {
+		t.Fatalf("bad runs: %v", runs)
+	}
+
+	if srv.Addr == "" {
+		t.Fatalf("expected the address of the server")
+	}
+}
+
+func TestHTTPServer_Errors(t *testing.T) {
+	_, addr := newTestHTTPServer(t)
+
+	cases := []struct {
+		Method string
+		Path   string
+		In     interface{}
+		Code   int
+	}{
+		{"GET", "/v1/runs/unknown", nil, 404},
+		{"GET", "/v1/runs/unknown/reports", nil, 404},
+		{"GET", "/v1/runs/unknown/logs", nil, 404},
+		{"DELETE", "/v1/runs/unknown", nil, 404},
+		{"GET", "/v1/runs/a/b", nil, 404},
+		{"PATCH", "/v1/runs", nil, 405},
+		{"POST", "/v1/runs", "not a run request", 400},
+		{"POST", "/v1/runs", &RunRequest{Runner: "unknown.runner"}, 400},
+	}
+
+	for _, tc := range cases {
+		if code := request(t, tc.Method, addr+tc.Path, tc.In, nil); code != tc.Code {
+			t.Fatalf("%s %s: expected code %d, got %d", tc.Method, tc.Path, tc.Code, code)
+		}
+	}
+}
+
+func TestHTTPServer_StopRun(t *testing.T) {
+	_, addr := newTestHTTPServer(t)
+
+	var run Run
+	if code := request(t, "POST", addr+"/v1/runs", &RunRequest{Config: mock.Config("block")}, &run); code != 200 {
+		t.Fatalf("bad code: %d", code)
+	}
+
+	if code := request(t, "POST", addr+"/v1/runs", &RunRequest{Config: mock.Config("ok")}, nil); code != 409 {
+		t.Fatalf("expected a conflict, got %d", code)
+	}
+
+	if code := request(t, "DELETE", addr+"/v1/runs/"+run.ID, nil, &run); code != 200 {
+		t.Fatalf("bad code: %d", code)
+	}
+	if run.Status != RUN_STATUS_COMPLETE || run.Summary.Cancelled != 1 {
+		t.Fatalf("bad run: %#v", run)
+	}
+}
+
+func TestHTTPServer_Token(t *testing.T) {
+	a := NewAgent(nil, ioutil.Discard)
+	srv, err := NewHTTPServer(a, "127.0.0.1:0", "secret", log.New(ioutil.Discard, "", 0))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer a.Shutdown()
+	defer srv.Shutdown()
+
+	cases := []struct {
+		Token string
+		Code  int
+	}{
+		{"", 403},
+		{"wrong", 403},
+		{"secret", 200},
+	}
+
+	for _, tc := range cases {
+		req, err := http.NewRequest("GET", "http://"+srv.Addr+"/v1/runs", nil)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		if tc.Token != "" {
+			req.Header.Set(TOKEN_HEADER, tc.Token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		resp.Body.Close()
+
+		if resp.StatusCode != tc.Code {
+			t.Fatalf("token %q: expected code %d, got %d", tc.Token, tc.Code, resp.StatusCode)
+		}
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	cases := map[string]bool{
+		"127.0.0.1:5757": true,
+		"[::1]:5757":     true,
+		"localhost:5757": true,
+		"10.0.0.2:5757":  false,
+		"0.0.0.0:5757":   false,
+		":5757":          false,
+		"127.0.0.1":      false,
+	}
+
+	for addr, expected := range cases {
+		if got := IsLoopbackAddr(addr); got != expected {
+			t.Fatalf("%s: expected %v, got %v", addr, expected, got)
+		}
+	}
+}
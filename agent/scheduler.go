@@ -0,0 +1,204 @@
+package agent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/cron"
+)
+
+// DEFAULT_SCHEDULE_RETAIN is the no of the last runs of a schedule that
+// are retained if the schedule does not set it
+const DEFAULT_SCHEDULE_RETAIN = 10
+
+// ScheduleStatus is the status of a schedule of the agent
+type ScheduleStatus struct {
+	Name string
+	Cron string
+
+	// Next is the time of the next run, including its jitter
+	Next time.Time `json:",omitempty"`
+
+	// Skipped is the no of runs that were skipped as another run was
+	// in progress
+	Skipped int
+
+	// Runs are the retained runs of the schedule, the latest first
+	Runs []*Run
+}
+
+// schedule is a schedule of the agent's recurring runs
+type schedule struct {
+	config *config.Schedule
+	cron   *cron.Schedule
+	jitter time.Duration
+	retain int
+
+	next    time.Time
+	skipped int
+}
+
+// newSchedule builds the schedule of the config
+func newSchedule(c *config.Schedule) (*schedule, error) {
+	cs, err := cron.Parse(c.Cron)
+	if err != nil {
+		return nil, fmt.Errorf("schedule '%s': %v", c.Name, err)
+	}
+
+	var jitter time.Duration
+	if c.Jitter != "" {
+		if jitter, err = time.ParseDuration(c.Jitter); err != nil {
+			return nil, fmt.Errorf("schedule '%s': invalid jitter: %v", c.Name, err)
+		}
+	}
+
+	retain := c.Retain
+	if retain == 0 {
+		retain = DEFAULT_SCHEDULE_RETAIN
+	}
+
+	return &schedule{
+		config: c,
+		cron:   cs,
+		jitter: jitter,
+		retain: retain,
+	}, nil
+}
+
+// Start starts the schedules of the agent's config. Each schedule starts
+// its runs at the times of its cron expression, after a random delay of
+// up to its jitter. A run is skipped if another run is in progress.
+func (a *Agent) Start() error {
+	var schedules []*schedule
+	for _, c := range a.mconfig.Schedules {
+		s, err := newSchedule(c)
+		if err != nil {
+			return err
+		}
+		schedules = append(schedules, s)
+	}
+
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	if a.started {
+		return fmt.Errorf("Agent has already been started")
+	}
+
+	a.started = true
+	a.stopCh = make(chan struct{})
+	a.schedules = schedules
+
+	for _, s := range schedules {
+		a.wg.Add(1)
+		go a.runSchedule(s, a.stopCh)
+	}
+
+	return nil
+}
+
+// runSchedule starts the runs of the schedule till the agent is stopped
+func (a *Agent) runSchedule(s *schedule, stopCh <-chan struct{}) {
+	defer a.wg.Done()
+
+	for {
+		a.m.Lock()
+		next := s.cron.Next(time.Now())
+		if !next.IsZero() {
+			next = next.Add(a.randomJitter(s.jitter))
+		}
+		s.next = next
+		a.m.Unlock()
+
+		if next.IsZero() {
+			a.logger.Printf("[WARN] agent: Schedule '%s' will never run", s.config.Name)
+			return
+		}
+
+		t := time.NewTimer(time.Until(next))
+		select {
+		case <-stopCh:
+			t.Stop()
+			return
+		case <-t.C:
+		}
+
+		a.startScheduled(s)
+	}
+}
+
+// startScheduled starts a run of the schedule, unless the Mtest of the
+// agent's active run is running. The run is then skipped.
+func (a *Agent) startScheduled(s *schedule) {
+	run, err := a.startRun(&RunRequest{
+		Runner: s.config.Runner,
+		Run:    s.config.Run,
+		Skip:   s.config.Skip,
+		Tags:   s.config.Tags,
+	}, s.config.Name)
+
+	switch err.(type) {
+	case nil:
+		a.logger.Printf("[INFO] agent: Started run '%s' of schedule '%s'", run.ID, s.config.Name)
+	case *RunInProgressError:
+		a.m.Lock()
+		s.skipped++
+		a.m.Unlock()
+		a.logger.Printf("[WARN] agent: Skipped the run of schedule '%s': %v", s.config.Name, err)
+	default:
+		a.logger.Printf("[ERR] agent: Failed to start the run of schedule '%s': %v", s.config.Name, err)
+	}
+}
+
+// randomJitter provides a random delay up to the jitter. The caller must
+// hold the lock, as the source of random numbers is not safe for
+// concurrent use.
+func (a *Agent) randomJitter(jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	return time.Duration(a.rand.Int63n(int64(jitter)))
+}
+
+// retention provides the no of the finished runs of the schedule that
+// are retained. The caller must hold the lock.
+func (a *Agent) retention(name string) int {
+	if name == "" {
+		return DEFAULT_RUN_RETENTION
+	}
+
+	for _, s := range a.schedules {
+		if s.config.Name == name {
+			return s.retain
+		}
+	}
+	return DEFAULT_SCHEDULE_RETAIN
+}
+
+// Schedules provides the status of the schedules
+func (a *Agent) Schedules() []*ScheduleStatus {
+	a.m.Lock()
+	defer a.m.Unlock()
+
+	statuses := make([]*ScheduleStatus, 0, len(a.schedules))
+	for _, s := range a.schedules {
+		status := &ScheduleStatus{
+			Name:    s.config.Name,
+			Cron:    s.config.Cron,
+			Next:    s.next,
+			Skipped: s.skipped,
+			Runs:    []*Run{},
+		}
+
+		for i := len(a.order) - 1; i >= 0; i-- {
+			if r := a.runs[a.order[i]]; r.Schedule == s.config.Name {
+				status.Runs = append(status.Runs, r.status())
+			}
+		}
+
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
@@ -0,0 +1,166 @@
+package agent
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver/mock"
+)
+
+// newScheduledAgent returns a started agent of the mock scenarios & the
+// schedule
+func newScheduledAgent(t *testing.T, schedule string, hints ...string) *Agent {
+	mconfig, err := config.ParseMtestConfig(strings.NewReader(mock.Config(hints...) + schedule))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	a := NewAgent(config.DefaultMtestConfig().Merge(mconfig), ioutil.Discard)
+	if err := a.Start(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	t.Cleanup(a.Shutdown)
+
+	return a
+}
+
+// waitForSchedule waits till the condition on the status of the only
+// schedule of the agent holds
+func waitForSchedule(t *testing.T, a *Agent, cond func(*ScheduleStatus) bool) *ScheduleStatus {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if s := a.Schedules()[0]; cond(s) {
+			return s
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	t.Fatalf("schedule did not reach the condition: %#v", a.Schedules()[0])
+	return nil
+}
+
+func TestAgent_Schedule(t *testing.T) {
+	a := newScheduledAgent(t, `
+schedule "smoke" {
+  cron   = "@every 20ms"
+  run    = "ok"
+  retain = 2
+}
+`, "ok", "fail")
+
+	// The runs beyond the retention are pruned
+	seen := make(map[string]bool)
+	s := waitForSchedule(t, a, func(s *ScheduleStatus) bool {
+		for _, r := range s.Runs {
+			seen[r.ID] = true
+		}
+		return len(seen) > 4
+	})
+
+	if s.Name != "smoke" || s.Cron != "@every 20ms" || s.Next.IsZero() {
+		t.Fatalf("bad schedule: %#v", s)
+	}
+
+	s = waitForSchedule(t, a, func(s *ScheduleStatus) bool {
+		return len(s.Runs) > 0 && s.Runs[0].Status != RUN_STATUS_RUNNING
+	})
+	a.Shutdown()
+
+	if runs := a.Schedules()[0].Runs; len(runs) > 3 {
+		t.Fatalf("expected at most 3 runs to be retained, got %d", len(runs))
+	}
+
+	run := s.Runs[0]
+	if run.Schedule != "smoke" || run.Summary.Total != 1 || run.Summary.Passed != 1 {
+		t.Fatalf("bad run: %#v: %#v", run, run.Summary)
+	}
+
+	// An unscheduled run is not a run of the schedule
+	unscheduled, err := a.StartRun(&RunRequest{Run: "ok"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	waitForRun(t, a, unscheduled.ID)
+
+	for _, r := range a.Schedules()[0].Runs {
+		if r.ID == unscheduled.ID {
+			t.Fatalf("expected the unscheduled run to be left out, got %#v", r)
+		}
+	}
+}
+
+func TestAgent_ScheduleOverlap(t *testing.T) {
+	a := newScheduledAgent(t, `
+schedule "stuck" {
+  cron = "@every 10ms"
+}
+`, "block")
+
+	s := waitForSchedule(t, a, func(s *ScheduleStatus) bool {
+		return s.Skipped >= 3
+	})
+
+	// The runs are skipped while the first run is in progress
+	if len(s.Runs) != 1 || s.Runs[0].Status != RUN_STATUS_RUNNING {
+		t.Fatalf("expected a single run in progress, got %v", s.Runs)
+	}
+
+	a.m.Lock()
+	running := a.active.mt.IsRunning()
+	a.m.Unlock()
+	if !running {
+		t.Fatalf("expected the Mtest of the run to be running")
+	}
+
+	a.Shutdown()
+
+	run, err := a.Run(s.Runs[0].ID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if run.Status != RUN_STATUS_COMPLETE || run.Summary.Cancelled != 1 {
+		t.Fatalf("expected the run to be stopped, got %#v", run)
+	}
+}
+
+func TestAgent_RandomJitter(t *testing.T) {
+	a := NewAgent(nil, ioutil.Discard)
+
+	if d := a.randomJitter(0); d != 0 {
+		t.Fatalf("expected no jitter, got %v", d)
+	}
+
+	for i := 0; i < 100; i++ {
+		if d := a.randomJitter(time.Minute); d < 0 || d >= time.Minute {
+			t.Fatalf("expected a jitter within a minute, got %v", d)
+		}
+	}
+}
+
+func TestAgent_Start_Invalid(t *testing.T) {
+	a := NewAgent(&config.MtestConfig{
+		Schedules: []*config.Schedule{{Name: "bad", Cron: "every day"}},
+	}, ioutil.Discard)
+
+	err := a.Start()
+	if err == nil || !strings.Contains(err.Error(), "schedule 'bad'") {
+		t.Fatalf("expected an error of the schedule, got %v", err)
+	}
+
+	a = NewAgent(nil, ioutil.Discard)
+	if err := a.Start(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer a.Shutdown()
+
+	if err := a.Start(); err == nil {
+		t.Fatalf("expected an error on a second start")
+	}
+
+	if s := a.Schedules(); len(s) != 0 {
+		t.Fatalf("expected no schedules, got %v", s)
+	}
+}
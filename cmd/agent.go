@@ -0,0 +1,211 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/agent"
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/logging"
+	"github.com/openebs/mtest/logging/flag-helpers"
+)
+
+// AgentCommand is a cli implementation that runs mtest as a long running
+// agent. The agent serves a local HTTP API to start runs, query their
+// status & reports & stream their logs.
+type AgentCommand struct {
+	// Version details of mtest that are set on the reports
+	Revision          string
+	Version           string
+	VersionPrerelease string
+
+	Ui cli.Ui
+
+	// ShutdownCh, if set, stops the agent when closed. The agent is
+	// stopped on an interrupt too.
+	ShutdownCh <-chan struct{}
+
+	// ready, if set, is invoked with the agent's HTTP server once it
+	// serves the API
+	ready func(*agent.HTTPServer)
+}
+
+func (c *AgentCommand) Run(args []string) int {
+	var configPaths []string
+	var addr, token string
+	var insecure bool
+
+	flags := flag.NewFlagSet("agent", flag.ContinueOnError)
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+	flags.Var((*flaghelper.StringFlag)(&configPaths), "config", "path(s) of config file(s)")
+	flags.StringVar(&addr, "addr", agent.DEFAULT_HTTP_ADDR, "local address of the agent's API")
+	flags.StringVar(&token, "token", os.Getenv(agent.TOKEN_ENV), "token required of the requests to the agent's API")
+	flags.BoolVar(&insecure, "insecure", false, "serve the API on a non-loopback address without a token")
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	// Anyone who reaches the API can run any config, e.g. with faults
+	// or a state dir of their choice
+	if token == "" && !insecure && !agent.IsLoopbackAddr(addr) {
+		c.Ui.Error(fmt.Sprintf("The API on the non-loopback address %s requires a -token, or -insecure to serve it without one", addr))
+		return 1
+	}
+
+	mconfig, err := config.MtestConfigMake{}.Make(configPaths)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	mconfig = mconfig.Merge(&config.MtestConfig{
+		Revision:          c.Revision,
+		Version:           c.Version,
+		VersionPrerelease: c.VersionPrerelease,
+	})
+
+	wtrVarsMake := &logging.WriterVariantsMake{
+		Existing: &cli.UiWriter{Ui: c.Ui},
+	}
+	if err := wtrVarsMake.Make(strings.ToUpper(mconfig.LogLevel), mconfig.EnableSyslog); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+	logOutput := wtrVarsMake.MultiWriter()
+
+	a := agent.NewAgent(mconfig, logOutput)
+	if err := a.Start(); err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	srv, err := agent.NewHTTPServer(a, addr, token, log.New(logOutput, "", log.LstdFlags))
+	if err != nil {
+		a.Shutdown()
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	if len(mconfig.Files) > 0 {
+		c.Ui.Info(fmt.Sprintf("Loaded configuration from %s", strings.Join(mconfig.Files, ", ")))
+	}
+	for _, s := range mconfig.Schedules {
+		c.Ui.Info(fmt.Sprintf("Scheduled runs '%s' at '%s'", s.Name, s.Cron))
+	}
+	c.Ui.Output(fmt.Sprintf("Mtest agent started! API served on http://%s/v1/runs", srv.Addr))
+
+	// The logs are streamed from now on
+	wtrVarsMake.GatedWriter().Flush()
+
+	if c.ready != nil {
+		c.ready(srv)
+	}
+
+	c.wait()
+
+	c.Ui.Output("Mtest agent shutting down")
+	srv.Shutdown()
+	a.Shutdown()
+
+	return 0
+}
+
+// wait blocks till the agent is to be stopped
+func (c *AgentCommand) wait() {
+	signalCh := make(chan os.Signal, 4)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	select {
+	case <-signalCh:
+	case <-c.ShutdownCh:
+	}
+}
+
+func (c *AgentCommand) Synopsis() string {
+	return "Runs Mtest as an agent with an HTTP API"
+}
+
+func (c *AgentCommand) Help() string {
+	helpText := `
+Usage: mtest agent [options]
+
+  Runs Mtest as a long running agent that serves a local HTTP API to
+  start runs, query their status & reports & stream their logs. This
+  lets a test orchestrator drive one persistent mtest on each node
+  instead of running mtest per run. The agents of several nodes are the
+  workers of the coordinator.runner, see 'mtest run -help'.
+
+  The config files of the agent are the base config of every run, over
+  which the config of a run is merged. One run is in progress at a
+  time; a run that is started while another is in progress is refused.
+
+  The schedule blocks of the config start recurring runs of the use
+  cases of the agent's config, e.g.
+
+    schedule "smoke" {
+      cron   = "*/15 * * * *"
+      run    = "^smoke"
+      jitter = "1m"
+      retain = 20
+    }
+
+  The cron is either the standard 5 fields i.e. minute, hour, day of
+  month, month & day of week, a descriptor such as @hourly or @daily, or
+  '@every <duration>'. Each run starts after a random delay of up to
+  the jitter. A scheduled run is skipped if a run is in progress. The
+  last 'retain' runs of a schedule are retained, 10 if not set.
+
+  The API is:
+
+    POST   /v1/runs               Starts a run. The body is a JSON object
+                                  with the Runner, the Config in HCL e.g.
+                                  its scenarios, along with the Run, Skip
+                                  & Tags to select the use cases. The
+                                  runner defaults to mserver.runner.
+    GET    /v1/runs               Lists the runs, the latest first.
+    GET    /v1/runs/<id>          Provides the status of a run along with
+                                  the summary of its use cases so far.
+    DELETE /v1/runs/<id>          Stops a run & waits for it to finish.
+    GET    /v1/runs/<id>/reports  Provides the reports of the use cases
+                                  of a run that completed so far.
+    GET    /v1/runs/<id>/logs     Streams the logs of a run, starting with
+                                  its last logs, till the run finishes.
+    GET    /v1/schedules          Lists the schedules along with the time
+                                  of their next run, the no of skipped
+                                  runs & their retained runs.
+
+  Append ?pretty to a request to indent its JSON response. If the agent
+  has a token, every request must carry it in the ` + agent.TOKEN_HEADER + `
+  header, else it is refused with 403.
+
+Options:
+
+  -config=<path>
+    The path to either a single config file or a directory of config
+    files to use for configuring Mtest. This option may be specified
+    multiple times.
+
+  -addr=<host:port>
+    The local address on which the API is served. Defaults to
+    ` + agent.DEFAULT_HTTP_ADDR + `. An address other than a loopback one
+    requires a -token, unless -insecure is set.
+
+  -token=<token>
+    The token that every request to the API must carry. Defaults to
+    the ` + agent.TOKEN_ENV + ` environment variable. The API accepts
+    any request if not set.
+
+  -insecure
+    Serve the API on an address other than a loopback one without a
+    token. Anyone who reaches the address can then run any config on
+    the node.
+`
+	return strings.TrimSpace(helpText)
+}
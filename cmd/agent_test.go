@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/agent"
+)
+
+func TestAgentCommand_Implements(t *testing.T) {
+	var _ cli.Command = &AgentCommand{}
+}
+
+func TestAgentCommand_Run(t *testing.T) {
+	shutdownCh := make(chan struct{})
+	ui := new(cli.MockUi)
+
+	var runs []*agent.Run
+	var code int
+	cmd := &AgentCommand{
+		Ui:         ui,
+		ShutdownCh: shutdownCh,
+		ready: func(srv *agent.HTTPServer) {
+			defer close(shutdownCh)
+
+			resp, err := http.Get("http://" + srv.Addr + "/v1/runs")
+			if err != nil {
+				t.Errorf("err: %s", err)
+				return
+			}
+			defer resp.Body.Close()
+
+			code = resp.StatusCode
+			json.NewDecoder(resp.Body).Decode(&runs)
+		},
+	}
+
+	if exit := cmd.Run([]string{"-addr=127.0.0.1:0"}); exit != 0 {
+		t.Fatalf("bad exit code: %d: %s", exit, ui.ErrorWriter.String())
+	}
+
+	if code != 200 || runs == nil || len(runs) != 0 {
+		t.Fatalf("bad response: %d: %v", code, runs)
+	}
+
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Mtest agent started! API served on http://127.0.0.1:") {
+		t.Fatalf("bad output: %s", out)
+	}
+}
+
+func TestAgentCommand_NonLoopback(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &AgentCommand{Ui: ui}
+
+	// The API is not served on all the interfaces without a token
+	if code := cmd.Run([]string{"-addr=:0"}); code != 1 {
+		t.Fatalf("bad exit code: %d", code)
+	}
+	if out := ui.ErrorWriter.String(); !strings.Contains(out, "requires a -token") {
+		t.Fatalf("bad output: %s", out)
+	}
+
+	for _, args := range [][]string{{"-addr=:0", "-token=secret"}, {"-addr=:0", "-insecure"}} {
+		shutdownCh := make(chan struct{})
+		ui := new(cli.MockUi)
+		cmd := &AgentCommand{
+			Ui:         ui,
+			ShutdownCh: shutdownCh,
+			ready: func(srv *agent.HTTPServer) {
+				close(shutdownCh)
+			},
+		}
+
+		if code := cmd.Run(args); code != 0 {
+			t.Fatalf("%v: bad exit code: %d: %s", args, code, ui.ErrorWriter.String())
+		}
+	}
+}
+
+func TestAgentCommand_Invalid(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &AgentCommand{Ui: ui}
+
+	if code := cmd.Run([]string{"-config=/nonexistent/mtest.hcl"}); code != 1 {
+		t.Fatalf("bad exit code: %d", code)
+	}
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/openebs/mtest/mtest"
+)
+
+// DiffCommand is a cli implementation that compares the reports of
+// two runs.
+type DiffCommand struct {
+	Meta
+}
+
+func (c *DiffCommand) Help() string {
+	helpText := `
+Usage: mtest diff [options] <resultsA> <resultsB>
+
+  Compares the reports of two runs, where resultsA is the earlier i.e.
+  the baseline run. Reports are lined up by their runner & use case.
+  The newly failing, newly passing, missing & added use cases are
+  highlighted along with the significant changes in duration.
+
+  The reports of a use case that was repeated, e.g. via -repeat, -soak
+  or -until-failure, are aggregated. The use case fails if any of its
+  reports failed, while its durations are compared by their mean.
+
+  Each of the results is either a results file written via the
+  -results option of a run, a run's file in the history or the ID of a
+  run in the history. See 'mtest history list'.
+
+  The exit code is 2 if a use case fails now or is not run anymore.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Diff Options:
+
+  -threshold=<percent>
+    The change in duration of a use case, as a percentage of its
+    earlier duration, that is significant. Defaults to 20. Durations
+    are not compared if this is 0.
+
+  ` + historyOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *DiffCommand) Synopsis() string {
+	return "Compares the reports of two runs"
+}
+
+func (c *DiffCommand) Run(args []string) int {
+	var (
+		stateDir    string
+		configPaths []string
+		threshold   float64
+	)
+
+	flags := c.Meta.FlagSet("diff", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.Float64Var(&threshold, "threshold", 20, "")
+	historyFlags(flags, &stateDir, &configPaths)
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) != 2 || threshold < 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	var results [2][]*mtest.Report
+	for i, arg := range args {
+		rpts, err := c.loadResults(arg, stateDir, configPaths)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error loading results %s: %s", arg, err))
+			return 1
+		}
+		results[i] = rpts
+	}
+
+	diff := mtest.DiffReports(results[0], results[1], threshold/100)
+
+	c.outputDiffs("Newly failing", diff.NewlyFailing, false)
+	c.outputDiffs("Missing", diff.Missing, false)
+	c.outputDiffs("Newly passing", diff.NewlyPassing, false)
+	c.outputDiffs("Added", diff.Added, false)
+	c.outputDiffs("Slower", diff.Slower, true)
+	c.outputDiffs("Faster", diff.Faster, true)
+
+	c.Ui.Output(fmt.Sprintf(
+		"%d newly failing, %d missing, %d newly passing, %d added, %d slower, %d faster, %d unchanged",
+		len(diff.NewlyFailing),
+		len(diff.Missing),
+		len(diff.NewlyPassing),
+		len(diff.Added),
+		len(diff.Slower),
+		len(diff.Faster),
+		diff.Unchanged))
+
+	if diff.HasRegressions() {
+		return 2
+	}
+
+	return 0
+}
+
+// loadResults loads the reports from a results file or else from the
+// run with the given ID in the history
+func (c *DiffCommand) loadResults(arg, stateDir string, configPaths []string) ([]*mtest.Report, error) {
+	if _, err := os.Stat(arg); err == nil {
+		return mtest.LoadReports(arg)
+	}
+
+	h, err := openHistory(stateDir, configPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	run, err := h.Get(arg)
+	if err != nil {
+		return nil, err
+	}
+
+	return run.Reports, nil
+}
+
+// outputDiffs outputs a section of the diffs, if any
+func (c *DiffCommand) outputDiffs(title string, diffs []*mtest.ReportDiff, durations bool) {
+	if len(diffs) == 0 {
+		return
+	}
+
+	c.Ui.Output(fmt.Sprintf("%s:", title))
+	for _, d := range diffs {
+		line := d.String()
+		if durations {
+			line = fmt.Sprintf("%s %s: %s", d.Runner, d.Usecase, d.DurationString())
+		}
+		c.Ui.Output("  " + line)
+	}
+	c.Ui.Output("")
+}
@@ -0,0 +1,60 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/mtest"
+	"github.com/openebs/mtest/util"
+)
+
+func TestDiffCommand_Implements(t *testing.T) {
+	var _ cli.Command = &DiffCommand{}
+}
+
+func TestDiffCommand_Regression(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	fileA := filepath.Join(dir, "a.json")
+	fileB := filepath.Join(dir, "b.json")
+
+	err = util.SaveConfig(fileA, []*mtest.Report{
+		{Runner: "mserver.runner", Usecase: "snapshot-restore", Success: true, Status: mtest.STATUS_OK},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	err = util.SaveConfig(fileB, []*mtest.Report{
+		{Runner: "mserver.runner", Usecase: "snapshot-restore", Status: mtest.STATUS_FAILED},
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	c := &DiffCommand{Meta: Meta{Ui: ui}}
+
+	if code := c.Run([]string{fileA, fileB}); code != 2 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "Newly failing:") || !strings.Contains(out, "snapshot-restore") {
+		t.Fatalf("bad output: %q", out)
+	}
+
+	ui = new(cli.MockUi)
+	c = &DiffCommand{Meta: Meta{Ui: ui}}
+	if code := c.Run([]string{fileB, fileA}); code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+}
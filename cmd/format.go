@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/mtest"
+)
+
+const (
+	// Human readable output of a run
+	FORMAT_TEXT = "text"
+
+	// Each report & the final summary as an indented JSON object
+	FORMAT_JSON = "json"
+
+	// Each report & the final summary as a JSON object per line
+	FORMAT_NDJSON = "ndjson"
+)
+
+const (
+	// Types of the JSON objects of a run's output
+	OUTPUT_TYPE_REPORT     = "report"
+	OUTPUT_TYPE_SUMMARY    = "summary"
+	OUTPUT_TYPE_PASS_RATES = "pass-rates"
+	OUTPUT_TYPE_PLAN       = "plan"
+)
+
+// runOutput is the JSON object that is emitted for each report, for
+// the summary of a run, for the pass rates of repeated runs & for the
+// plan of a use case of a dry run. Type tells which one of these is set.
+type runOutput struct {
+	Type string
+
+	// The run of the report when the runs are repeated
+	Iteration int `json:",omitempty"`
+
+	Report    *mtest.Report     `json:",omitempty"`
+	Summary   *mtest.Summary    `json:",omitempty"`
+	PassRates []*mtest.PassRate `json:",omitempty"`
+	Plan      *mtest.Plan       `json:",omitempty"`
+}
+
+// checkFormat validates the format of the run output
+func checkFormat(format string) error {
+	switch format {
+	case FORMAT_TEXT, FORMAT_JSON, FORMAT_NDJSON:
+		return nil
+	}
+
+	return fmt.Errorf("Invalid format '%s', expected one of %s, %s or %s",
+		format, FORMAT_TEXT, FORMAT_JSON, FORMAT_NDJSON)
+}
+
+// jsonOutput emits the reports & the summary of a run as JSON objects
+// to the output of a cli.Ui. It is safe to use across goroutines, as
+// reports of parallel use cases are emitted as they complete.
+type jsonOutput struct {
+	m      sync.Mutex
+	ui     cli.Ui
+	indent bool
+}
+
+// Report emits the report of a use case. The iteration is the run
+// of the use case if the runs are repeated, else 0.
+func (o *jsonOutput) Report(iteration int, r *mtest.Report) {
+	o.emit(&runOutput{Type: OUTPUT_TYPE_REPORT, Iteration: iteration, Report: r})
+}
+
+// Handler provides the handler of the reports of an iteration
+func (o *jsonOutput) Handler(iteration int) mtest.ReportHandler {
+	return func(r *mtest.Report) {
+		o.Report(iteration, r)
+	}
+}
+
+// Summary emits the summary of the run
+func (o *jsonOutput) Summary(s *mtest.Summary) {
+	o.emit(&runOutput{Type: OUTPUT_TYPE_SUMMARY, Summary: s})
+}
+
+// PassRates emits the pass rates of the use cases of repeated runs
+func (o *jsonOutput) PassRates(rates []*mtest.PassRate) {
+	o.emit(&runOutput{Type: OUTPUT_TYPE_PASS_RATES, PassRates: rates})
+}
+
+// Plan emits the plan of a use case of a dry run
+func (o *jsonOutput) Plan(p *mtest.Plan) {
+	o.emit(&runOutput{Type: OUTPUT_TYPE_PLAN, Plan: p})
+}
+
+func (o *jsonOutput) emit(out *runOutput) {
+	var (
+		b   []byte
+		err error
+	)
+
+	if o.indent {
+		b, err = json.MarshalIndent(out, "", "  ")
+	} else {
+		b, err = json.Marshal(out)
+	}
+
+	o.m.Lock()
+	defer o.m.Unlock()
+
+	if err != nil {
+		o.ui.Error(fmt.Sprintf("Error encoding %s as JSON: %s", out.Type, err))
+		return
+	}
+
+	o.ui.Output(string(b))
+}
+
+// errorUi is a cli.Ui that writes all of its messages to the error
+// writer of the wrapped Ui. This keeps the output writer free for the
+// machine readable output of a run.
+type errorUi struct {
+	cli.Ui
+}
+
+func (u *errorUi) Output(message string) {
+	u.Ui.Error(message)
+}
+
+func (u *errorUi) Info(message string) {
+	u.Ui.Error(message)
+}
+
+func (u *errorUi) Warn(message string) {
+	u.Ui.Error(message)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"bufio"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/mtest"
+)
+
+func TestJSONOutput_NDJSON(t *testing.T) {
+	ui := new(cli.MockUi)
+	out := &jsonOutput{ui: ui}
+
+	reports := []*mtest.Report{
+		{Runner: "test.runner", Usecase: "a", Status: mtest.STATUS_OK, Success: true},
+		{Runner: "test.runner", Usecase: "b", Status: mtest.STATUS_FAILED, Message: "step 'x': failed"},
+	}
+	for _, r := range reports {
+		out.Report(0, r)
+	}
+	out.Summary(mtest.Summarize(reports))
+
+	var lines []runOutput
+	scanner := bufio.NewScanner(strings.NewReader(ui.OutputWriter.String()))
+	for scanner.Scan() {
+		var o runOutput
+		if err := json.Unmarshal(scanner.Bytes(), &o); err != nil {
+			t.Fatalf("err: %s: %q", err, scanner.Text())
+		}
+		lines = append(lines, o)
+	}
+
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 objects, got %d: %q", len(lines), ui.OutputWriter.String())
+	}
+
+	if lines[1].Type != OUTPUT_TYPE_REPORT || lines[1].Report.Usecase != "b" || lines[1].Report.Success {
+		t.Fatalf("bad report: %#v", lines[1])
+	}
+
+	if lines[2].Type != OUTPUT_TYPE_SUMMARY || lines[2].Summary.Total != 2 || lines[2].Summary.Failed != 1 {
+		t.Fatalf("bad summary: %#v", lines[2])
+	}
+}
+
+func TestRunCommand_InvalidFormat(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Ui: ui}
+
+	if code := cmd.Run([]string{"-format=yaml"}); code != 1 {
+		t.Fatalf("bad exit code: %d", code)
+	}
+
+	expected := "Invalid format 'yaml'"
+	if !strings.Contains(ui.ErrorWriter.String(), expected) {
+		t.Fatalf("expected %q, got %q", expected, ui.ErrorWriter.String())
+	}
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/logging/flag-helpers"
+	"github.com/openebs/mtest/mtest"
+)
+
+// HistoryCommand is a cli implementation that groups the commands
+// which browse the persisted runs.
+type HistoryCommand struct {
+	Meta
+}
+
+func (c *HistoryCommand) Help() string {
+	helpText := `
+Usage: mtest history <subcommand> [options]
+
+  Browses the runs that have been persisted by 'mtest run' & its
+  runner specific commands. Each run is persisted as a JSON file in
+  the history directory within the state directory.
+
+Subcommands:
+
+  list     Lists the persisted runs, the oldest first
+  show     Shows the reports of a persisted run
+  prune    Removes the older persisted runs
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HistoryCommand) Synopsis() string {
+	return "Browses the history of runs"
+}
+
+func (c *HistoryCommand) Run(args []string) int {
+	return cli.RunResultHelp
+}
+
+// historyFlags registers the flags that locate the history of runs
+func historyFlags(flags *flag.FlagSet, stateDir *string, configPaths *[]string) {
+	flags.StringVar(stateDir, "state-dir", "", "directory of mtest's state")
+	flags.Var((*flaghelper.StringFlag)(configPaths), "config", "path(s) of config file(s)")
+}
+
+// openHistory opens the history of runs within the state directory. The
+// state directory of the config is used if it is not provided.
+func openHistory(stateDir string, configPaths []string) (*mtest.History, error) {
+	if stateDir == "" {
+		mtconfig, err := config.MtestConfigMake{}.Make(configPaths)
+		if err != nil {
+			return nil, err
+		}
+		stateDir = mtconfig.StateDir
+	}
+
+	return mtest.NewHistory(stateDir)
+}
+
+// historyOptionsUsage returns the help string of the options that are
+// common to all the history commands.
+func historyOptionsUsage() string {
+	helpText := `
+  -state-dir=<path>
+    The state directory that has the history of runs. Defaults to
+    the state_dir of the config.
+
+  -config=<path>
+    The path to either a single config file or a directory of config
+    files, whose state_dir is used. This option may be specified
+    multiple times.
+`
+	return strings.TrimSpace(helpText)
+}
+
+// formatList aligns the columns of the rows which have their columns
+// separated by '|'
+func formatList(rows []string) string {
+	var buf bytes.Buffer
+
+	w := tabwriter.NewWriter(&buf, 0, 8, 3, ' ', 0)
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Replace(row, "|", "\t", -1))
+	}
+	w.Flush()
+
+	return strings.TrimRight(buf.String(), "\n")
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HistoryListCommand is a cli implementation that lists the
+// persisted runs.
+type HistoryListCommand struct {
+	Meta
+}
+
+func (c *HistoryListCommand) Help() string {
+	helpText := `
+Usage: mtest history list [options]
+
+  Lists the persisted runs, the oldest first.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+History Options:
+
+  ` + historyOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HistoryListCommand) Synopsis() string {
+	return "Lists the persisted runs"
+}
+
+func (c *HistoryListCommand) Run(args []string) int {
+	var (
+		stateDir    string
+		configPaths []string
+	)
+
+	flags := c.Meta.FlagSet("history list", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	historyFlags(flags, &stateDir, &configPaths)
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(flags.Args()) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	h, err := openHistory(stateDir, configPaths)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error opening history: %s", err))
+		return 1
+	}
+
+	runs, err := h.List()
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error listing runs: %s", err))
+		return 1
+	}
+
+	if len(runs) == 0 {
+		c.Ui.Output(fmt.Sprintf("No runs found in %s", h.Dir()))
+		return 0
+	}
+
+	rows := []string{"ID|Runner|Started|Duration|Passed|Failed|Status"}
+	for _, r := range runs {
+		status := "OK"
+		passed, failed := 0, 0
+		if r.Summary != nil {
+			passed = r.Summary.Passed
+			failed = r.Summary.Total - r.Summary.Passed
+			if !r.Summary.Success {
+				status = "FAILED"
+			}
+		}
+
+		rows = append(rows, fmt.Sprintf("%s|%s|%s|%v|%d|%d|%s",
+			r.ID,
+			r.Runner,
+			r.Started.Format(time.RFC3339),
+			r.Duration,
+			passed,
+			failed,
+			status))
+	}
+
+	c.Ui.Output(formatList(rows))
+	return 0
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HistoryPruneCommand is a cli implementation that removes the older
+// persisted runs.
+type HistoryPruneCommand struct {
+	Meta
+}
+
+func (c *HistoryPruneCommand) Help() string {
+	helpText := `
+Usage: mtest history prune [options]
+
+  Removes the older persisted runs. At least one of -keep or
+  -older-than is required. When both are provided, the runs older
+  than the given age are removed while retaining the latest runs.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Prune Options:
+
+  -keep=<count>
+    The no of latest runs to retain.
+
+  -older-than=<duration>
+    Remove the runs that started earlier than this duration ago,
+    e.g. 168h for the runs older than a week.
+
+  ` + historyOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HistoryPruneCommand) Synopsis() string {
+	return "Removes the older persisted runs"
+}
+
+func (c *HistoryPruneCommand) Run(args []string) int {
+	var (
+		stateDir    string
+		configPaths []string
+		keep        int
+		olderThan   time.Duration
+	)
+
+	flags := c.Meta.FlagSet("history prune", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.IntVar(&keep, "keep", -1, "")
+	flags.DurationVar(&olderThan, "older-than", 0, "")
+	historyFlags(flags, &stateDir, &configPaths)
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	if len(flags.Args()) != 0 || (keep < 0 && olderThan <= 0) {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	if keep < 0 {
+		keep = 0
+	}
+
+	var before time.Time
+	if olderThan > 0 {
+		before = time.Now().Add(-olderThan)
+	}
+
+	h, err := openHistory(stateDir, configPaths)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error opening history: %s", err))
+		return 1
+	}
+
+	pruned, err := h.Prune(keep, before)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error pruning runs: %s", err))
+		return 1
+	}
+
+	for _, id := range pruned {
+		c.Ui.Output(fmt.Sprintf("Removed run %s", id))
+	}
+	c.Ui.Output(fmt.Sprintf("Pruned %d run(s)", len(pruned)))
+
+	return 0
+}
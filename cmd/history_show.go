@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/openebs/mtest/mtest"
+)
+
+// HistoryShowCommand is a cli implementation that shows a
+// persisted run.
+type HistoryShowCommand struct {
+	Meta
+}
+
+func (c *HistoryShowCommand) Help() string {
+	helpText := `
+Usage: mtest history show [options] [<id>]
+
+  Shows the reports of a persisted run. The latest run is shown if
+  the run's ID is not provided.
+
+General Options:
+
+  ` + generalOptionsUsage() + `
+
+Show Options:
+
+  -json
+    Output the run as it was persisted i.e. as JSON.
+
+  ` + historyOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+func (c *HistoryShowCommand) Synopsis() string {
+	return "Shows a persisted run"
+}
+
+func (c *HistoryShowCommand) Run(args []string) int {
+	var (
+		stateDir    string
+		configPaths []string
+		asJSON      bool
+	)
+
+	flags := c.Meta.FlagSet("history show", FlagSetClient)
+	flags.Usage = func() { c.Ui.Output(c.Help()) }
+	flags.BoolVar(&asJSON, "json", false, "")
+	historyFlags(flags, &stateDir, &configPaths)
+
+	if err := flags.Parse(args); err != nil {
+		return 1
+	}
+
+	args = flags.Args()
+	if len(args) > 1 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	h, err := openHistory(stateDir, configPaths)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error opening history: %s", err))
+		return 1
+	}
+
+	var run *mtest.RunRecord
+	if len(args) == 1 {
+		run, err = h.Get(args[0])
+	} else {
+		run, err = h.Latest()
+	}
+
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error reading run: %s", err))
+		return 1
+	}
+
+	if run == nil {
+		c.Ui.Error(fmt.Sprintf("No runs found in %s", h.Dir()))
+		return 1
+	}
+
+	if asJSON {
+		b, err := json.MarshalIndent(run, "", "  ")
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error encoding run: %s", err))
+			return 1
+		}
+		c.Ui.Output(string(b))
+		return 0
+	}
+
+	rows := []string{
+		fmt.Sprintf("ID|= %s", run.ID),
+		fmt.Sprintf("Runner|= %s", run.Runner),
+		fmt.Sprintf("Config Files|= %s", strings.Join(run.ConfigFiles, ", ")),
+		fmt.Sprintf("Started|= %s", run.Started.Format(time.RFC3339)),
+		fmt.Sprintf("Duration|= %v", run.Duration),
+		fmt.Sprintf("Version|= %s", run.Version),
+		fmt.Sprintf("Revision|= %s", run.Revision),
+	}
+	if run.Summary != nil {
+		rows = append(rows, fmt.Sprintf("Summary|= %s", run.Summary))
+	}
+	c.Ui.Output(formatList(rows))
+
+	c.Ui.Output("")
+	c.Ui.Output("Reports:")
+	for _, r := range run.Reports {
+		if r != nil {
+			c.Ui.Output(r.String())
+		}
+	}
+
+	return 0
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/mtest"
+)
+
+func TestHistoryCommands_Implement(t *testing.T) {
+	var _ cli.Command = &HistoryCommand{}
+	var _ cli.Command = &HistoryListCommand{}
+	var _ cli.Command = &HistoryShowCommand{}
+	var _ cli.Command = &HistoryPruneCommand{}
+}
+
+func TestHistoryCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	h, err := mtest.NewHistory(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	run := mtest.NewRunRecord("test.runner", time.Now(), []*mtest.Report{
+		{Runner: "test.runner", Usecase: "snapshot-restore", Status: mtest.STATUS_FAILED},
+	})
+	if err := h.Save(run); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	list := &HistoryListCommand{Meta: Meta{Ui: ui}}
+	if code := list.Run([]string{"-state-dir=" + dir}); code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, run.ID) || !strings.Contains(out, "FAILED") {
+		t.Fatalf("bad list output: %q", out)
+	}
+
+	ui = new(cli.MockUi)
+	show := &HistoryShowCommand{Meta: Meta{Ui: ui}}
+	if code := show.Run([]string{"-state-dir=" + dir}); code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "snapshot-restore") {
+		t.Fatalf("bad show output: %q", out)
+	}
+
+	ui = new(cli.MockUi)
+	prune := &HistoryPruneCommand{Meta: Meta{Ui: ui}}
+	if code := prune.Run([]string{"-state-dir=" + dir}); code != 1 {
+		t.Fatalf("expected prune without options to fail, got %d", code)
+	}
+
+	ui = new(cli.MockUi)
+	prune = &HistoryPruneCommand{Meta: Meta{Ui: ui}}
+	if code := prune.Run([]string{"-state-dir=" + dir, "-keep=0"}); code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Pruned 1 run(s)") {
+		t.Fatalf("bad prune output: %q", out)
+	}
+}
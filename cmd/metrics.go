@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/openebs/mtest/metrics"
+)
+
+// METRICS_PATH is the path on which the metrics are served
+const METRICS_PATH = "/metrics"
+
+// serveMetrics serves the metrics of the registry on the address in the
+// background. The address of the returned server is the one it listens
+// on, which is useful if the port of the address is 0.
+func serveMetrics(addr string, reg *metrics.Registry) (*http.Server, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(METRICS_PATH, reg)
+
+	srv := &http.Server{
+		Addr:    ln.Addr().String(),
+		Handler: mux,
+	}
+	go srv.Serve(ln)
+
+	return srv, nil
+}
+
+// writeMetrics writes the metrics of the registry to the file at path
+func writeMetrics(path string, reg *metrics.Registry) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err := reg.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/mtest"
+)
+
+// progressRenderer renders the events of a run as lines of progress on
+// the terminal. The use cases that completed & failed so far are
+// counted along the way.
+//
+// NOTE: The events are handled one at a time by the broker of the
+// events, hence the counts need no lock.
+type progressRenderer struct {
+	ui cli.Ui
+
+	// No of use cases that completed & failed so far
+	done   int
+	failed int
+}
+
+// newProgressRenderer returns a renderer that outputs to the Ui
+func newProgressRenderer(ui cli.Ui) *progressRenderer {
+	return &progressRenderer{
+		ui: ui,
+	}
+}
+
+// HandleEvent renders the event. The start of a step is not rendered,
+// as its end follows soon after.
+func (p *progressRenderer) HandleEvent(ev *mtest.Event) {
+	prefix := "==> "
+	if ev.Worker != "" {
+		prefix += ev.Worker + ": "
+	}
+
+	switch ev.Type {
+	case mtest.EVENT_RUN_STARTED:
+		p.done, p.failed = 0, 0
+		p.ui.Output(fmt.Sprintf("==> %s: run started", ev.Runner))
+
+	case mtest.EVENT_USECASE_STARTED:
+		p.ui.Output(fmt.Sprintf("%s%s: started", prefix, ev.Usecase))
+
+	case mtest.EVENT_STEP_FINISHED:
+		line := fmt.Sprintf("%s%s: step '%s' %s in %v", prefix, ev.Usecase, ev.Step, ev.Status, ev.Duration.Round(time.Millisecond))
+		if ev.Attempts > 1 {
+			line += fmt.Sprintf(" after %d attempts", ev.Attempts)
+		}
+		if ev.Error != "" {
+			line += ": " + ev.Error
+		}
+		p.ui.Output(line)
+
+	case mtest.EVENT_RESOURCE_CREATED, mtest.EVENT_RESOURCE_DELETED:
+		action := "created"
+		if ev.Type == mtest.EVENT_RESOURCE_DELETED {
+			action = "deleted"
+		}
+
+		line := fmt.Sprintf("%s%s: %s %s %s", prefix, ev.Usecase, ev.Resource.Kind, ev.Resource.Name, action)
+		if ev.Resource.ID != "" {
+			line += " (" + ev.Resource.ID + ")"
+		}
+		p.ui.Output(line)
+
+	case mtest.EVENT_USECASE_FINISHED:
+		p.done++
+		if ev.Status != mtest.STATUS_OK {
+			p.failed++
+		}
+		p.ui.Output(fmt.Sprintf("%s%s: %s in %v [%d done, %d failed]",
+			prefix, ev.Usecase, ev.Status, ev.Duration.Round(time.Millisecond), p.done, p.failed))
+
+	case mtest.EVENT_RUN_FINISHED:
+		line := fmt.Sprintf("==> %s: run %s in %v", ev.Runner, ev.Status, ev.Duration.Round(time.Millisecond))
+		if ev.Error != "" {
+			line += ": " + ev.Error
+		}
+		p.ui.Output(line)
+	}
+}
@@ -0,0 +1,1103 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
+	"sync"
+	"syscall"
+
+	"strings"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/logging"
+	"github.com/openebs/mtest/metrics"
+	"github.com/openebs/mtest/mtest"
+	"github.com/openebs/mtest/util"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/logging/flag-helpers"
+)
+
+// gracefulTimeout controls how long we wait before forcefully terminating
+const gracefulTimeout = 5 * time.Second
+
+// RunCommand is a cli implementation that runs any of the registered
+// Mtest runners. The runner is selected by its name, while the rest of
+// the run i.e. config, logging & reporting is common to all runners.
+//
+// NOTE: Commands that are dedicated to a particular runner are built
+// on top of this command.
+type RunCommand struct {
+	m    sync.Mutex
+	Ui   cli.Ui
+	args []string
+
+	// Version details of mtest that are set on the reports
+	Revision          string
+	Version           string
+	VersionPrerelease string
+
+	// Name of the command as invoked from the CLI
+	name string
+
+	// Name of the runner to be run
+	runner string
+
+	// Path of the JUnit XML report file, if any
+	junitFile string
+
+	// Path of the results file, if any
+	resultsFile string
+
+	// No of times the use cases are run. With untilFailure, this is
+	// the max no of runs & is unbounded if not set.
+	repeat int
+
+	// A flag indicating if the runs should be repeated till a use
+	// case fails
+	untilFailure bool
+
+	// A flag indicating if the run was stopped on an interrupt
+	interrupted bool
+
+	// Format of the run output i.e. text, json or ndjson
+	format string
+
+	// A flag indicating if the use cases should only be planned
+	// & not run
+	dryRun bool
+
+	// ID of the run to be resumed
+	resume string
+
+	// A flag indicating if the progress of the run should be rendered
+	// as its events are published
+	progress bool
+
+	// Path of the file to which the events of the run are written, if
+	// any
+	eventsFile string
+
+	// Path of the file to which the metrics are written at the end of
+	// the run, if any
+	metricsFile string
+
+	// Local address on which the metrics are served during the run, if
+	// any
+	metricsAddr string
+
+	// ID of the run, which is known upfront if its progress is
+	// being checkpointed
+	runID string
+
+	// The undecorated Ui that gets the machine readable output
+	out cli.Ui
+
+	// A flag indicating if the run should not be persisted in
+	// the history of runs
+	noHistory bool
+
+	// The mtest config that was used to build the runner
+	mtconfig *config.MtestConfig
+
+	// A flag indicating if dependencies & their initialization
+	// have been invoked or not
+	initialized bool
+
+	// A dependency that aligns to MtestConfigMaker interface
+	mtConfMake config.MtestConfigMaker
+
+	// A dependency that aligns to WriterVariantsMaker interface
+	wtrVarsMake logging.WriterVariantsMaker
+
+	// A dependency that aligns to MtestMaker interface
+	mtestMake mtest.MtestMaker
+}
+
+// IsInitialized indicates if RunCommand is being
+// initialized now
+func (c *RunCommand) IsInitialized() bool {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.isInitialized()
+}
+
+// isInitialized helper method defines whether RunCommand
+// is being initialized right now
+func (c *RunCommand) isInitialized() bool {
+	return c.initialized
+}
+
+// SetAll injects various dependencies required for
+// RunCommand's functioning.
+// Each of these dependencies align to *Maker interface
+//
+// NOTE:
+//  Once injected, each dependant's Make() function
+//  might be executed.
+func (c *RunCommand) SetAll() error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	// Initialize only if not initialized earlier
+	if !c.isInitialized() {
+
+		// Build a config maker instance
+		if c.mtConfMake == nil {
+			c.mtConfMake = &config.MtestConfigMake{}
+		}
+
+		// Get the mtest config which will use the config maker
+		// instance. This is done so early as config is required
+		// for every other thing
+		mtconfig := c.readMtestConfig()
+		if mtconfig == nil {
+			return fmt.Errorf("Could not create mtest config")
+		}
+		c.mtconfig = mtconfig
+
+		// Build a writer variants maker instance
+		if c.wtrVarsMake == nil {
+			c.wtrVarsMake = &logging.WriterVariantsMake{
+				Existing: &cli.UiWriter{Ui: c.Ui},
+			}
+		}
+
+		// This will create variants of writer instances
+		err := c.wtrVarsMake.Make(strings.ToUpper(mtconfig.LogLevel), mtconfig.EnableSyslog)
+		if err != nil {
+			return err
+		}
+
+		// Build a Mtest maker instance that is associated
+		// with the selected runner
+		if c.mtestMake == nil {
+			c.mtestMake, err = mtest.GetRunner(c.runner, c.wtrVarsMake.MultiWriter(), mtconfig)
+			if err != nil {
+				return err
+			}
+		}
+
+		// Once all the above maker instances are set as dependencies
+		c.initialized = true
+	}
+
+	return nil
+}
+
+// This will read & load the Mtest config from the provided paths
+// do necessary validations & merge any user privided config
+// properties. All of these are done on top of default mtest config.
+func (c *RunCommand) readMtestConfig() *config.MtestConfig {
+	// Variable to hold a set of config paths
+	var configPaths []string
+
+	// Make a new, empty mtest config.
+	// TODO
+	//    when user will provide config options
+	//    while using this CLI
+	cmdConfig := &config.MtestConfig{
+		Revision:          c.Revision,
+		Version:           c.Version,
+		VersionPrerelease: c.VersionPrerelease,
+	}
+
+	flags := flag.NewFlagSet(c.name, flag.ContinueOnError)
+	flags.Usage = func() { c.Ui.Error(c.Help()) }
+
+	// options
+	flags.Var((*flaghelper.StringFlag)(&configPaths), "config", "path(s) of config file(s)")
+	flags.StringVar(&c.junitFile, "report-junit", "", "path of the JUnit XML report file")
+	flags.StringVar(&c.resultsFile, "results", "", "path of the results file")
+	flags.StringVar(&cmdConfig.Soak, "soak", "", "duration for which the use cases are run in cycles")
+	flags.StringVar(&cmdConfig.Run, "run", "", "regular expression of the use cases to run")
+	flags.StringVar(&cmdConfig.Skip, "skip", "", "regular expression of the use cases to skip")
+	flags.Var(flaghelper.FuncVar(func(s string) error {
+		for _, t := range strings.Split(s, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				cmdConfig.Tags = append(cmdConfig.Tags, t)
+			}
+		}
+		return nil
+	}), "tags", "tags of the use cases to run")
+	flags.IntVar(&c.repeat, "repeat", 0, "no of times the use cases are run")
+	flags.BoolVar(&c.untilFailure, "until-failure", false, "repeat the runs till a use case fails")
+	flags.StringVar(&c.format, "format", FORMAT_TEXT, "format of the run output")
+	flags.StringVar(&cmdConfig.StateDir, "state-dir", "", "directory of mtest's state")
+	flags.BoolVar(&c.noHistory, "no-history", false, "do not persist the run in the history")
+	flags.BoolVar(&c.dryRun, "dry-run", false, "plan the use cases without running them")
+	flags.StringVar(&c.resume, "resume", "", "id of the interrupted run to be resumed")
+	flags.BoolVar(&c.progress, "progress", c.name == "ebs", "render the progress of the run")
+	flags.StringVar(&c.eventsFile, "events-file", "", "path of the file to which the events are written")
+	flags.StringVar(&c.metricsFile, "metrics-file", "", "path of the file to which the metrics are written")
+	flags.StringVar(&c.metricsAddr, "metrics-addr", "", "local address on which the metrics are served")
+	flags.Var(flaghelper.FuncVar(func(s string) error {
+		seed, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Invalid seed '%s', expected an integer", s)
+		}
+		cmdConfig.Random = &config.Random{Seed: seed}
+		return nil
+	}), "seed", "seed of the random runner's sequences")
+
+	// Only the generic run command lets the runner to be selected
+	if c.name == "run" {
+		flags.StringVar(&c.runner, "runner", mtest.MTEST_MSERVER_RUNNER_NAME, "name of the runner")
+		flags.Var(flaghelper.FuncVar(func(s string) error {
+			var workers []string
+			for _, w := range strings.Split(s, ",") {
+				if w = strings.TrimSpace(w); w != "" {
+					workers = append(workers, w)
+				}
+			}
+			cmdConfig.Coordinator = &config.Coordinator{Workers: workers}
+			return nil
+		}), "workers", "addresses of the agents of the coordinator runner")
+	}
+
+	err := flags.Parse(c.args)
+	if err != nil {
+		c.Ui.Error(fmt.Sprintf("Error loading configuration from %v. %s", configPaths, err))
+		return nil
+	}
+
+	if err := checkFormat(c.format); err != nil {
+		c.Ui.Error(err.Error())
+		return nil
+	}
+
+	if c.repeat < 0 {
+		c.Ui.Error(fmt.Sprintf("Invalid repeat %d, expected a positive count", c.repeat))
+		return nil
+	}
+
+	if c.resume != "" && (c.isRepeated() || cmdConfig.Soak != "") {
+		c.Ui.Error("Resume of a run can not be combined with -repeat, -until-failure or -soak")
+		return nil
+	}
+
+	// Everything other than the machine readable output is
+	// moved to the error writer, so that the output can be piped
+	if c.format != FORMAT_TEXT {
+		if c.out == nil {
+			c.out = c.Ui
+		}
+		c.Ui = decorateUi(&errorUi{Ui: c.out})
+	}
+
+	if c.mtConfMake == nil {
+		c.Ui.Error(fmt.Sprintf("Mtest-config-maker instance is nil"))
+		return nil
+	}
+
+	mtconfig, err := c.mtConfMake.Make(configPaths)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return nil
+	}
+
+	// Merge any CLI options over config file options
+	mtconfig = mtconfig.Merge(cmdConfig)
+
+	// Inform about mt configuration file(s)
+	if len(mtconfig.Files) > 0 {
+		c.Ui.Info(fmt.Sprintf("Loaded configuration from %s", strings.Join(mtconfig.Files, ", ")))
+	} else {
+		c.Ui.Info("No configuration files loaded")
+	}
+
+	// Build Mtest information for messaging
+	info := make(map[string]string)
+	info["log level"] = mtconfig.LogLevel
+	info["runner"] = c.runner
+	if v := mtconfig.VersionString(); v != "" {
+		info["version"] = v
+	}
+	if !c.noHistory || c.resume != "" {
+		info["state dir"] = mtconfig.StateDir
+	}
+	if c.resume != "" {
+		info["resume"] = c.resume
+	}
+	if mtconfig.Soak != "" {
+		info["soak"] = mtconfig.Soak
+	}
+	if mtconfig.Run != "" {
+		info["run"] = mtconfig.Run
+	}
+	if mtconfig.Skip != "" {
+		info["skip"] = mtconfig.Skip
+	}
+	if len(mtconfig.Tags) > 0 {
+		info["tags"] = strings.Join(mtconfig.Tags, ", ")
+	}
+	if len(mtconfig.Scenarios) > 0 {
+		names := make([]string, 0, len(mtconfig.Scenarios))
+		for _, s := range mtconfig.Scenarios {
+			names = append(names, s.Name)
+		}
+		info["scenarios"] = strings.Join(names, ", ")
+	}
+
+	// Sort the keys for output
+	infoKeys := make([]string, 0, len(info))
+	for key := range info {
+		infoKeys = append(infoKeys, key)
+	}
+	sort.Strings(infoKeys)
+
+	// Mtest configuration output
+	padding := 18
+	c.Ui.Output("Mtest configuration:\n")
+	for _, k := range infoKeys {
+		c.Ui.Info(fmt.Sprintf(
+			"%s%s: %s",
+			strings.Repeat(" ", padding-len(k)),
+			strings.Title(k),
+			info[k]))
+	}
+	c.Ui.Output("")
+
+	return mtconfig
+}
+
+// This CLI sub-command's entry point
+func (c *RunCommand) Run(args []string) int {
+	return c.run("run", args)
+}
+
+// run runs the command with the provided name
+func (c *RunCommand) run(name string, args []string) int {
+	c.name = name
+
+	// Decorate this CLI's UI
+	c.out = c.Ui
+	c.Ui = decorateUi(c.Ui)
+
+	// Set the args which may have the mtest config
+	c.args = args
+
+	// Dependency Injection
+	if !c.IsInitialized() {
+		err := c.SetAll()
+		if err != nil {
+			c.Ui.Error(err.Error())
+			return 1
+		}
+	}
+
+	if c.wtrVarsMake == nil {
+		c.Ui.Error(fmt.Sprintf("Writer-variants-maker instance is nil."))
+		return 1
+	}
+
+	// Defer flush the gatedWriter which is linked to this
+	// CLI's io.writer during Dependency Injection
+	gatedLogger := c.wtrVarsMake.GatedWriter()
+	if gatedLogger == nil {
+		return 1
+	}
+	defer gatedLogger.Flush()
+
+	if c.mtestMake == nil {
+		c.Ui.Error(fmt.Sprintf("Mtest-maker instance is nil."))
+		return 1
+	}
+
+	// Get a Mtest instance that is associated with the runner
+	mt, err := c.mtestMake.Make()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	var jsonOut *jsonOutput
+	if c.format == FORMAT_JSON || c.format == FORMAT_NDJSON {
+		jsonOut = &jsonOutput{
+			ui:     c.out,
+			indent: c.format == FORMAT_JSON,
+		}
+	}
+
+	if c.dryRun {
+		return c.outputPlans(mt, jsonOut)
+	}
+
+	checkpoints, checkpoint, err := c.checkpoint(mt)
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	if c.progress {
+		mt.RegisterEventHandler(newProgressRenderer(c.Ui))
+	}
+
+	if c.eventsFile != "" {
+		f, err := os.Create(c.eventsFile)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error creating events file %s: %s", c.eventsFile, err))
+			return 1
+		}
+		defer f.Close()
+
+		h := mtest.NewJSONEventHandler(f)
+		mt.RegisterEventHandler(h)
+		defer mt.DeregisterEventHandler(h)
+	}
+
+	if c.metricsFile != "" || c.metricsAddr != "" {
+		h := mtest.NewMetricsHandler(metrics.Default)
+		mt.RegisterEventHandler(h)
+		defer mt.DeregisterEventHandler(h)
+	}
+
+	if c.metricsAddr != "" {
+		srv, err := serveMetrics(c.metricsAddr, metrics.Default)
+		if err != nil {
+			c.Ui.Error(fmt.Sprintf("Error serving metrics on %s: %s", c.metricsAddr, err))
+			return 1
+		}
+		defer srv.Close()
+
+		c.Ui.Info(fmt.Sprintf("Metrics served on http://%s%s", srv.Addr, METRICS_PATH))
+	}
+
+	// Output the header that the server has started
+	c.Ui.Output(fmt.Sprintf("Mtest %s run started! Log data will start streaming:\n", c.name))
+
+	// Start the use cases
+	started := time.Now()
+	runs, err := c.startRepeated(mt, jsonOut)
+	defer mt.Stop()
+
+	if err != nil {
+		c.Ui.Error(err.Error())
+		// Exit code is set to 0 as this has nothing to do
+		// with running of CLI. CLI execution was fine.
+		return 0
+	}
+
+	// Reports of all the runs
+	var rpts []*mtest.Report
+	for _, run := range runs {
+		rpts = append(rpts, run...)
+	}
+
+	if jsonOut != nil {
+		jsonOut.Summary(mtest.Summarize(rpts))
+		if c.isRepeated() {
+			jsonOut.PassRates(mtest.PassRates(runs))
+		}
+	} else if c.isRepeated() {
+		c.outputPassRates(runs)
+	} else {
+		c.outputReports(rpts)
+	}
+
+	if !c.noHistory {
+		c.saveHistory(started, rpts)
+	}
+
+	if checkpoint != nil {
+		c.completeCheckpoint(checkpoints, checkpoint, rpts)
+	}
+
+	if c.resultsFile != "" {
+		if err := util.SaveConfig(c.resultsFile, rpts); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing results to %s: %s", c.resultsFile, err))
+			return 1
+		}
+		c.Ui.Info(fmt.Sprintf("Results written to %s", c.resultsFile))
+	}
+
+	if c.junitFile != "" {
+		if err := c.writeJUnit(rpts); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing JUnit report to %s: %s", c.junitFile, err))
+			return 1
+		}
+		c.Ui.Info(fmt.Sprintf("JUnit report written to %s", c.junitFile))
+	}
+
+	if c.metricsFile != "" {
+		if err := writeMetrics(c.metricsFile, metrics.Default); err != nil {
+			c.Ui.Error(fmt.Sprintf("Error writing metrics to %s: %s", c.metricsFile, err))
+			return 1
+		}
+		c.Ui.Info(fmt.Sprintf("Metrics written to %s", c.metricsFile))
+	}
+
+	return 0
+}
+
+// outputPlans outputs the plans of the use cases of a dry run. Nothing
+// is run & hence no history, results or reports are written.
+func (c *RunCommand) outputPlans(mt *mtest.Mtest, jsonOut *jsonOutput) int {
+	plans, err := mt.Plan()
+	if err != nil {
+		c.Ui.Error(err.Error())
+		return 1
+	}
+
+	for _, p := range plans {
+		if jsonOut != nil {
+			jsonOut.Plan(p)
+		} else {
+			c.Ui.Output(p.String())
+		}
+	}
+
+	c.Ui.Info(fmt.Sprintf("Dry run planned %d use case(s), nothing was run", len(plans)))
+
+	return 0
+}
+
+// isRepeated indicates if the use cases are run more than once
+func (c *RunCommand) isRepeated() bool {
+	return c.repeat > 1 || c.untilFailure
+}
+
+// startRepeated starts the mtest runs as many times as requested & returns
+// the reports of each run. The runs end early on an interrupt, or when a
+// use case fails if requested so.
+func (c *RunCommand) startRepeated(mt *mtest.Mtest, jsonOut *jsonOutput) ([][]*mtest.Report, error) {
+	max := c.repeat
+	if max <= 0 && !c.untilFailure {
+		max = 1
+	}
+
+	var runs [][]*mtest.Report
+	for i := 1; max <= 0 || i <= max; i++ {
+		iteration := 0
+		if c.isRepeated() {
+			iteration = i
+			c.Ui.Output(fmt.Sprintf("Starting run %d", i))
+		}
+
+		// Each report is emitted as soon as its use case completes
+		if jsonOut != nil {
+			if err := mt.SetReportHandler(jsonOut.Handler(iteration)); err != nil {
+				return runs, err
+			}
+		}
+
+		rpts, err := c.start(mt)
+		if err != nil {
+			return runs, err
+		}
+		runs = append(runs, rpts)
+
+		if !c.isRepeated() {
+			break
+		}
+
+		summary := mtest.Summarize(rpts)
+		c.Ui.Output(fmt.Sprintf("Run %d: %s", i, summary))
+		for _, rpt := range rpts {
+			if rpt != nil && !rpt.Success {
+				c.Ui.Error(rpt.String())
+			}
+		}
+
+		if c.interrupted {
+			break
+		}
+
+		if c.untilFailure && !summary.Success {
+			c.Ui.Output(fmt.Sprintf("Stopping the runs as a use case failed in run %d", i))
+			break
+		}
+	}
+
+	return runs, nil
+}
+
+// outputPassRates outputs the pass rate of each use case across the runs
+func (c *RunCommand) outputPassRates(runs [][]*mtest.Report) {
+	c.Ui.Output("")
+	c.Ui.Output(fmt.Sprintf("Mtest pass rates of %d run(s):\n", len(runs)))
+
+	for _, p := range mtest.PassRates(runs) {
+		if p.Class == mtest.CLASS_STABLE {
+			c.Ui.Info(p.String())
+		} else {
+			c.Ui.Error(p.String())
+		}
+	}
+}
+
+// outputReports outputs each report in a readable form
+func (c *RunCommand) outputReports(rpts []*mtest.Report) {
+	c.Ui.Output("")
+	c.Ui.Output("Mtest reports:\n")
+
+	for _, rpt := range rpts {
+		if rpt == nil {
+			continue
+		}
+
+		if rpt.Success {
+			c.Ui.Info(rpt.String())
+		} else {
+			c.Ui.Error(rpt.String())
+		}
+	}
+
+	c.outputLoadResults(rpts)
+	c.outputRandomFailures(rpts)
+
+	c.Ui.Output("")
+	c.Ui.Output(fmt.Sprintf("Mtest summary: %s", mtest.Summarize(rpts)))
+}
+
+// outputLoadResults outputs the results of the load tests, if any,
+// as a table
+func (c *RunCommand) outputLoadResults(rpts []*mtest.Report) {
+	rows := []string{"Use case|Rate|Concurrency|Ops|Failures|Dropped|Errors|Ops/sec|p50|p90|p99|Max|Waited p99"}
+	for _, rpt := range rpts {
+		if rpt == nil {
+			continue
+		}
+
+		res, ok := rpt.Message.(*mtest.LoadResult)
+		if !ok {
+			continue
+		}
+
+		latency := &mtest.OpStats{}
+		if res.Latency != nil {
+			latency = res.Latency
+		}
+
+		// Time waited on the serialized calls, e.g. the volume
+		// attaches to an EC2 instance, which the latencies exclude
+		waited := "-"
+		if res.Wait != nil {
+			waited = res.Wait.P99.String()
+		}
+
+		rows = append(rows, fmt.Sprintf("%s|%v|%d|%d|%d|%d|%.1f%%|%.2f|%v|%v|%v|%v|%s",
+			rpt.Usecase,
+			res.Rate,
+			res.Concurrency,
+			res.Ops,
+			res.Failures,
+			res.Dropped,
+			res.ErrorRate*100,
+			res.Throughput,
+			latency.P50,
+			latency.P90,
+			latency.P99,
+			latency.Max,
+			waited))
+	}
+
+	if len(rows) == 1 {
+		return
+	}
+
+	c.Ui.Output("")
+	c.Ui.Output("Mtest load results:\n")
+	c.Ui.Output(formatList(rows))
+}
+
+// outputRandomFailures outputs the failed sequences of the random runner,
+// if any, along with their minimal reproductions & how to replay them
+func (c *RunCommand) outputRandomFailures(rpts []*mtest.Report) {
+	var lines []string
+	for _, rpt := range rpts {
+		if rpt == nil {
+			continue
+		}
+
+		res, ok := rpt.Message.(*mtest.RandomResult)
+		if !ok || res.FailedOp == 0 {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("%s: op %d failed: %s", rpt.Usecase, res.FailedOp, res.Error))
+		lines = append(lines, fmt.Sprintf("  replay via -runner=%s -seed=%d", mtest.MTEST_RANDOM_RUNNER_NAME, res.Seed))
+
+		ops := res.Minimal
+		if len(ops) == 0 {
+			ops = res.Ops[:res.FailedOp]
+		} else {
+			lines = append(lines, fmt.Sprintf("  minimal reproduction after %d shrink run(s):", res.ShrinkRuns))
+		}
+		for i, op := range ops {
+			lines = append(lines, fmt.Sprintf("  %d. %s", i+1, op))
+		}
+	}
+
+	if len(lines) == 0 {
+		return
+	}
+
+	c.Ui.Output("")
+	c.Ui.Output("Mtest random failures:\n")
+	c.Ui.Output(strings.Join(lines, "\n"))
+}
+
+// decorateUi decorates the Ui with the prefixes of the run commands
+func decorateUi(ui cli.Ui) cli.Ui {
+	return &cli.PrefixedUi{
+		OutputPrefix: " ",
+		InfoPrefix:   "INFO:  ",
+		ErrorPrefix:  "ERROR: ",
+		WarnPrefix:   "WARN:  ",
+		Ui:           ui,
+	}
+}
+
+// saveHistory persists the run in the history of runs. A failure to
+// persist is only warned, as the run itself has completed.
+func (c *RunCommand) saveHistory(started time.Time, rpts []*mtest.Report) {
+	if c.mtconfig == nil {
+		return
+	}
+
+	h, err := mtest.NewHistory(c.mtconfig.StateDir)
+	if err != nil {
+		c.Ui.Warn(fmt.Sprintf("Run not saved in history: %s", err))
+		return
+	}
+
+	record := mtest.NewRunRecord(c.runner, started, rpts)
+	if c.runID != "" {
+		record.ID = c.runID
+	}
+	record.ConfigFiles = c.mtconfig.Files
+	record.Version = c.mtconfig.VersionString()
+	record.Revision = c.mtconfig.Revision
+
+	if err := h.Save(record); err != nil {
+		c.Ui.Warn(fmt.Sprintf("Run not saved in history: %s", err))
+		return
+	}
+
+	c.Ui.Info(fmt.Sprintf("Run saved in history as %s", record.ID))
+}
+
+// checkpoint provides the checkpoint of the run along with its store.
+// The progress of a run is checkpointed if the run is saved in the
+// history, it is not repeated & its runner supports checkpoints. A
+// resumed run continues from its checkpoint. Nil is returned if the
+// run is not checkpointed.
+func (c *RunCommand) checkpoint(mt *mtest.Mtest) (*mtest.Checkpoints, *mtest.Checkpoint, error) {
+	if c.mtconfig == nil {
+		return nil, nil, nil
+	}
+
+	if c.resume == "" && (c.noHistory || c.isRepeated() || c.mtconfig.Soak != "") {
+		return nil, nil, nil
+	}
+
+	store, err := mtest.NewCheckpoints(c.mtconfig.StateDir)
+	if err != nil {
+		if c.resume != "" {
+			return nil, nil, err
+		}
+		c.Ui.Warn(fmt.Sprintf("Run not checkpointed: %s", err))
+		return nil, nil, nil
+	}
+
+	if c.resume == "" {
+		cp := store.New(mtest.NewRunID(time.Now()), c.runner, c.mtconfig.Files)
+
+		// Runners that do not support checkpoints are run as is
+		if err := mt.SetCheckpoint(cp); err != nil {
+			return nil, nil, nil
+		}
+
+		if err := store.Save(cp); err != nil {
+			c.Ui.Warn(fmt.Sprintf("Run not checkpointed: %s", err))
+			mt.SetCheckpoint(nil)
+			return nil, nil, nil
+		}
+
+		c.runID = cp.ID
+		c.Ui.Info(fmt.Sprintf("Run %s is checkpointed & can be resumed via -resume=%s", cp.ID, cp.ID))
+		return store, cp, nil
+	}
+
+	cp, err := store.Get(c.resume)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if cp.Runner != c.runner {
+		return nil, nil, fmt.Errorf("Run '%s' was run by runner '%s' & not by '%s'", cp.ID, cp.Runner, c.runner)
+	}
+
+	if err := mt.SetCheckpoint(cp); err != nil {
+		return nil, nil, err
+	}
+
+	if strings.Join(cp.ConfigFiles, ",") != strings.Join(c.mtconfig.Files, ",") {
+		c.Ui.Warn(fmt.Sprintf("Run %s used the config files %v, resuming with %v", cp.ID, cp.ConfigFiles, c.mtconfig.Files))
+	}
+
+	c.runID = cp.ID
+	c.Ui.Info(fmt.Sprintf("Resuming run %s", cp.ID))
+	return store, cp, nil
+}
+
+// completeCheckpoint removes the checkpoint once all the use cases of
+// the run have passed, else the run is left to be resumed
+func (c *RunCommand) completeCheckpoint(store *mtest.Checkpoints, cp *mtest.Checkpoint, rpts []*mtest.Report) {
+	if c.interrupted || !mtest.Summarize(rpts).Success {
+		c.Ui.Info(fmt.Sprintf("Run %s can be resumed via -resume=%s", cp.ID, cp.ID))
+		return
+	}
+
+	if err := store.Delete(cp); err != nil {
+		c.Ui.Warn(fmt.Sprintf("Checkpoint of run %s not removed: %s", cp.ID, err))
+	}
+}
+
+// writeJUnit writes the reports to the JUnit XML report file
+func (c *RunCommand) writeJUnit(rpts []*mtest.Report) error {
+	f, err := os.Create(c.junitFile)
+	if err != nil {
+		return err
+	}
+
+	if err := mtest.WriteJUnit(f, rpts); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+// start starts the mtest run & waits for its completion.
+//
+// The run is stopped on an interrupt, which aborts the in-flight
+// executions. The run's reports are returned if the run stops within
+// the graceful timeout.
+func (c *RunCommand) start(mt *mtest.Mtest) ([]*mtest.Report, error) {
+	signalCh := make(chan os.Signal, 4)
+	signal.Notify(signalCh, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signalCh)
+
+	h, err := mt.StartAsync()
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case <-h.Done():
+		return h.Wait()
+	case sig := <-signalCh:
+		c.Ui.Output(fmt.Sprintf("Caught signal: %v, stopping the run after %s",
+			sig, h.Status().Summary))
+		c.interrupted = true
+	}
+
+	h.Cancel()
+
+	select {
+	case <-h.Done():
+		return h.Wait()
+	case <-signalCh:
+		return nil, fmt.Errorf("Run was forcefully terminated")
+	case <-time.After(gracefulTimeout):
+		return nil, fmt.Errorf("Run did not stop within %v", gracefulTimeout)
+	}
+}
+
+func (c *RunCommand) Synopsis() string {
+	return "Runs a registered Mtest runner"
+}
+
+func (c *RunCommand) Help() string {
+	helpText := `
+Usage: mtest run [options]
+
+  Runs the use cases of a registered Mtest runner. The runners
+  compiled into this binary are listed by 'mtest runners'.
+
+  The mserver.runner runs the scenarios of the config, while the
+  load.runner runs the load tests of the config. A load test executes
+  an executor or a scenario repeatedly at a rate (ops/sec) or at a
+  concurrency level, for a duration or a no of ops. The latency
+  percentiles & histograms, error rate & throughput of each load test
+  are reported. The volume attaches to an EC2 instance are serialized,
+  hence the time that the ops waited on each other is reported apart
+  & is excluded from the latencies, though not from the throughput.
+
+  The random.runner runs random but valid sequences of volume, snapshot
+  & backup operations against the EBS driver, as per the random block
+  of the config. Each operation is picked such that its preconditions
+  hold, e.g. a snapshot is created of an existing volume only, & the
+  volumes, snapshots & backups are checked against a local model after
+  every operation. The seed of the sequences is logged & reported. A
+  failed sequence is shrunk to a minimal reproduction & can be
+  replayed exactly via -seed.
+
+  The coordinator.runner splits the scenarios of the config across the
+  mtest agents of several nodes, i.e. its workers, & merges their
+  reports into a single result. The first use case is run by the first
+  worker, the second by the second & so on. Each worker runs its use
+  cases with the runner & the drivers of its own agent's config, e.g.
+  the EBS driver of its node's instance. All the steps of a use case
+  are run by its worker, unless the worker of a step, 1 being the first
+  worker, is set via its worker attribute, e.g. to attach a volume on
+  one node & then on another. The coordinator then runs each step of
+  such a use case on its worker & passes the outputs of the steps, e.g.
+  the EBS IDs of the volumes, on to the later steps. These use cases
+  are run after the others, one at a time. The workers are set via
+  -workers or the coordinator block of the config, e.g.
+
+    coordinator {
+      workers       = ["10.0.0.2:5757", "10.0.0.3:5757"]
+      runner        = "mserver.runner"
+      poll_interval = "2s"
+    }
+
+  The requests to the agents carry the token of the coordinator block,
+  else of the MTEST_AGENT_TOKEN environment variable, if the agents
+  require one. See 'mtest agent' to run a worker.
+
+General Options :
+
+  -runner=<name>
+    The name of the runner to run. Defaults to mserver.runner.
+
+  -workers=<host:port,...>
+    The comma separated addresses of the agents of the workers of the
+    coordinator.runner. Overrides the workers of the config.
+
+` + runOptionsUsage()
+	return strings.TrimSpace(helpText)
+}
+
+// runOptionsUsage returns the help string of the options that are
+// common to all the run commands.
+func runOptionsUsage() string {
+	helpText := `
+  -config=<path>
+    The path to either a single config file or a directory of config
+    files to use for configuring Mtest. This option may be
+    specified multiple times. If multiple config files are used, the
+    values from each will be merged together. During merging, values
+    from files found later in the list are merged over values from
+    previously parsed files.
+
+  -run=<regex>
+    Run only the use cases whose names match the regular expression,
+    e.g. -run='^snapshot-'.
+
+  -skip=<regex>
+    Skip the use cases whose names match the regular expression. This
+    applies after -run.
+
+  -tags=<tag,...>
+    Run only the use cases that have any of the comma separated tags,
+    e.g. -tags=smoke or -tags=snapshot,backup. Tags are set via the
+    tags of a scenario or a load test in the config.
+
+  -soak=<duration>
+    Run the use cases in cycles till the duration elapses, e.g. 12h.
+    Interim summaries of the use cases along with the ops, failures &
+    latency percentiles of each executor are logged at the interval
+    set by soak_interval of the config, 5m by default. Only the last
+    100 reports & the first 100 failed ones are output, whereas the
+    logged summaries cover all the cycles. Overrides the soak of the
+    config.
+
+  -repeat=<count>
+    The no of times the use cases are run. The pass rate of each use
+    case across the runs is output, where a use case is classified as
+    FLAKY if it failed in some of the runs or passed only after a step
+    was retried.
+
+  -until-failure
+    Repeat the runs till a use case fails. The runs are unbounded
+    unless -repeat is provided too.
+
+  -format=<text|json|ndjson>
+    The format of the run output. Defaults to text. With json or
+    ndjson, each report is written to stdout as a JSON object as soon
+    as its use case completes, followed by a summary object of the run.
+    Objects are indented with json & are one per line with ndjson. The
+    Type field of an object is either "report", "summary", "pass-rates"
+    or "plan", where the pass rates are emitted for the repeated runs
+    only & the plans for a dry run only. Logs & messages are written to
+    stderr.
+
+  -dry-run
+    Plan the use cases without running them. The config, the runner,
+    the drivers & their executors are resolved, but no request is
+    executed & no endpoint is contacted. Each planned step is printed
+    with its request, where the references to the earlier steps & the
+    driver's defaults e.g. the size, type & encryption of a volume are
+    resolved. References to the values known only on execution are
+    left as is. Nothing is written to the history.
+
+  -resume=<run-id>
+    Resume an interrupted or failed run. The progress of each use case,
+    i.e. its completed steps & their outputs such as the volume names,
+    EBS IDs & backup URLs, is checkpointed in the state dir as the run
+    progresses, unless the run is repeated, soaked or is not saved in
+    the history. On resume, the passed use cases are not run again &
+    the others continue after their last completed step. A use case
+    whose scenario was changed since is run again from its start. The
+    resumed run is saved in the history with the same ID. The
+    checkpoint is removed once all the use cases pass.
+
+  -seed=<int>
+    The seed of the first sequence of the random.runner, where each
+    later sequence's seed is one more than its earlier one. The same
+    seed generates the same sequence of operations. Overrides the seed
+    of the config. A seed is picked if not set.
+
+  -progress
+    Render the progress of the run as it happens, i.e. the start & end
+    of each use case & step along with its status, the volumes,
+    snapshots & backups that were created or deleted & the no of use
+    cases that are done & failed so far. Enabled by default for
+    'mtest ebs', disable via -progress=false.
+
+  -events-file=<path>
+    The path of the file to which the events of the run are written as
+    newline delimited JSON, as they happen. The Type of an event is
+    either "run-started", "run-finished", "usecase-started",
+    "usecase-finished", "step-started", "step-finished",
+    "resource-created" or "resource-deleted".
+
+  -metrics-file=<path>
+    The path of the file to which the metrics are written in Prometheus
+    text exposition format at the end of the run. The metrics are the
+    counts of the runs & the use cases that were run, passed & failed,
+    the durations of the steps, the volumes, snapshots & backups that
+    were created & deleted, along with the calls, errors & latencies of
+    each operation of the EC2 API.
+
+  -metrics-addr=<host:port>
+    The local address on which the metrics are served at /metrics for
+    Prometheus to scrape, as long as the run is in progress.
+
+  -state-dir=<path>
+    The directory where mtest persists its state e.g. the history of
+    runs. Overrides the state_dir of the config. Defaults to ~/.mtest.
+
+  -no-history
+    Do not persist the run in the history of runs. See 'mtest history'.
+
+  -results=<path>
+    The path of the file to which the reports are written as JSON. Two
+    such files can be compared via 'mtest diff'.
+
+  -report-junit=<path>
+    The path of the file to which the reports are written as JUnit XML.
+    Each runner is a testsuite & each of its use cases is a testcase.
+`
+	return strings.TrimSpace(helpText)
+}
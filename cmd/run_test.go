@@ -0,0 +1,396 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/driver/mock"
+	"github.com/openebs/mtest/mtest"
+)
+
+func TestRunCommand_Implements(t *testing.T) {
+	var _ cli.Command = &RunCommand{}
+}
+
+func TestRunCommand_UnknownRunner(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := filepath.Join(tmpDir, "mtest.hcl")
+	if err := ioutil.WriteFile(file, []byte(`log_level = "INFO"`), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Ui: ui}
+
+	code := cmd.Run([]string{"-config=" + file, "-runner=unicorn.runner"})
+	if code != 1 {
+		t.Fatalf("bad exit code: %d", code)
+	}
+
+	expected := "Runner 'unicorn.runner' is not supported"
+	if !strings.Contains(ui.ErrorWriter.String(), expected) {
+		t.Fatalf("expected %q, got %q", expected, ui.ErrorWriter.String())
+	}
+}
+
+// writeMockConfig writes a config with a scenario per executor hint
+// of the mock driver
+func writeMockConfig(t *testing.T, dir string, hints ...string) string {
+	file := filepath.Join(dir, "mtest.hcl")
+	if err := ioutil.WriteFile(file, []byte(mock.Config(hints...)), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	return file
+}
+
+func TestRunCommand_RepeatNDJSON(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := writeMockConfig(t, tmpDir, "ok")
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Ui: ui}
+
+	code := cmd.Run([]string{"-config=" + file, "-no-history", "-repeat=3", "-format=ndjson"})
+	if code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	var types []string
+	var last runOutput
+	scanner := bufio.NewScanner(strings.NewReader(ui.OutputWriter.String()))
+	for scanner.Scan() {
+		if err := json.Unmarshal(scanner.Bytes(), &last); err != nil {
+			t.Fatalf("err: %s: %q", err, scanner.Text())
+		}
+		types = append(types, last.Type)
+	}
+
+	expected := []string{"report", "report", "report", "summary", "pass-rates"}
+	if !reflect.DeepEqual(types, expected) {
+		t.Fatalf("bad output:\nwant: %v\n got: %v", expected, types)
+	}
+
+	if len(last.PassRates) != 1 || last.PassRates[0].Runs != 3 || last.PassRates[0].Class != mtest.CLASS_STABLE {
+		t.Fatalf("bad pass rates: %#v", last.PassRates)
+	}
+}
+
+func TestRunCommand_UntilFailure(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := writeMockConfig(t, tmpDir, "ok", "fail")
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Ui: ui}
+
+	code := cmd.Run([]string{"-config=" + file, "-no-history", "-until-failure"})
+	if code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	out := ui.OutputWriter.String()
+	if !strings.Contains(out, "a use case failed in run 1") {
+		t.Fatalf("expected the runs to stop after run 1, got %q", out)
+	}
+
+	if !strings.Contains(ui.ErrorWriter.String(), "fail [FAILING]: 0/1 passed") {
+		t.Fatalf("expected the pass rate of the failing use case, got %q", ui.ErrorWriter.String())
+	}
+}
+
+func TestRunCommand_Selection(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := writeMockConfig(t, tmpDir, "ok", "fail", "okay")
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Ui: ui}
+
+	code := cmd.Run([]string{"-config=" + file, "-no-history", "-run=^ok", "-skip=ay$", "-format=ndjson"})
+	if code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	var usecases []string
+	scanner := bufio.NewScanner(strings.NewReader(ui.OutputWriter.String()))
+	for scanner.Scan() {
+		var out runOutput
+		if err := json.Unmarshal(scanner.Bytes(), &out); err != nil {
+			t.Fatalf("err: %s: %q", err, scanner.Text())
+		}
+		if out.Report != nil {
+			usecases = append(usecases, out.Report.Usecase)
+		}
+	}
+
+	if !reflect.DeepEqual(usecases, []string{"ok"}) {
+		t.Fatalf("bad use cases: %v", usecases)
+	}
+}
+
+func TestRunCommand_DryRun(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// The failing executor would fail the run, if it were executed
+	file := writeMockConfig(t, tmpDir, "ok", "fail")
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Ui: ui}
+
+	code := cmd.Run([]string{"-config=" + file, "-state-dir=" + tmpDir, "-dry-run", "-format=ndjson"})
+	if code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	var usecases []string
+	scanner := bufio.NewScanner(strings.NewReader(ui.OutputWriter.String()))
+	for scanner.Scan() {
+		var out runOutput
+		if err := json.Unmarshal(scanner.Bytes(), &out); err != nil {
+			t.Fatalf("err: %s: %q", err, scanner.Text())
+		}
+		if out.Type != OUTPUT_TYPE_PLAN {
+			t.Fatalf("expected only plans, got %q", scanner.Text())
+		}
+		usecases = append(usecases, out.Plan.Usecase)
+
+		if len(out.Plan.Steps) != 1 || out.Plan.Steps[0].Request.Name != "vol1" {
+			t.Fatalf("bad plan: %q", scanner.Text())
+		}
+	}
+
+	if !reflect.DeepEqual(usecases, []string{"ok", "fail"}) {
+		t.Fatalf("bad use cases: %v", usecases)
+	}
+
+	// Nothing is run & hence nothing is persisted
+	if _, err := os.Stat(filepath.Join(tmpDir, mtest.HISTORY_DIR)); !os.IsNotExist(err) {
+		t.Fatalf("expected no history, got %v", err)
+	}
+}
+
+func TestRunCommand_Resume(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := writeMockConfig(t, tmpDir, "ok", "fail")
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Ui: ui}
+
+	if code := cmd.Run([]string{"-config=" + file, "-state-dir=" + tmpDir}); code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	store, err := mtest.NewCheckpoints(tmpDir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ids, err := store.IDs()
+	if err != nil || len(ids) != 1 {
+		t.Fatalf("expected a checkpoint of the failed run, got %v: %v", ids, err)
+	}
+
+	expected := "can be resumed via -resume=" + ids[0]
+	if !strings.Contains(ui.OutputWriter.String(), expected) {
+		t.Fatalf("expected %q, got %q", expected, ui.OutputWriter.String())
+	}
+
+	cp, err := store.Get(ids[0])
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	passed := cp.Usecases["ok"].Report
+
+	// The failed use case passes once its step is fixed
+	fixed := mock.Config("ok") + fmt.Sprintf("scenario \"fail\" {\n  step \"s\" {\n    driver = %q\n    executor = \"ok\"\n    name = \"vol1\"\n  }\n}\n",
+		mock.DRIVER_NAME)
+	if err := ioutil.WriteFile(file, []byte(fixed), 0600); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ui = new(cli.MockUi)
+	cmd = &RunCommand{Ui: ui}
+
+	code := cmd.Run([]string{"-config=" + file, "-state-dir=" + tmpDir, "-resume=" + ids[0], "-format=ndjson"})
+	if code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	var summary *mtest.Summary
+	reports := make(map[string]*mtest.Report)
+	scanner := bufio.NewScanner(strings.NewReader(ui.OutputWriter.String()))
+	for scanner.Scan() {
+		var out runOutput
+		if err := json.Unmarshal(scanner.Bytes(), &out); err != nil {
+			t.Fatalf("err: %s: %q", err, scanner.Text())
+		}
+		if out.Summary != nil {
+			summary = out.Summary
+		}
+		if out.Report != nil {
+			reports[out.Report.Usecase] = out.Report
+		}
+	}
+
+	if summary == nil || !summary.Success || summary.Total != 2 {
+		t.Fatalf("bad summary: %#v", summary)
+	}
+
+	// The passed use case is not run again, hence its report is the
+	// one of the first run
+	if r := reports["ok"]; r == nil || !r.Started.Equal(passed.Started) {
+		t.Fatalf("expected the report of the first run, got %#v", r)
+	}
+
+	// The checkpoint of the passed run is removed & the run is in
+	// the history by its ID
+	if ids, _ := store.IDs(); len(ids) != 0 {
+		t.Fatalf("expected no checkpoints, got %v", ids)
+	}
+
+	h, err := mtest.NewHistory(tmpDir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := h.Get(ids[0]); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Only the runs that have checkpoints can be resumed
+	ui = new(cli.MockUi)
+	cmd = &RunCommand{Ui: ui}
+	if code := cmd.Run([]string{"-config=" + file, "-state-dir=" + tmpDir, "-resume=" + ids[0]}); code != 1 {
+		t.Fatalf("bad exit code: %d", code)
+	}
+	if !strings.Contains(ui.ErrorWriter.String(), "not found") {
+		t.Fatalf("expected an error, got %q", ui.ErrorWriter.String())
+	}
+}
+
+func TestRunCommand_ProgressEvents(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := writeMockConfig(t, tmpDir, "ok", "fail")
+	eventsFile := filepath.Join(tmpDir, "events.ndjson")
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Ui: ui}
+
+	code := cmd.Run([]string{"-config=" + file, "-no-history", "-progress", "-events-file=" + eventsFile})
+	if code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	out := ui.OutputWriter.String()
+	for _, want := range []string{
+		"==> mserver.runner: run started",
+		"ok: step 's' OK in",
+		"fail: FAILED in",
+		"[2 done, 1 failed]",
+		"==> mserver.runner: run FAILED in",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected progress %q, got %s", want, out)
+		}
+	}
+
+	b, err := ioutil.ReadFile(eventsFile)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var types []mtest.EventType
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		var ev mtest.Event
+		if err := json.Unmarshal(scanner.Bytes(), &ev); err != nil {
+			t.Fatalf("err: %s: %q", err, scanner.Text())
+		}
+		types = append(types, ev.Type)
+	}
+
+	if len(types) != 10 || types[0] != mtest.EVENT_RUN_STARTED || types[9] != mtest.EVENT_RUN_FINISHED {
+		t.Fatalf("bad events: %v", types)
+	}
+}
+
+func TestRunCommand_Metrics(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	file := writeMockConfig(t, tmpDir, "ok", "fail")
+	metricsFile := filepath.Join(tmpDir, "metrics.prom")
+
+	ui := new(cli.MockUi)
+	cmd := &RunCommand{Ui: ui}
+
+	code := cmd.Run([]string{"-config=" + file, "-no-history", "-metrics-file=" + metricsFile, "-metrics-addr=127.0.0.1:0"})
+	if code != 0 {
+		t.Fatalf("bad exit code: %d: %s", code, ui.ErrorWriter.String())
+	}
+
+	if out := ui.OutputWriter.String(); !strings.Contains(out, "Metrics served on http://127.0.0.1:") {
+		t.Fatalf("expected the metrics to be served, got %s", out)
+	}
+
+	b, err := ioutil.ReadFile(metricsFile)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, want := range []string{
+		"# TYPE mtest_usecases_total counter",
+		`mtest_usecases_total{runner="mserver.runner"} 2`,
+		`mtest_usecases_passed_total{runner="mserver.runner"} 1`,
+		`mtest_usecases_failed_total{runner="mserver.runner",status="FAILED"} 1`,
+		`mtest_runs_total{runner="mserver.runner",status="FAILED"} 1`,
+		`mtest_step_duration_seconds_count{executor="ok",status="OK"} 1`,
+	} {
+		if !strings.Contains(string(b), want) {
+			t.Fatalf("expected metric %q, got %s", want, b)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/mtest"
+)
+
+// RunnersCommand is a cli implementation that lists the Mtest runners
+// that have been compiled into this binary.
+type RunnersCommand struct {
+	Ui cli.Ui
+}
+
+func (c *RunnersCommand) Help() string {
+	helpText := `
+Usage: mtest runners
+
+  Lists the Mtest runners that can be run via 'mtest run -runner=<name>'.
+`
+	return strings.TrimSpace(helpText)
+}
+
+func (c *RunnersCommand) Run(args []string) int {
+	if len(args) != 0 {
+		c.Ui.Error(c.Help())
+		return 1
+	}
+
+	for _, name := range mtest.Runners() {
+		c.Ui.Output(name)
+	}
+
+	return 0
+}
+
+func (c *RunnersCommand) Synopsis() string {
+	return "Lists the available Mtest runners"
+}
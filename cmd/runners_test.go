@@ -0,0 +1,30 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/mitchellh/cli"
+	"github.com/openebs/mtest/mtest"
+)
+
+func TestRunnersCommand_Implements(t *testing.T) {
+	var _ cli.Command = &RunnersCommand{}
+}
+
+func TestRunnersCommand_Run(t *testing.T) {
+	ui := new(cli.MockUi)
+	cmd := &RunnersCommand{Ui: ui}
+
+	if code := cmd.Run([]string{}); code != 0 {
+		t.Fatalf("bad exit code: %d", code)
+	}
+
+	if !strings.Contains(ui.OutputWriter.String(), mtest.MTEST_MSERVER_RUNNER_NAME) {
+		t.Fatalf("expected %s in output, got %q", mtest.MTEST_MSERVER_RUNNER_NAME, ui.OutputWriter.String())
+	}
+
+	if code := cmd.Run([]string{"extra"}); code != 1 {
+		t.Fatalf("bad exit code: %d", code)
+	}
+}
@@ -23,26 +23,75 @@ func Commands(metaPtr *cmd.Meta) map[string]cli.CommandFactory {
 		}
 	}
 
+	ver := Version
+	rel := VersionPrerelease
+	if GitDescribe != "" {
+		ver = GitDescribe
+		// Trim off a leading 'v', we append it anyways.
+		if ver[0] == 'v' {
+			ver = ver[1:]
+		}
+	}
+	if GitDescribe == "" && rel == "" && VersionPrerelease != "" {
+		rel = "dev"
+	}
+
 	return map[string]cli.CommandFactory{
+		"agent": func() (cli.Command, error) {
+			return &cmd.AgentCommand{
+				Revision:          GitCommit,
+				Version:           ver,
+				VersionPrerelease: rel,
+				Ui:                meta.Ui,
+			}, nil
+		},
+		"diff": func() (cli.Command, error) {
+			return &cmd.DiffCommand{
+				Meta: meta,
+			}, nil
+		},
 		"ebs": func() (cli.Command, error) {
 			return &cmd.EBSCommand{
+				Revision:          GitCommit,
+				Version:           ver,
+				VersionPrerelease: rel,
+				Ui:                meta.Ui,
+			}, nil
+		},
+		"history": func() (cli.Command, error) {
+			return &cmd.HistoryCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history list": func() (cli.Command, error) {
+			return &cmd.HistoryListCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history prune": func() (cli.Command, error) {
+			return &cmd.HistoryPruneCommand{
+				Meta: meta,
+			}, nil
+		},
+		"history show": func() (cli.Command, error) {
+			return &cmd.HistoryShowCommand{
+				Meta: meta,
+			}, nil
+		},
+		"run": func() (cli.Command, error) {
+			return &cmd.RunCommand{
+				Revision:          GitCommit,
+				Version:           ver,
+				VersionPrerelease: rel,
+				Ui:                meta.Ui,
+			}, nil
+		},
+		"runners": func() (cli.Command, error) {
+			return &cmd.RunnersCommand{
 				Ui: meta.Ui,
 			}, nil
 		},
 		"version": func() (cli.Command, error) {
-			ver := Version
-			rel := VersionPrerelease
-			if GitDescribe != "" {
-				ver = GitDescribe
-				// Trim off a leading 'v', we append it anyways.
-				if ver[0] == 'v' {
-					ver = ver[1:]
-				}
-			}
-			if GitDescribe == "" && rel == "" && VersionPrerelease != "" {
-				rel = "dev"
-			}
-
 			return &cmd.VersionCommand{
 				Revision:          GitCommit,
 				Version:           ver,
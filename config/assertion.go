@@ -0,0 +1,57 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Operators of the assertions
+const (
+	OP_EQ       = "=="
+	OP_NE       = "!="
+	OP_GT       = ">"
+	OP_GE       = ">="
+	OP_LT       = "<"
+	OP_LE       = "<="
+	OP_CONTAINS = "contains"
+)
+
+var assertionRegex = regexp.MustCompile(`^([A-Za-z0-9_.]+)\s*(==|!=|>=|<=|>|<|\scontains\s)\s*(.*)$`)
+
+// Assertion is an expectation on a value of a step's output, e.g.
+// `State == "available"` or `Size >= 4G`.
+//
+// The key is a path within the output of the step, while the value
+// can refer to the outputs of the earlier steps via ${<step>.<key>}.
+// Values are compared as numbers or sizes if both the sides are so.
+type Assertion struct {
+	Key   string
+	Op    string
+	Value string
+}
+
+// ParseAssertion parses an assertion of the form <key> <op> <value>.
+// The value may be quoted.
+func ParseAssertion(s string) (*Assertion, error) {
+	m := assertionRegex.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return nil, fmt.Errorf("invalid assertion '%s', expected <key> <op> <value> with op one of ==, !=, >, >=, <, <= or contains", s)
+	}
+
+	value := strings.TrimSpace(m[3])
+	if len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"' {
+		value = value[1 : len(value)-1]
+	}
+
+	return &Assertion{
+		Key:   m[1],
+		Op:    strings.TrimSpace(m[2]),
+		Value: value,
+	}, nil
+}
+
+// String provides the assertion in its declared form
+func (a *Assertion) String() string {
+	return fmt.Sprintf("%s %s %q", a.Key, a.Op, a.Value)
+}
@@ -23,6 +23,54 @@ type MtestConfig struct {
 	// SyslogFacility is used to control the syslog facility used.
 	SyslogFacility string `mapstructure:"syslog_facility"`
 
+	// RunTimeout is the deadline of an entire run, e.g. "2h".
+	// There is no deadline if this is not set.
+	RunTimeout string `mapstructure:"run_timeout"`
+
+	// StepTimeout is the deadline of each scenario step, e.g. "10m".
+	// A step can override this with its own timeout.
+	StepTimeout string `mapstructure:"step_timeout"`
+
+	// Soak is the wall clock duration for which the scenarios are
+	// run in cycles, e.g. "12h". The scenarios are run once if this
+	// is not set.
+	Soak string `mapstructure:"soak"`
+
+	// SoakInterval is the interval at which interim summaries are
+	// logged while soaking, e.g. "5m"
+	SoakInterval string `mapstructure:"soak_interval"`
+
+	// Run & Skip are regular expressions on the names of the use
+	// cases. Only the use cases that match Run & do not match Skip
+	// are run.
+	Run  string `mapstructure:"-"`
+	Skip string `mapstructure:"-"`
+
+	// Tags select the use cases that have any of these tags
+	Tags []string `mapstructure:"-"`
+
+	// StateDir is the directory where mtest persists its state,
+	// e.g. the history of runs
+	StateDir string `mapstructure:"state_dir"`
+
+	// Scenarios are the use cases that a runner will execute
+	Scenarios []*Scenario `mapstructure:"-"`
+
+	// Loads are the load tests that the load runner will execute
+	Loads []*Load `mapstructure:"-"`
+
+	// Faults are injected by the drivers into the requests they send
+	Faults []*Fault `mapstructure:"-"`
+
+	// Random configures the sequences of the random runner
+	Random *Random `mapstructure:"-"`
+
+	// Schedules are the recurring runs of the agent
+	Schedules []*Schedule `mapstructure:"-"`
+
+	// Coordinator configures the workers of the coordinator runner
+	Coordinator *Coordinator `mapstructure:"-"`
+
 	// Version information is set at compilation time
 	Revision          string
 	Version           string
@@ -70,9 +118,16 @@ func DefaultMtestConfig() *MtestConfig {
 	return &MtestConfig{
 		LogLevel:       "INFO",
 		SyslogFacility: "LOCAL0",
+		StateDir:       defaultStateDir(),
 	}
 }
 
+// defaultStateDir provides the state directory within the user's
+// home directory, if known, else within the current directory.
+func defaultStateDir() string {
+	return filepath.Join(os.Getenv("HOME"), ".mtest")
+}
+
 // Merge merges two configurations & returns a new one.
 func (mc *MtestConfig) Merge(b *MtestConfig) *MtestConfig {
 	result := *mc
@@ -87,12 +142,88 @@ func (mc *MtestConfig) Merge(b *MtestConfig) *MtestConfig {
 		result.SyslogFacility = b.SyslogFacility
 	}
 
+	if b.RunTimeout != "" {
+		result.RunTimeout = b.RunTimeout
+	}
+	if b.StepTimeout != "" {
+		result.StepTimeout = b.StepTimeout
+	}
+	if b.Soak != "" {
+		result.Soak = b.Soak
+	}
+	if b.SoakInterval != "" {
+		result.SoakInterval = b.SoakInterval
+	}
+	if b.StateDir != "" {
+		result.StateDir = b.StateDir
+	}
+
+	if b.Run != "" {
+		result.Run = b.Run
+	}
+	if b.Skip != "" {
+		result.Skip = b.Skip
+	}
+	if len(b.Tags) > 0 {
+		result.Tags = b.Tags
+	}
+
+	if b.Revision != "" {
+		result.Revision = b.Revision
+	}
+	if b.Version != "" {
+		result.Version = b.Version
+	}
+	if b.VersionPrerelease != "" {
+		result.VersionPrerelease = b.VersionPrerelease
+	}
+
+	// Merge the scenarios
+	if len(b.Scenarios) > 0 {
+		result.Scenarios = mergeScenarios(result.Scenarios, b.Scenarios)
+	}
+
+	// Merge the load tests
+	if len(b.Loads) > 0 {
+		result.Loads = mergeLoads(result.Loads, b.Loads)
+	}
+
+	// Merge the faults
+	if len(b.Faults) > 0 {
+		result.Faults = mergeFaults(result.Faults, b.Faults)
+	}
+
+	// Merge the random runner's config
+	if b.Random != nil {
+		result.Random = result.Random.Merge(b.Random)
+	}
+
+	// Merge the schedules
+	if len(b.Schedules) > 0 {
+		result.Schedules = mergeSchedules(result.Schedules, b.Schedules)
+	}
+
+	// Merge the coordinator's config
+	if b.Coordinator != nil {
+		result.Coordinator = result.Coordinator.Merge(b.Coordinator)
+	}
+
 	// Merge config files lists
 	result.Files = append(result.Files, b.Files...)
 
 	return &result
 }
 
+// VersionString provides the version of mtest along with its
+// pre-release, if any.
+func (mc *MtestConfig) VersionString() string {
+	if mc.VersionPrerelease == "" {
+		return mc.Version
+	}
+
+	return mc.Version + "-" + mc.VersionPrerelease
+}
+
 // LoadMtestConfig loads the configuration at the given path, regardless if
 // its a file or directory.
 func LoadMtestConfig(path string) (*MtestConfig, error) {
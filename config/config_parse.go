@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/hashicorp/go-multierror"
 	"github.com/hashicorp/hcl"
@@ -72,6 +73,17 @@ func parseConfig(result *MtestConfig, list *ast.ObjectList) error {
 		"log_level",
 		"enable_syslog",
 		"syslog_facility",
+		"run_timeout",
+		"step_timeout",
+		"soak",
+		"soak_interval",
+		"state_dir",
+		"scenario",
+		"load",
+		"fault",
+		"random",
+		"schedule",
+		"coordinator",
 	}
 	if err := checkHCLKeys(list, valid); err != nil {
 		return multierror.Prefix(err, "config:")
@@ -83,11 +95,76 @@ func parseConfig(result *MtestConfig, list *ast.ObjectList) error {
 		return err
 	}
 
+	delete(m, "scenario")
+	delete(m, "load")
+	delete(m, "fault")
+	delete(m, "random")
+	delete(m, "schedule")
+	delete(m, "coordinator")
+
+	// Parse the scenarios
+	if o := list.Filter("scenario"); len(o.Items) > 0 {
+		if err := parseScenarios(&result.Scenarios, o); err != nil {
+			return multierror.Prefix(err, "scenario ->")
+		}
+	}
+
+	// Parse the load tests
+	if o := list.Filter("load"); len(o.Items) > 0 {
+		if err := parseLoads(&result.Loads, o); err != nil {
+			return multierror.Prefix(err, "load ->")
+		}
+	}
+
+	// Parse the faults
+	if o := list.Filter("fault"); len(o.Items) > 0 {
+		if err := parseFaults(&result.Faults, o); err != nil {
+			return multierror.Prefix(err, "fault ->")
+		}
+	}
+
+	// Parse the random runner's config
+	if o := list.Filter("random"); len(o.Items) > 0 {
+		if err := parseRandom(&result.Random, o); err != nil {
+			return err
+		}
+	}
+
+	// Parse the schedules
+	if o := list.Filter("schedule"); len(o.Items) > 0 {
+		if err := parseSchedules(&result.Schedules, o); err != nil {
+			return multierror.Prefix(err, "schedule ->")
+		}
+	}
+
+	// Parse the coordinator's config
+	if o := list.Filter("coordinator"); len(o.Items) > 0 {
+		if err := parseCoordinator(&result.Coordinator, o); err != nil {
+			return err
+		}
+	}
+
 	// Decode the rest
 	if err := mapstructure.WeakDecode(m, result); err != nil {
 		return err
 	}
 
+	if err := checkDuration("run_timeout", result.RunTimeout); err != nil {
+		return err
+	}
+
+	if err := checkDuration("step_timeout", result.StepTimeout); err != nil {
+		return err
+	}
+
+	if err := checkDuration("soak", result.Soak); err != nil {
+		return err
+	}
+
+	if err := checkDuration("soak_interval", result.SoakInterval); err != nil {
+		return err
+	}
+
 	return nil
 }
 
@@ -118,3 +195,16 @@ func checkHCLKeys(node ast.Node, valid []string) error {
 
 	return result
 }
+
+// checkDuration verifies the value, if set, to be a valid duration
+func checkDuration(key, value string) error {
+	if value == "" {
+		return nil
+	}
+
+	if _, err := time.ParseDuration(value); err != nil {
+		return fmt.Errorf("invalid %s: %v", key, err)
+	}
+
+	return nil
+}
@@ -19,12 +19,18 @@ func TestMtestConfig_Merge(t *testing.T) {
 		LogLevel:       "INFO",
 		EnableSyslog:   false,
 		SyslogFacility: "local0.info",
+		Soak:           "1h",
+		SoakInterval:   "1m",
+		StateDir:       "/var/lib/mtest",
 	}
 
 	c2 := &MtestConfig{
 		LogLevel:       "DEBUG",
 		EnableSyslog:   true,
 		SyslogFacility: "local0.debug",
+		Soak:           "12h",
+		SoakInterval:   "5m",
+		StateDir:       "/tmp/mtest",
 	}
 
 	result := c1.Merge(c2)
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Coordinator configures the coordinator runner, which splits the use
+// cases across the mtest agents of several nodes, i.e. its workers, &
+// merges their reports.
+//
+// Example:
+//
+//	coordinator {
+//	  workers       = ["10.0.0.2:5757", "10.0.0.3:5757"]
+//	  runner        = "mserver.runner"
+//	  poll_interval = "2s"
+//	}
+//
+// The token of the agents' APIs, if they have one, is set via token or
+// else the MTEST_AGENT_TOKEN environment variable.
+type Coordinator struct {
+	// Workers are the addresses of the APIs of the agents
+	Workers []string `mapstructure:"workers"`
+
+	// Runner that runs the use cases on the workers, mserver.runner
+	// if not set
+	Runner string `mapstructure:"runner"`
+
+	// PollInterval is the interval at which the workers are polled
+	// for their reports, e.g. "2s"
+	PollInterval string `mapstructure:"poll_interval"`
+
+	// Token that the requests to the agents carry, if set
+	Token string `mapstructure:"token"`
+}
+
+// Copy returns a deep copy of the coordinator's config.
+func (c *Coordinator) Copy() *Coordinator {
+	if c == nil {
+		return nil
+	}
+
+	nc := *c
+	nc.Workers = append([]string(nil), c.Workers...)
+
+	return &nc
+}
+
+// Merge merges the two configs of the coordinator
+func (c *Coordinator) Merge(b *Coordinator) *Coordinator {
+	if c == nil {
+		return b.Copy()
+	}
+
+	result := c.Copy()
+	if b == nil {
+		return result
+	}
+
+	if len(b.Workers) > 0 {
+		result.Workers = append([]string(nil), b.Workers...)
+	}
+	if b.Runner != "" {
+		result.Runner = b.Runner
+	}
+	if b.PollInterval != "" {
+		result.PollInterval = b.PollInterval
+	}
+	if b.Token != "" {
+		result.Token = b.Token
+	}
+
+	return result
+}
+
+func parseCoordinator(result **Coordinator, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'coordinator' block allowed")
+	}
+
+	item := list.Items[0]
+
+	var listVal *ast.ObjectList
+	if ot, ok := item.Val.(*ast.ObjectType); ok {
+		listVal = ot.List
+	} else {
+		return fmt.Errorf("coordinator: should be an object")
+	}
+
+	valid := []string{
+		"workers",
+		"runner",
+		"poll_interval",
+		"token",
+	}
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return multierror.Prefix(err, "coordinator:")
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, item.Val); err != nil {
+		return err
+	}
+
+	coordinator := &Coordinator{}
+	if err := mapstructure.WeakDecode(m, coordinator); err != nil {
+		return err
+	}
+
+	if err := checkDuration("poll_interval", coordinator.PollInterval); err != nil {
+		return multierror.Prefix(err, "coordinator:")
+	}
+
+	*result = coordinator
+	return nil
+}
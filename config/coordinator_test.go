@@ -0,0 +1,77 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseCoordinator(t *testing.T) {
+	mconfig, err := ParseMtestConfig(strings.NewReader(`
+coordinator {
+  workers       = ["10.0.0.2:5757", "10.0.0.3:5757"]
+  runner        = "mserver.runner"
+  poll_interval = "500ms"
+  token         = "secret"
+}
+`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Coordinator{
+		Workers:      []string{"10.0.0.2:5757", "10.0.0.3:5757"},
+		Runner:       "mserver.runner",
+		PollInterval: "500ms",
+		Token:        "secret",
+	}
+	if !reflect.DeepEqual(mconfig.Coordinator, expected) {
+		t.Fatalf("bad coordinator:\nwant: %#v\n got: %#v", expected, mconfig.Coordinator)
+	}
+}
+
+func TestParseCoordinator_Invalid(t *testing.T) {
+	cases := []struct {
+		Config string
+		Err    string
+	}{
+		{
+			`coordinator { workers = ["a"] } coordinator { workers = ["b"] }`,
+			"only one 'coordinator' block allowed",
+		},
+		{
+			`coordinator { nodes = ["a"] }`,
+			"invalid key: nodes",
+		},
+		{
+			`coordinator { poll_interval = "often" }`,
+			"poll_interval",
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseMtestConfig(strings.NewReader(tc.Config))
+		if err == nil {
+			t.Fatalf("expected error, got nothing: %s", tc.Config)
+		}
+
+		if !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("expected error containing %q, got %q", tc.Err, err)
+		}
+	}
+}
+
+func TestCoordinator_Merge(t *testing.T) {
+	a := &MtestConfig{
+		Coordinator: &Coordinator{Workers: []string{"a", "b"}, PollInterval: "1s"},
+	}
+	b := &MtestConfig{
+		Coordinator: &Coordinator{Workers: []string{"c"}, Runner: "mserver.runner"},
+	}
+
+	result := a.Merge(b).Coordinator
+	expected := &Coordinator{Workers: []string{"c"}, Runner: "mserver.runner", PollInterval: "1s"}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("bad merge:\nwant: %#v\n got: %#v", expected, result)
+	}
+}
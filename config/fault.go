@@ -0,0 +1,184 @@
+package config
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/mitchellh/mapstructure"
+	"github.com/openebs/mtest/driver"
+)
+
+// Fault is a failure that is injected by a driver into the requests
+// it sends, e.g. the EBS driver into its EC2 API requests.
+//
+// Example:
+//
+//	fault "throttle-creates" {
+//	  driver      = "ebs"
+//	  operations  = ["CreateVolume", "CreateSnapshot"]
+//	  probability = 0.2
+//	  error       = "throttle"
+//	}
+//
+//	fault "slow-network" {
+//	  driver  = "ebs"
+//	  latency = "2s"
+//	}
+type Fault struct {
+	// Name of the fault
+	Name string `mapstructure:"-"`
+
+	// Name of the driver that injects the fault
+	Driver string `mapstructure:"driver"`
+
+	// Operations that are faulted, all the operations if not set
+	Operations []string `mapstructure:"operations"`
+
+	// Probability with which a request is faulted, between 0 & 1.
+	// Every request is faulted if not set.
+	Probability float64 `mapstructure:"probability"`
+
+	// Latency that is added to a request, e.g. "2s"
+	Latency string `mapstructure:"latency"`
+
+	// Error is the kind of failure, one of throttle, 5xx, drop or
+	// corrupt
+	Error string `mapstructure:"error"`
+}
+
+// DriverFault provides the fault in the form understood by the drivers
+func (f *Fault) DriverFault() *driver.Fault {
+	// The latency has been validated while parsing
+	latency, _ := time.ParseDuration(f.Latency)
+
+	probability := f.Probability
+	if probability == 0 {
+		probability = 1
+	}
+
+	return &driver.Fault{
+		Name:        f.Name,
+		Operations:  f.Operations,
+		Probability: probability,
+		Latency:     latency,
+		Error:       f.Error,
+	}
+}
+
+// DriverConfig provides the config of a driver that has the faults
+// to be injected by that driver
+func (mc *MtestConfig) DriverConfig(name string) map[string]string {
+	config := make(map[string]string)
+
+	for _, f := range mc.Faults {
+		if f.Driver == name {
+			f.DriverFault().Encode(config)
+		}
+	}
+
+	return config
+}
+
+// mergeFaults merges two lists of faults. A fault in b replaces the
+// fault of the same name in a.
+func mergeFaults(a, b []*Fault) []*Fault {
+	result := make([]*Fault, 0, len(a)+len(b))
+	result = append(result, a...)
+
+	for _, fb := range b {
+		replaced := false
+		for i, fa := range result {
+			if fa.Name == fb.Name {
+				result[i] = fb
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			result = append(result, fb)
+		}
+	}
+
+	return result
+}
+
+func parseFaults(result *[]*Fault, list *ast.ObjectList) error {
+	list = list.Children()
+	if len(list.Items) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+
+		if _, ok := seen[n]; ok {
+			return fmt.Errorf("fault '%s' defined more than once", n)
+		}
+		seen[n] = struct{}{}
+
+		var listVal *ast.ObjectList
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("fault '%s': should be an object", n)
+		}
+
+		valid := []string{
+			"driver",
+			"operations",
+			"probability",
+			"latency",
+			"error",
+		}
+		if err := checkHCLKeys(listVal, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("fault '%s':", n))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		fault := &Fault{
+			Name: n,
+		}
+		if err := mapstructure.WeakDecode(m, fault); err != nil {
+			return err
+		}
+
+		if err := checkFault(fault); err != nil {
+			return fmt.Errorf("fault '%s': %v", n, err)
+		}
+
+		*result = append(*result, fault)
+	}
+
+	return nil
+}
+
+// checkFault validates the fault
+func checkFault(f *Fault) error {
+	if f.Driver == "" {
+		return fmt.Errorf("driver is required")
+	}
+
+	if f.Probability < 0 || f.Probability > 1 {
+		return fmt.Errorf("probability should be between 0 & 1")
+	}
+
+	if f.Error != "" && !driver.IsFaultError(f.Error) {
+		return fmt.Errorf("error should be one of %s, %s, %s or %s, got '%s'",
+			driver.FAULT_THROTTLE, driver.FAULT_SERVER_ERROR, driver.FAULT_DROP, driver.FAULT_CORRUPT, f.Error)
+	}
+
+	if f.Error == "" && f.Latency == "" {
+		return fmt.Errorf("at least one of latency or error is required")
+	}
+
+	return checkDuration("latency", f.Latency)
+}
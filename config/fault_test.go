@@ -0,0 +1,75 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/driver"
+)
+
+func TestParseFaults(t *testing.T) {
+	mconfig, err := ParseMtestConfig(strings.NewReader(`
+fault "throttle-creates" {
+  driver      = "ebs"
+  operations  = ["CreateVolume", "CreateSnapshot"]
+  probability = 0.2
+  error       = "throttle"
+}
+
+fault "slow-network" {
+  driver  = "ebs"
+  latency = "2s"
+}
+
+fault "elsewhere" {
+  driver = "other"
+  error  = "drop"
+}
+`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(mconfig.Faults) != 3 {
+		t.Fatalf("bad: expected 3 faults, got %d", len(mconfig.Faults))
+	}
+
+	faults, err := driver.DecodeFaults(mconfig.DriverConfig("ebs"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(faults) != 2 {
+		t.Fatalf("bad: expected 2 faults of ebs, got %v", faults)
+	}
+
+	slow, throttle := faults[0], faults[1]
+	if slow.Name != "slow-network" || slow.Latency != 2*time.Second || slow.Probability != 1 || slow.Error != "" {
+		t.Fatalf("bad fault: %s", slow)
+	}
+
+	if throttle.Probability != 0.2 || throttle.Error != driver.FAULT_THROTTLE || len(throttle.Operations) != 2 {
+		t.Fatalf("bad fault: %s", throttle)
+	}
+}
+
+func TestParseFaults_Invalid(t *testing.T) {
+	cases := []struct {
+		Config string
+		Err    string
+	}{
+		{`fault "a" { error = "drop" }`, "driver is required"},
+		{`fault "a" { driver = "ebs" }`, "at least one of latency or error is required"},
+		{`fault "a" { driver = "ebs" error = "boom" }`, "error should be one of"},
+		{`fault "a" { driver = "ebs" error = "drop" probability = 1.5 }`, "probability should be between 0 & 1"},
+		{`fault "a" { driver = "ebs" latency = "soon" }`, "latency"},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseMtestConfig(strings.NewReader(tc.Config))
+		if err == nil || !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("%s: expected error containing %q, got %v", tc.Config, tc.Err, err)
+		}
+	}
+}
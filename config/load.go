@@ -0,0 +1,226 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Load is a load test that executes an operation repeatedly at a
+// rate or at a concurrency level. The operation is either a single
+// execution of an executor or a run of a declared scenario.
+//
+// Example:
+//
+//	load "create-at-8" {
+//	  tags        = ["volume"]
+//	  driver      = "ebs"
+//	  executor    = "ebs.volume.create.executor"
+//	  name        = "load-${unique}"
+//	  concurrency = 8
+//	  duration    = "10m"
+//	}
+//
+//	load "cycle-at-2-per-sec" {
+//	  scenario    = "create-remove"
+//	  rate        = 2
+//	  concurrency = 16
+//	  ops         = 500
+//	}
+type Load struct {
+	// Name of the load test which is also the use case name
+	Name string `mapstructure:"-"`
+
+	// Tags of the use case that are used to select the use cases
+	// to be run
+	Tags []string `mapstructure:"tags"`
+
+	// Name of the scenario that is run as the operation
+	Scenario string `mapstructure:"scenario"`
+
+	// The executor that is executed as the operation, along with
+	// its driver, request name & request options
+	Driver   string            `mapstructure:"driver"`
+	Executor string            `mapstructure:"executor"`
+	Request  string            `mapstructure:"name"`
+	Options  map[string]string `mapstructure:"options"`
+
+	// Timeout is the deadline of each execution of the executor
+	Timeout string `mapstructure:"timeout"`
+
+	// Rate is the no of operations started per second. The operations
+	// are started back to back by each concurrent worker if not set.
+	Rate float64 `mapstructure:"rate"`
+
+	// Concurrency is the max no of operations in flight. It is 1 if
+	// not set.
+	Concurrency int `mapstructure:"concurrency"`
+
+	// Duration for which the operations are started, e.g. "5m"
+	Duration string `mapstructure:"duration"`
+
+	// Ops is the max no of operations that are started
+	Ops int `mapstructure:"ops"`
+
+	// MaxErrorRate is the fraction of the operations that may fail
+	// for the load test to be successful
+	MaxErrorRate float64 `mapstructure:"max_error_rate"`
+}
+
+// Copy returns a deep copy of the load test.
+func (l *Load) Copy() *Load {
+	if l == nil {
+		return nil
+	}
+
+	nl := *l
+	nl.Tags = append([]string(nil), l.Tags...)
+	nl.Options = make(map[string]string, len(l.Options))
+	for k, v := range l.Options {
+		nl.Options[k] = v
+	}
+
+	return &nl
+}
+
+// Step provides the step that executes the executor of the load test.
+// Nil is returned if the load test runs a scenario.
+func (l *Load) Step() *Step {
+	if l.Scenario != "" {
+		return nil
+	}
+
+	return &Step{
+		Name:     "op",
+		Driver:   l.Driver,
+		Executor: l.Executor,
+		Request:  l.Request,
+		Options:  l.Options,
+		Timeout:  l.Timeout,
+	}
+}
+
+// mergeLoads merges two lists of load tests. A load test in b
+// replaces the load test of the same name in a.
+func mergeLoads(a, b []*Load) []*Load {
+	result := make([]*Load, 0, len(a)+len(b))
+	result = append(result, a...)
+
+	for _, lb := range b {
+		replaced := false
+		for i, la := range result {
+			if la.Name == lb.Name {
+				result[i] = lb
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			result = append(result, lb)
+		}
+	}
+
+	return result
+}
+
+func parseLoads(result *[]*Load, list *ast.ObjectList) error {
+	list = list.Children()
+	if len(list.Items) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+
+		if _, ok := seen[n]; ok {
+			return fmt.Errorf("load '%s' defined more than once", n)
+		}
+		seen[n] = struct{}{}
+
+		var listVal *ast.ObjectList
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("load '%s': should be an object", n)
+		}
+
+		valid := []string{
+			"tags",
+			"scenario",
+			"driver",
+			"executor",
+			"name",
+			"options",
+			"timeout",
+			"rate",
+			"concurrency",
+			"duration",
+			"ops",
+			"max_error_rate",
+		}
+		if err := checkHCLKeys(listVal, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("load '%s':", n))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+		delete(m, "options")
+
+		load := &Load{
+			Name: n,
+		}
+		if err := mapstructure.WeakDecode(m, load); err != nil {
+			return err
+		}
+
+		if o := listVal.Filter("options"); len(o.Items) > 0 {
+			if err := parseStepOptions(&load.Options, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("load '%s', options:", n))
+			}
+		}
+
+		if err := checkLoad(load); err != nil {
+			return fmt.Errorf("load '%s': %v", n, err)
+		}
+
+		*result = append(*result, load)
+	}
+
+	return nil
+}
+
+// checkLoad validates the load test
+func checkLoad(l *Load) error {
+	if l.Scenario == "" && (l.Driver == "" || l.Executor == "") {
+		return fmt.Errorf("either scenario or driver & executor are required")
+	}
+
+	if l.Scenario != "" && (l.Driver != "" || l.Executor != "") {
+		return fmt.Errorf("scenario can not be set along with driver & executor")
+	}
+
+	if l.Rate < 0 || l.Concurrency < 0 || l.Ops < 0 {
+		return fmt.Errorf("rate, concurrency & ops can not be negative")
+	}
+
+	if l.MaxErrorRate < 0 || l.MaxErrorRate > 1 {
+		return fmt.Errorf("max_error_rate should be between 0 & 1")
+	}
+
+	if l.Duration == "" && l.Ops == 0 {
+		return fmt.Errorf("at least one of duration or ops is required")
+	}
+
+	if err := checkDuration("duration", l.Duration); err != nil {
+		return err
+	}
+
+	return checkDuration("timeout", l.Timeout)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseLoads(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("../mockit/", "load_mtest_config.hcl"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mconfig, err := ParseMtestConfigFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(mconfig.Loads) != 2 {
+		t.Fatalf("bad: expected 2 load tests, got %d", len(mconfig.Loads))
+	}
+
+	expected := &Load{
+		Name:        "create-at-8",
+		Driver:      "ebs",
+		Executor:    "ebs.volume.create.executor",
+		Request:     "load-${unique}",
+		Options:     map[string]string{"Size": "1G"},
+		Concurrency: 8,
+		Duration:    "10m",
+	}
+	if !reflect.DeepEqual(mconfig.Loads[0], expected) {
+		t.Fatalf("bad load:\nwant: %#v\n got: %#v", expected, mconfig.Loads[0])
+	}
+
+	cycle := mconfig.Loads[1]
+	if cycle.Scenario != "create-remove" || cycle.Rate != 2 || cycle.Ops != 500 || cycle.MaxErrorRate != 0.01 {
+		t.Fatalf("bad load: %#v", cycle)
+	}
+
+	if cycle.Step() != nil {
+		t.Fatalf("expected no step for a scenario load")
+	}
+}
+
+func TestParseLoads_Invalid(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Config string
+		Err    string
+	}{
+		{
+			"no operation",
+			`load "a" { ops = 1 }`,
+			"either scenario or driver & executor are required",
+		},
+		{
+			"both operations",
+			`load "a" { scenario = "s" driver = "ebs" executor = "x" ops = 1 }`,
+			"scenario can not be set along with driver & executor",
+		},
+		{
+			"unbounded",
+			`load "a" { scenario = "s" }`,
+			"at least one of duration or ops is required",
+		},
+		{
+			"bad error rate",
+			`load "a" { scenario = "s" ops = 1 max_error_rate = 2 }`,
+			"max_error_rate should be between 0 & 1",
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseMtestConfig(strings.NewReader(tc.Config))
+		if err == nil {
+			t.Fatalf("%s: expected error, got nothing", tc.Name)
+		}
+
+		if !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("%s: expected error containing %q, got %q", tc.Name, tc.Err, err)
+		}
+	}
+}
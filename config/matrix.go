@@ -0,0 +1,391 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+)
+
+// MATRIX_REF is the prefix of the references to the parameters of a
+// matrix, e.g. ${matrix.type}
+const MATRIX_REF = "matrix"
+
+var matrixRefRegex = regexp.MustCompile(`\$\{\s*` + MATRIX_REF + `\.([^}\s]+)\s*\}`)
+
+// Matrix declares the parameter axes of a scenario. The scenario is
+// expanded into a use case per combination of the values of its axes,
+// where the steps refer to the values via ${matrix.<axis>}.
+//
+// NOTE: The values are quoted, as HCL drops the bools of a list.
+//
+// Example:
+//
+//	matrix {
+//	  type       = ["gp2", "io1"]
+//	  size       = ["4G", "100G"]
+//	  iops       = ["", "100"]
+//	  encrypted  = ["true", "false"]
+//	  filesystem = ["ext4", "xfs"]
+//
+//	  exclude {
+//	    type = "gp2"
+//	    iops = "100"
+//	  }
+//
+//	  exclude {
+//	    type = "io1"
+//	    iops = ""
+//	  }
+//
+//	  include {
+//	    type       = "st1"
+//	    size       = "500G"
+//	    iops       = ""
+//	    encrypted  = "false"
+//	    filesystem = "xfs"
+//	  }
+//	}
+type Matrix struct {
+	// Axes in their declared order
+	Axes []*MatrixAxis
+
+	// Exclude drops the combinations that match all the values of
+	// any of its entries
+	Exclude []map[string]string
+
+	// Include adds the combinations, each of which has a value for
+	// every axis, unless they are already present
+	Include []map[string]string
+}
+
+// MatrixAxis is a parameter of a scenario & the values it is run with
+type MatrixAxis struct {
+	Name   string
+	Values []string
+}
+
+// Copy returns a deep copy of the matrix.
+func (m *Matrix) Copy() *Matrix {
+	if m == nil {
+		return nil
+	}
+
+	nm := &Matrix{
+		Axes:    make([]*MatrixAxis, 0, len(m.Axes)),
+		Exclude: copyCombinations(m.Exclude),
+		Include: copyCombinations(m.Include),
+	}
+	for _, a := range m.Axes {
+		nm.Axes = append(nm.Axes, &MatrixAxis{
+			Name:   a.Name,
+			Values: append([]string(nil), a.Values...),
+		})
+	}
+
+	return nm
+}
+
+// Combinations provides the combinations of the values of the axes in
+// the order of the axes & their values, followed by the included ones.
+func (m *Matrix) Combinations() []map[string]string {
+	combos := []map[string]string{{}}
+	for _, a := range m.Axes {
+		next := make([]map[string]string, 0, len(combos)*len(a.Values))
+		for _, c := range combos {
+			for _, v := range a.Values {
+				nc := make(map[string]string, len(c)+1)
+				for k, cv := range c {
+					nc[k] = cv
+				}
+				nc[a.Name] = v
+				next = append(next, nc)
+			}
+		}
+		combos = next
+	}
+
+	var result []map[string]string
+	for _, c := range combos {
+		if !m.excluded(c) {
+			result = append(result, c)
+		}
+	}
+
+	for _, inc := range m.Include {
+		if !m.contains(result, inc) {
+			result = append(result, inc)
+		}
+	}
+
+	return result
+}
+
+// Name provides the name of the combination, e.g. type=gp2,size=4G
+func (m *Matrix) Name(combo map[string]string) string {
+	parts := make([]string, 0, len(m.Axes))
+	for _, a := range m.Axes {
+		parts = append(parts, a.Name+"="+combo[a.Name])
+	}
+
+	return strings.Join(parts, ",")
+}
+
+func (m *Matrix) excluded(combo map[string]string) bool {
+	for _, ex := range m.Exclude {
+		matches := true
+		for k, v := range ex {
+			if combo[k] != v {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Matrix) contains(combos []map[string]string, combo map[string]string) bool {
+	name := m.Name(combo)
+	for _, c := range combos {
+		if m.Name(c) == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m *Matrix) axis(name string) *MatrixAxis {
+	for _, a := range m.Axes {
+		if a.Name == name {
+			return a
+		}
+	}
+
+	return nil
+}
+
+// Expand provides a scenario per combination of the scenario's matrix,
+// where the references to the matrix are replaced by the values of the
+// combination. The scenario is named after its combination, e.g.
+// volume-types[type=gp2,size=4G]. A scenario without a matrix is
+// provided as is.
+func (s *Scenario) Expand() []*Scenario {
+	if s.Matrix == nil {
+		return []*Scenario{s}
+	}
+
+	combos := s.Matrix.Combinations()
+	result := make([]*Scenario, 0, len(combos))
+	for _, combo := range combos {
+		ns := s.Copy()
+		ns.Name = fmt.Sprintf("%s[%s]", s.Name, s.Matrix.Name(combo))
+		ns.Matrix = nil
+
+		resolve := func(v string) string {
+			return matrixRefRegex.ReplaceAllStringFunc(v, func(ref string) string {
+				return combo[matrixRefRegex.FindStringSubmatch(ref)[1]]
+			})
+		}
+
+		for _, st := range ns.Steps {
+			st.Request = resolve(st.Request)
+			st.ExpectError = resolve(st.ExpectError)
+			for k, v := range st.Options {
+				st.Options[k] = resolve(v)
+			}
+			for i, a := range st.Assert {
+				st.Assert[i] = resolve(a)
+			}
+		}
+
+		result = append(result, ns)
+	}
+
+	return result
+}
+
+// ExpandScenarios expands the scenarios that have a matrix, retaining
+// the order of the scenarios
+func ExpandScenarios(scenarios []*Scenario) []*Scenario {
+	result := make([]*Scenario, 0, len(scenarios))
+	for _, s := range scenarios {
+		result = append(result, s.Expand()...)
+	}
+
+	return result
+}
+
+func copyCombinations(combos []map[string]string) []map[string]string {
+	if combos == nil {
+		return nil
+	}
+
+	result := make([]map[string]string, 0, len(combos))
+	for _, c := range combos {
+		nc := make(map[string]string, len(c))
+		for k, v := range c {
+			nc[k] = v
+		}
+		result = append(result, nc)
+	}
+
+	return result
+}
+
+func parseMatrix(result **Matrix, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'matrix' block allowed")
+	}
+
+	ot, ok := list.Items[0].Val.(*ast.ObjectType)
+	if !ok {
+		return fmt.Errorf("matrix: should be an object")
+	}
+
+	m := &Matrix{}
+	for _, item := range ot.List.Items {
+		key := item.Keys[0].Token.Value().(string)
+
+		switch key {
+		case "exclude", "include":
+			combo, err := parseCombination(key, item)
+			if err != nil {
+				return err
+			}
+
+			if key == "exclude" {
+				m.Exclude = append(m.Exclude, combo)
+			} else {
+				m.Include = append(m.Include, combo)
+			}
+		default:
+			if m.axis(key) != nil {
+				return fmt.Errorf("matrix: axis '%s' defined more than once", key)
+			}
+
+			var values []interface{}
+			if err := hcl.DecodeObject(&values, item.Val); err != nil {
+				return fmt.Errorf("matrix: axis '%s': %v", key, err)
+			}
+
+			axis := &MatrixAxis{Name: key}
+			for _, v := range values {
+				axis.Values = append(axis.Values, fmt.Sprint(v))
+			}
+			m.Axes = append(m.Axes, axis)
+		}
+	}
+
+	if err := checkMatrix(m); err != nil {
+		return err
+	}
+
+	*result = m
+	return nil
+}
+
+// parseCombination parses an include or exclude block of a matrix
+func parseCombination(key string, item *ast.ObjectItem) (map[string]string, error) {
+	if _, ok := item.Val.(*ast.ObjectType); !ok {
+		return nil, fmt.Errorf("matrix: %s should be an object", key)
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, item.Val); err != nil {
+		return nil, fmt.Errorf("matrix: %s: %v", key, err)
+	}
+
+	combo := make(map[string]string, len(m))
+	for k, v := range m {
+		combo[k] = fmt.Sprint(v)
+	}
+
+	return combo, nil
+}
+
+// checkMatrix validates the axes & the include & exclude rules of a
+// matrix
+func checkMatrix(m *Matrix) error {
+	if len(m.Axes) == 0 {
+		return fmt.Errorf("matrix: at least one axis is required")
+	}
+
+	for _, a := range m.Axes {
+		if len(a.Values) == 0 {
+			return fmt.Errorf("matrix: axis '%s' has no values", a.Name)
+		}
+
+		seen := make(map[string]struct{})
+		for _, v := range a.Values {
+			if _, ok := seen[v]; ok {
+				return fmt.Errorf("matrix: axis '%s' has the value '%s' more than once", a.Name, v)
+			}
+			seen[v] = struct{}{}
+		}
+	}
+
+	for _, ex := range m.Exclude {
+		if len(ex) == 0 {
+			return fmt.Errorf("matrix: exclude should have at least one axis")
+		}
+
+		for k := range ex {
+			if m.axis(k) == nil {
+				return fmt.Errorf("matrix: exclude refers to an unknown axis '%s'", k)
+			}
+		}
+	}
+
+	for _, inc := range m.Include {
+		for k := range inc {
+			if m.axis(k) == nil {
+				return fmt.Errorf("matrix: include refers to an unknown axis '%s'", k)
+			}
+		}
+
+		for _, a := range m.Axes {
+			if _, ok := inc[a.Name]; !ok {
+				return fmt.Errorf("matrix: include should have a value for the axis '%s'", a.Name)
+			}
+		}
+	}
+
+	if len(m.Combinations()) == 0 {
+		return fmt.Errorf("matrix: all the combinations are excluded")
+	}
+
+	return nil
+}
+
+// checkMatrixRefs validates that the steps refer only to the axes of
+// the matrix. A scenario without a matrix can not refer to one.
+func checkMatrixRefs(m *Matrix, steps []*Step) error {
+	for _, st := range steps {
+		values := []string{st.Request, st.ExpectError}
+		values = append(values, st.Assert...)
+		for _, v := range st.Options {
+			values = append(values, v)
+		}
+
+		for _, v := range values {
+			for _, ref := range matrixRefRegex.FindAllStringSubmatch(v, -1) {
+				if m == nil {
+					return fmt.Errorf("step '%s': %s refers to a matrix that is not declared", st.Name, ref[0])
+				}
+				if m.axis(ref[1]) == nil {
+					return fmt.Errorf("step '%s': %s refers to an unknown axis", st.Name, ref[0])
+				}
+			}
+		}
+	}
+
+	return nil
+}
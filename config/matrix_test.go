@@ -0,0 +1,174 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMatrix(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("../mockit/", "matrix_mtest_config.hcl"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mconfig, err := ParseMtestConfigFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	m := mconfig.Scenarios[0].Matrix
+	if m == nil {
+		t.Fatalf("expected a matrix")
+	}
+
+	// Axes must retain their declared order
+	expected := []*MatrixAxis{
+		{Name: "type", Values: []string{"gp2", "io1"}},
+		{Name: "size", Values: []string{"4G", "100G"}},
+		{Name: "iops", Values: []string{"", "100"}},
+		{Name: "encrypted", Values: []string{"true", "false"}},
+	}
+	if !reflect.DeepEqual(m.Axes, expected) {
+		t.Fatalf("bad axes:\nwant: %#v\n got: %#v", expected, m.Axes)
+	}
+
+	if len(m.Exclude) != 2 || len(m.Include) != 1 {
+		t.Fatalf("bad rules: %#v", m)
+	}
+
+	if m.Include[0]["encrypted"] != "false" {
+		t.Fatalf("bad include: %v", m.Include[0])
+	}
+}
+
+func TestScenario_Expand(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("../mockit/", "matrix_mtest_config.hcl"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mconfig, err := ParseMtestConfigFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	scenarios := ExpandScenarios(mconfig.Scenarios)
+
+	var names []string
+	for _, s := range scenarios {
+		names = append(names, s.Name)
+	}
+
+	expected := []string{
+		"volume-types[type=gp2,size=4G,iops=,encrypted=true]",
+		"volume-types[type=gp2,size=4G,iops=,encrypted=false]",
+		"volume-types[type=gp2,size=100G,iops=,encrypted=true]",
+		"volume-types[type=gp2,size=100G,iops=,encrypted=false]",
+		"volume-types[type=io1,size=4G,iops=100,encrypted=true]",
+		"volume-types[type=io1,size=4G,iops=100,encrypted=false]",
+		"volume-types[type=io1,size=100G,iops=100,encrypted=true]",
+		"volume-types[type=io1,size=100G,iops=100,encrypted=false]",
+		"volume-types[type=st1,size=500G,iops=,encrypted=false]",
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("bad use cases:\nwant: %v\n got: %v", expected, names)
+	}
+
+	st1 := scenarios[8]
+	if st1.Matrix != nil || !reflect.DeepEqual(st1.Tags, []string{"volume", "matrix"}) {
+		t.Fatalf("bad scenario: %#v", st1)
+	}
+
+	create := st1.Steps[0]
+	if create.Request != "vol-st1-${unique}" {
+		t.Fatalf("bad request: %s", create.Request)
+	}
+
+	opts := map[string]string{
+		"VolumeType": "st1",
+		"Size":       "500G",
+		"VolumeIOPS": "",
+		"Encrypted":  "false",
+	}
+	if !reflect.DeepEqual(create.Options, opts) {
+		t.Fatalf("bad options:\nwant: %v\n got: %v", opts, create.Options)
+	}
+
+	if st1.Steps[1].Assert[0] != "VolumeType == st1" {
+		t.Fatalf("bad assertion: %s", st1.Steps[1].Assert[0])
+	}
+
+	// The declared scenario is intact
+	if mconfig.Scenarios[0].Steps[0].Options["VolumeType"] != "${matrix.type}" {
+		t.Fatalf("expected the scenario to be intact: %v", mconfig.Scenarios[0].Steps[0].Options)
+	}
+}
+
+func TestParseMatrix_Invalid(t *testing.T) {
+	step := `step "b" { driver = "ebs" executor = "x" name = "${matrix.type}" }`
+
+	cases := []struct {
+		Name   string
+		Config string
+		Err    string
+	}{
+		{
+			"no axes",
+			`scenario "a" { matrix { } ` + step + ` }`,
+			"at least one axis is required",
+		},
+		{
+			"no values",
+			`scenario "a" { matrix { type = [] } ` + step + ` }`,
+			"axis 'type' has no values",
+		},
+		{
+			"duplicate value",
+			`scenario "a" { matrix { type = ["gp2", "gp2"] } ` + step + ` }`,
+			"more than once",
+		},
+		{
+			"unknown axis of exclude",
+			`scenario "a" { matrix { type = ["gp2"] exclude { size = "4G" } } ` + step + ` }`,
+			"exclude refers to an unknown axis 'size'",
+		},
+		{
+			"partial include",
+			`scenario "a" { matrix { type = ["gp2"] size = ["4G"] include { type = "io1" } } ` + step + ` }`,
+			"include should have a value for the axis 'size'",
+		},
+		{
+			"all excluded",
+			`scenario "a" { matrix { type = ["gp2"] exclude { type = "gp2" } } ` + step + ` }`,
+			"all the combinations are excluded",
+		},
+		{
+			"unknown axis",
+			`scenario "a" { matrix { size = ["4G"] } ` + step + ` }`,
+			"${matrix.type} refers to an unknown axis",
+		},
+		{
+			"no matrix",
+			`scenario "a" { ` + step + ` }`,
+			"refers to a matrix that is not declared",
+		},
+		{
+			"reserved step name",
+			`scenario "a" { step "matrix" { driver = "ebs" executor = "x" } }`,
+			"step name 'matrix' is reserved",
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseMtestConfig(strings.NewReader(tc.Config))
+		if err == nil {
+			t.Fatalf("%s: expected error, got nothing", tc.Name)
+		}
+
+		if !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("%s: expected error containing %q, got %q", tc.Name, tc.Err, err)
+		}
+	}
+}
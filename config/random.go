@@ -0,0 +1,146 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Random configures the random runner, which generates random but valid
+// sequences of volume, snapshot & backup operations. A failed sequence
+// can be replayed exactly via its seed.
+//
+// Example:
+//
+//	random {
+//	  seed      = 1536828311
+//	  sequences = 10
+//	  ops       = 40
+//
+//	  options {
+//	    Size = "1G"
+//	  }
+//	}
+type Random struct {
+	// Driver that executes the operations, ebs if not set
+	Driver string `mapstructure:"driver"`
+
+	// Seed of the first sequence, the seed of each later sequence is
+	// one more than its earlier one. A seed is picked if not set.
+	Seed int64 `mapstructure:"seed"`
+
+	// Sequences is the no of sequences that are run, 1 if not set
+	Sequences int `mapstructure:"sequences"`
+
+	// Ops is the no of operations of a sequence, 20 if not set
+	Ops int `mapstructure:"ops"`
+
+	// MaxShrinkRuns is the max no of runs made to shrink a failed
+	// sequence, 50 if not set. A negative value disables shrinking.
+	MaxShrinkRuns int `mapstructure:"max_shrink_runs"`
+
+	// Options of the requests that create the volumes, e.g. Size
+	Options map[string]string `mapstructure:"options"`
+}
+
+// Copy returns a deep copy of the random runner's config.
+func (r *Random) Copy() *Random {
+	if r == nil {
+		return nil
+	}
+
+	nr := *r
+	nr.Options = make(map[string]string, len(r.Options))
+	for k, v := range r.Options {
+		nr.Options[k] = v
+	}
+
+	return &nr
+}
+
+// Merge merges the two configs of the random runner
+func (r *Random) Merge(b *Random) *Random {
+	if r == nil {
+		return b.Copy()
+	}
+
+	result := r.Copy()
+	if b == nil {
+		return result
+	}
+
+	if b.Driver != "" {
+		result.Driver = b.Driver
+	}
+	if b.Seed != 0 {
+		result.Seed = b.Seed
+	}
+	if b.Sequences != 0 {
+		result.Sequences = b.Sequences
+	}
+	if b.Ops != 0 {
+		result.Ops = b.Ops
+	}
+	if b.MaxShrinkRuns != 0 {
+		result.MaxShrinkRuns = b.MaxShrinkRuns
+	}
+	for k, v := range b.Options {
+		result.Options[k] = v
+	}
+
+	return result
+}
+
+func parseRandom(result **Random, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'random' block allowed")
+	}
+
+	item := list.Items[0]
+
+	var listVal *ast.ObjectList
+	if ot, ok := item.Val.(*ast.ObjectType); ok {
+		listVal = ot.List
+	} else {
+		return fmt.Errorf("random: should be an object")
+	}
+
+	valid := []string{
+		"driver",
+		"seed",
+		"sequences",
+		"ops",
+		"max_shrink_runs",
+		"options",
+	}
+	if err := checkHCLKeys(listVal, valid); err != nil {
+		return multierror.Prefix(err, "random:")
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, item.Val); err != nil {
+		return err
+	}
+	delete(m, "options")
+
+	random := &Random{}
+	if err := mapstructure.WeakDecode(m, random); err != nil {
+		return err
+	}
+
+	if o := listVal.Filter("options"); len(o.Items) > 0 {
+		if err := parseStepOptions(&random.Options, o); err != nil {
+			return multierror.Prefix(err, "random, options:")
+		}
+	}
+
+	if random.Sequences < 0 || random.Ops < 0 {
+		return fmt.Errorf("random: sequences & ops can not be negative")
+	}
+
+	*result = random
+	return nil
+}
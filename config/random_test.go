@@ -0,0 +1,89 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseRandom(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("../mockit/", "random_mtest_config.hcl"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mconfig, err := ParseMtestConfigFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := &Random{
+		Seed:          1536828311,
+		Sequences:     10,
+		Ops:           40,
+		MaxShrinkRuns: 100,
+		Options:       map[string]string{"Size": "1G", "VolumeType": "gp2"},
+	}
+	if !reflect.DeepEqual(mconfig.Random, expected) {
+		t.Fatalf("bad random:\nwant: %#v\n got: %#v", expected, mconfig.Random)
+	}
+}
+
+func TestParseRandom_Invalid(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Config string
+		Err    string
+	}{
+		{
+			"more than one",
+			`random { ops = 1 } random { ops = 2 }`,
+			"only one 'random' block allowed",
+		},
+		{
+			"unknown key",
+			`random { steps = 1 }`,
+			"invalid key: steps",
+		},
+		{
+			"negative ops",
+			`random { ops = -1 }`,
+			"sequences & ops can not be negative",
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseMtestConfig(strings.NewReader(tc.Config))
+		if err == nil {
+			t.Fatalf("%s: expected error, got nothing", tc.Name)
+		}
+
+		if !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("%s: expected error containing %q, got %q", tc.Name, tc.Err, err)
+		}
+	}
+}
+
+func TestRandom_Merge(t *testing.T) {
+	a := &MtestConfig{
+		Random: &Random{Seed: 1, Ops: 40, Options: map[string]string{"Size": "1G"}},
+	}
+	b := &MtestConfig{
+		Random: &Random{Seed: 2},
+	}
+
+	result := a.Merge(b).Random
+	expected := &Random{Seed: 2, Ops: 40, Options: map[string]string{"Size": "1G"}}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("bad merge:\nwant: %#v\n got: %#v", expected, result)
+	}
+
+	if a.Random.Seed != 1 {
+		t.Fatalf("expected the merged config to be intact, got %#v", a.Random)
+	}
+
+	if result = (&MtestConfig{}).Merge(b).Random; result.Seed != 2 {
+		t.Fatalf("bad merge: %#v", result)
+	}
+}
@@ -0,0 +1,459 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/mitchellh/mapstructure"
+)
+
+// Scenario is an ordered chain of steps that will be executed by a
+// runner as a single use case.
+//
+// Example:
+//
+//	scenario "snapshot-restore" {
+//	  tags = ["snapshot", "backup"]
+//
+//	  step "create" {
+//	    driver   = "ebs"
+//	    executor = "ebs.volume.create.executor"
+//	    name     = "vol1"
+//	    options {
+//	      Size = "4G"
+//	    }
+//	  }
+//
+//	  step "read" {
+//	    driver   = "ebs"
+//	    executor = "ebs.volume.read.executor"
+//	    options {
+//	      uuid = "${create.Name}"
+//	    }
+//	    assert = [
+//	      "State == available",
+//	      "Size >= 4G",
+//	    ]
+//	  }
+//
+//	  step "snap" {
+//	    driver   = "ebs"
+//	    executor = "ebs.snapshot.create.executor"
+//	    name     = "snap1"
+//	    options {
+//	      VolumeName = "${create.Name}"
+//	    }
+//	  }
+//	}
+type Scenario struct {
+	// Name of the scenario which is also the use case name
+	Name string
+
+	// Tags of the use case that are used to select the use cases
+	// to be run, e.g. smoke or snapshot
+	Tags []string
+
+	// Steps to be executed in the order they were declared
+	Steps []*Step
+
+	// Matrix, if set, expands the scenario into a use case per
+	// combination of its parameters
+	Matrix *Matrix
+}
+
+// Step is a single execution of a driver's executor within a Scenario.
+type Step struct {
+	// Name of the step, unique within its scenario. Later steps can
+	// refer to the outputs of this step via ${<name>.<key>}
+	Name string `mapstructure:"-"`
+
+	// Name of the driver that provides the executor
+	Driver string `mapstructure:"driver"`
+
+	// The executor hint, e.g. ebs.snapshot.create.executor
+	Executor string `mapstructure:"executor"`
+
+	// The driver.Request name
+	Request string `mapstructure:"name"`
+
+	// The driver.Request options
+	Options map[string]string `mapstructure:"options"`
+
+	// Timeout is the deadline of this step, e.g. "5m". This overrides
+	// the step_timeout of the config.
+	Timeout string `mapstructure:"timeout"`
+
+	// Attempts is the maximum no of times this step is executed till
+	// it succeeds. The step is executed once if this is not set.
+	Attempts int `mapstructure:"attempts"`
+
+	// Assert are the expectations on the output of this step, e.g.
+	// `State == "available"`. The step fails if any of them fails.
+	Assert []string `mapstructure:"assert"`
+
+	// ExpectError is a substring of the error that this step is
+	// expected to fail with. The step fails if it succeeds.
+	ExpectError string `mapstructure:"expect_error"`
+
+	// Worker places this step on a worker of the coordinator runner,
+	// 1 being its first worker, e.g. to attach a volume on one node &
+	// then on another. The step is run on the use case's worker if
+	// this is not set. Other runners ignore it.
+	Worker int `mapstructure:"worker"`
+}
+
+// Copy returns a deep copy of the scenario.
+func (s *Scenario) Copy() *Scenario {
+	if s == nil {
+		return nil
+	}
+
+	ns := *s
+	ns.Tags = append([]string(nil), s.Tags...)
+	ns.Matrix = s.Matrix.Copy()
+	ns.Steps = make([]*Step, 0, len(s.Steps))
+	for _, step := range s.Steps {
+		ns.Steps = append(ns.Steps, step.Copy())
+	}
+
+	return &ns
+}
+
+// Copy returns a deep copy of the step.
+func (st *Step) Copy() *Step {
+	if st == nil {
+		return nil
+	}
+
+	nst := *st
+	nst.Options = make(map[string]string, len(st.Options))
+	for k, v := range st.Options {
+		nst.Options[k] = v
+	}
+	nst.Assert = append([]string(nil), st.Assert...)
+
+	return &nst
+}
+
+// FormatScenarios provides the scenarios in HCL, which parses back to
+// the same scenarios. This lets the scenarios be sent as the config of
+// a run, e.g. to the mtest agent of another node.
+func FormatScenarios(scenarios []*Scenario) string {
+	var buf bytes.Buffer
+	for _, s := range scenarios {
+		fmt.Fprintf(&buf, "scenario %s {\n", strconv.Quote(s.Name))
+		if len(s.Tags) > 0 {
+			fmt.Fprintf(&buf, "  tags = %s\n", formatList(s.Tags))
+		}
+		if s.Matrix != nil {
+			formatMatrix(&buf, s.Matrix)
+		}
+
+		for _, st := range s.Steps {
+			fmt.Fprintf(&buf, "\n  step %s {\n", strconv.Quote(st.Name))
+			fmt.Fprintf(&buf, "    driver = %s\n", strconv.Quote(st.Driver))
+			fmt.Fprintf(&buf, "    executor = %s\n", strconv.Quote(st.Executor))
+			if st.Request != "" {
+				fmt.Fprintf(&buf, "    name = %s\n", strconv.Quote(st.Request))
+			}
+			if st.Timeout != "" {
+				fmt.Fprintf(&buf, "    timeout = %s\n", strconv.Quote(st.Timeout))
+			}
+			if st.Attempts > 0 {
+				fmt.Fprintf(&buf, "    attempts = %d\n", st.Attempts)
+			}
+			if len(st.Assert) > 0 {
+				fmt.Fprintf(&buf, "    assert = %s\n", formatList(st.Assert))
+			}
+			if st.ExpectError != "" {
+				fmt.Fprintf(&buf, "    expect_error = %s\n", strconv.Quote(st.ExpectError))
+			}
+			if st.Worker > 0 {
+				fmt.Fprintf(&buf, "    worker = %d\n", st.Worker)
+			}
+			if len(st.Options) > 0 {
+				buf.WriteString("    options {\n")
+				for _, k := range sortedKeys(st.Options) {
+					fmt.Fprintf(&buf, "      %s = %s\n", strconv.Quote(k), strconv.Quote(st.Options[k]))
+				}
+				buf.WriteString("    }\n")
+			}
+			buf.WriteString("  }\n")
+		}
+		buf.WriteString("}\n\n")
+	}
+
+	return buf.String()
+}
+
+// formatMatrix writes the matrix block of a scenario in HCL
+func formatMatrix(buf *bytes.Buffer, m *Matrix) {
+	buf.WriteString("\n  matrix {\n")
+	for _, a := range m.Axes {
+		fmt.Fprintf(buf, "    %s = %s\n", strconv.Quote(a.Name), formatList(a.Values))
+	}
+	for _, c := range m.Exclude {
+		formatCombination(buf, "exclude", c)
+	}
+	for _, c := range m.Include {
+		formatCombination(buf, "include", c)
+	}
+	buf.WriteString("  }\n")
+}
+
+func formatCombination(buf *bytes.Buffer, key string, combo map[string]string) {
+	fmt.Fprintf(buf, "    %s {\n", key)
+	for _, k := range sortedKeys(combo) {
+		fmt.Fprintf(buf, "      %s = %s\n", strconv.Quote(k), strconv.Quote(combo[k]))
+	}
+	buf.WriteString("    }\n")
+}
+
+func formatList(values []string) string {
+	quoted := make([]string, 0, len(values))
+	for _, v := range values {
+		quoted = append(quoted, strconv.Quote(v))
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// mergeScenarios merges two lists of scenarios. A scenario in b
+// replaces the scenario of the same name in a, while new ones are
+// appended in their order.
+func mergeScenarios(a, b []*Scenario) []*Scenario {
+	result := make([]*Scenario, 0, len(a)+len(b))
+	result = append(result, a...)
+
+	for _, sb := range b {
+		replaced := false
+		for i, sa := range result {
+			if sa.Name == sb.Name {
+				result[i] = sb
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			result = append(result, sb)
+		}
+	}
+
+	return result
+}
+
+func parseScenarios(result *[]*Scenario, list *ast.ObjectList) error {
+	list = list.Children()
+	if len(list.Items) == 0 {
+		return nil
+	}
+
+	// Go through each object and turn it into an actual result.
+	seen := make(map[string]struct{})
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+
+		// Make sure we haven't already found this
+		if _, ok := seen[n]; ok {
+			return fmt.Errorf("scenario '%s' defined more than once", n)
+		}
+		seen[n] = struct{}{}
+
+		// We need this later
+		var listVal *ast.ObjectList
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("scenario '%s': should be an object", n)
+		}
+
+		// Check for invalid keys
+		valid := []string{
+			"tags",
+			"step",
+			"matrix",
+		}
+		if err := checkHCLKeys(listVal, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("scenario '%s':", n))
+		}
+
+		scenario := &Scenario{
+			Name: n,
+		}
+
+		if o := listVal.Filter("tags"); len(o.Items) > 0 {
+			if err := parseTags(&scenario.Tags, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("scenario '%s':", n))
+			}
+		}
+
+		if o := listVal.Filter("step"); len(o.Items) > 0 {
+			if err := parseSteps(&scenario.Steps, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("scenario '%s':", n))
+			}
+		}
+
+		if len(scenario.Steps) == 0 {
+			return fmt.Errorf("scenario '%s': at least one step is required", n)
+		}
+
+		if o := listVal.Filter("matrix"); len(o.Items) > 0 {
+			if err := parseMatrix(&scenario.Matrix, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("scenario '%s':", n))
+			}
+		}
+
+		if err := checkMatrixRefs(scenario.Matrix, scenario.Steps); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("scenario '%s':", n))
+		}
+
+		*result = append(*result, scenario)
+	}
+
+	return nil
+}
+
+func parseSteps(result *[]*Step, list *ast.ObjectList) error {
+	list = list.Children()
+	if len(list.Items) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+
+		if _, ok := seen[n]; ok {
+			return fmt.Errorf("step '%s' defined more than once", n)
+		}
+
+		// ${unique} & ${matrix.<axis>} are builtin references
+		// of the scenarios
+		if n == "unique" || n == MATRIX_REF {
+			return fmt.Errorf("step name '%s' is reserved", n)
+		}
+		seen[n] = struct{}{}
+
+		var listVal *ast.ObjectList
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("step '%s': should be an object", n)
+		}
+
+		valid := []string{
+			"driver",
+			"executor",
+			"name",
+			"options",
+			"timeout",
+			"attempts",
+			"assert",
+			"expect_error",
+			"worker",
+		}
+		if err := checkHCLKeys(listVal, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("step '%s':", n))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		// Options are decoded separately as HCL decodes
+		// a block into a list of maps
+		delete(m, "options")
+
+		step := &Step{
+			Name: n,
+		}
+		if err := mapstructure.WeakDecode(m, step); err != nil {
+			return err
+		}
+
+		if o := listVal.Filter("options"); len(o.Items) > 0 {
+			if err := parseStepOptions(&step.Options, o); err != nil {
+				return multierror.Prefix(err, fmt.Sprintf("step '%s', options:", n))
+			}
+		}
+
+		if step.Driver == "" {
+			return fmt.Errorf("step '%s': driver is required", n)
+		}
+
+		if step.Executor == "" {
+			return fmt.Errorf("step '%s': executor is required", n)
+		}
+
+		if err := checkDuration("timeout", step.Timeout); err != nil {
+			return fmt.Errorf("step '%s': %v", n, err)
+		}
+
+		if step.Attempts < 0 {
+			return fmt.Errorf("step '%s': attempts can not be negative", n)
+		}
+
+		if step.Worker < 0 {
+			return fmt.Errorf("step '%s': worker can not be negative", n)
+		}
+
+		for _, a := range step.Assert {
+			if _, err := ParseAssertion(a); err != nil {
+				return fmt.Errorf("step '%s': %v", n, err)
+			}
+		}
+
+		*result = append(*result, step)
+	}
+
+	return nil
+}
+
+func parseTags(result *[]string, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'tags' allowed")
+	}
+
+	var tags []string
+	if err := hcl.DecodeObject(&tags, list.Items[0].Val); err != nil {
+		return fmt.Errorf("tags: %v", err)
+	}
+
+	*result = tags
+	return nil
+}
+
+func parseStepOptions(result *map[string]string, list *ast.ObjectList) error {
+	if len(list.Items) > 1 {
+		return fmt.Errorf("only one 'options' block allowed")
+	}
+
+	var m map[string]interface{}
+	if err := hcl.DecodeObject(&m, list.Items[0].Val); err != nil {
+		return err
+	}
+
+	opts := make(map[string]string)
+	if err := mapstructure.WeakDecode(m, &opts); err != nil {
+		return err
+	}
+
+	*result = opts
+	return nil
+}
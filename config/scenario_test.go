@@ -0,0 +1,265 @@
+package config
+
+import (
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseScenarios(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("../mockit/", "scenario_mtest_config.hcl"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mconfig, err := ParseMtestConfigFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(mconfig.Scenarios) != 1 {
+		t.Fatalf("bad: expected 1 scenario, got %d", len(mconfig.Scenarios))
+	}
+
+	scenario := mconfig.Scenarios[0]
+	if scenario.Name != "snapshot-restore" {
+		t.Fatalf("bad scenario name: %q", scenario.Name)
+	}
+
+	if !reflect.DeepEqual(scenario.Tags, []string{"snapshot", "backup"}) {
+		t.Fatalf("bad tags: %v", scenario.Tags)
+	}
+
+	// Steps must retain their declared order
+	var names []string
+	for _, step := range scenario.Steps {
+		names = append(names, step.Name)
+	}
+	expected := []string{"create", "snap", "backup", "restore", "remove"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Fatalf("bad step order:\nwant: %v\n got: %v", expected, names)
+	}
+
+	create := &Step{
+		Name:     "create",
+		Driver:   "ebs",
+		Executor: "ebs.volume.create.executor",
+		Request:  "vol1",
+		Options: map[string]string{
+			"Size":       "4G",
+			"VolumeType": "gp2",
+		},
+	}
+	if !reflect.DeepEqual(scenario.Steps[0], create) {
+		t.Fatalf("bad step:\nwant: %#v\n got: %#v", create, scenario.Steps[0])
+	}
+
+	if scenario.Steps[2].Attempts != 3 {
+		t.Fatalf("bad attempts: %d", scenario.Steps[2].Attempts)
+	}
+
+	if scenario.Steps[3].Options["BackupURL"] != "${backup.BackupURL}" {
+		t.Fatalf("bad options: %#v", scenario.Steps[3].Options)
+	}
+}
+
+func TestParseScenarios_Invalid(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Config string
+		Err    string
+	}{
+		{
+			"unknown step key",
+			`scenario "a" { step "b" { driver = "ebs" executor = "x" foo = "bar" } }`,
+			"invalid key: foo",
+		},
+		{
+			"missing executor",
+			`scenario "a" { step "b" { driver = "ebs" } }`,
+			"executor is required",
+		},
+		{
+			"negative attempts",
+			`scenario "a" { step "b" { driver = "ebs" executor = "x" attempts = -1 } }`,
+			"attempts can not be negative",
+		},
+		{
+			"negative worker",
+			`scenario "a" { step "b" { driver = "ebs" executor = "x" worker = -1 } }`,
+			"worker can not be negative",
+		},
+		{
+			"reserved step name",
+			`scenario "a" { step "unique" { driver = "ebs" executor = "x" } }`,
+			"step name 'unique' is reserved",
+		},
+		{
+			"invalid assertion",
+			`scenario "a" { step "b" { driver = "ebs" executor = "x" assert = ["State is available"] } }`,
+			"invalid assertion 'State is available'",
+		},
+		{
+			"no steps",
+			`scenario "a" { }`,
+			"at least one step is required",
+		},
+		{
+			"duplicate step",
+			`scenario "a" {
+			  step "b" { driver = "ebs" executor = "x" }
+			  step "b" { driver = "ebs" executor = "x" }
+			}`,
+			"defined more than once",
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseMtestConfig(strings.NewReader(tc.Config))
+		if err == nil {
+			t.Fatalf("%s: expected error, got nothing", tc.Name)
+		}
+
+		if !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("%s: expected error containing %q, got %q", tc.Name, tc.Err, err)
+		}
+	}
+}
+
+func TestParseScenarios_Assertions(t *testing.T) {
+	mconfig, err := ParseMtestConfig(strings.NewReader(`
+scenario "negative" {
+  step "read" {
+    driver   = "ebs"
+    executor = "ebs.volume.read.executor"
+    assert   = ["State == \"available\"", "Size >= 4G", "Tags.Name contains vol"]
+  }
+
+  step "remove-twice" {
+    driver       = "ebs"
+    executor     = "ebs.volume.remove.executor"
+    expect_error = "not found"
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	steps := mconfig.Scenarios[0].Steps
+	if len(steps[0].Assert) != 3 || steps[1].ExpectError != "not found" {
+		t.Fatalf("bad steps: %#v, %#v", steps[0], steps[1])
+	}
+
+	expected := []*Assertion{
+		{Key: "State", Op: OP_EQ, Value: "available"},
+		{Key: "Size", Op: OP_GE, Value: "4G"},
+		{Key: "Tags.Name", Op: OP_CONTAINS, Value: "vol"},
+	}
+	for i, s := range steps[0].Assert {
+		a, err := ParseAssertion(s)
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		if !reflect.DeepEqual(a, expected[i]) {
+			t.Fatalf("bad assertion:\nwant: %#v\n got: %#v", expected[i], a)
+		}
+	}
+}
+
+func TestMtestConfig_MergeScenarios(t *testing.T) {
+	step := &Step{Name: "s", Driver: "ebs", Executor: "x"}
+
+	c1 := &MtestConfig{
+		Scenarios: []*Scenario{
+			{Name: "a", Steps: []*Step{step}},
+			{Name: "b", Steps: []*Step{step}},
+		},
+	}
+
+	replaced := &Scenario{Name: "a", Steps: []*Step{step, step}}
+	c2 := &MtestConfig{
+		Scenarios: []*Scenario{
+			replaced,
+			{Name: "c", Steps: []*Step{step}},
+		},
+	}
+
+	result := c1.Merge(c2)
+
+	var names []string
+	for _, s := range result.Scenarios {
+		names = append(names, s.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b", "c"}) {
+		t.Fatalf("bad: %v", names)
+	}
+
+	if result.Scenarios[0] != replaced {
+		t.Fatalf("bad: scenario 'a' was not replaced")
+	}
+}
+
+func TestFormatScenarios(t *testing.T) {
+	path, err := filepath.Abs(filepath.Join("../mockit/", "scenario_mtest_config.hcl"))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mconfig, err := ParseMtestConfigFile(path)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	matrix, err := ParseMtestConfig(strings.NewReader(`
+scenario "volume-types" {
+  tags = ["matrix"]
+
+  matrix {
+    type = ["gp2", "io1"]
+    size = ["4G", "8G"]
+    exclude { type = "io1", size = "4G" }
+    include { type = "st1", size = "500G" }
+  }
+
+  step "create" {
+    driver       = "ebs"
+    executor     = "ebs.volume.create.executor"
+    timeout      = "1m"
+    expect_error = "quoted \"${matrix.type}\""
+    worker       = 2
+    options {
+      VolumeType = "${matrix.type}"
+      Size       = "${matrix.size}"
+    }
+  }
+}
+`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	scenarios := append(mconfig.Scenarios, matrix.Scenarios...)
+	result, err := ParseMtestConfig(strings.NewReader(FormatScenarios(scenarios)))
+	if err != nil {
+		t.Fatalf("err: %s\n%s", err, FormatScenarios(scenarios))
+	}
+	if !reflect.DeepEqual(result.Scenarios, scenarios) {
+		t.Fatalf("bad scenarios:\nwant: %#v\n got: %#v", scenarios, result.Scenarios)
+	}
+
+	// The expanded scenarios are named after their combinations
+	expanded := ExpandScenarios(matrix.Scenarios)
+	result, err = ParseMtestConfig(strings.NewReader(FormatScenarios(expanded)))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(result.Scenarios) != len(expanded) || result.Scenarios[0].Name != "volume-types[type=gp2,size=4G]" {
+		t.Fatalf("bad scenarios: %#v", result.Scenarios)
+	}
+	if opts := result.Scenarios[0].Steps[0].Options; opts["VolumeType"] != "gp2" || opts["Size"] != "4G" {
+		t.Fatalf("bad options: %#v", opts)
+	}
+}
@@ -0,0 +1,150 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/hcl"
+	"github.com/hashicorp/hcl/hcl/ast"
+	"github.com/mitchellh/mapstructure"
+	"github.com/openebs/mtest/cron"
+)
+
+// Schedule is a recurring run of the use cases by a long running mtest
+// i.e. the agent. A scheduled run is skipped if a run is in progress.
+//
+// Example:
+//
+//	schedule "smoke" {
+//	  cron   = "*/15 * * * *"
+//	  run    = "^smoke"
+//	  jitter = "1m"
+//	  retain = 20
+//	}
+//
+//	schedule "nightly-ebs" {
+//	  cron = "0 2 * * *"
+//	  tags = ["ebs"]
+//	}
+type Schedule struct {
+	// Name of the schedule
+	Name string `mapstructure:"-"`
+
+	// Cron is the cron expression of the times of the runs, e.g.
+	// "0 2 * * *", "@daily" or "@every 15m"
+	Cron string `mapstructure:"cron"`
+
+	// Runner is the name of the runner, mserver.runner if not set
+	Runner string `mapstructure:"runner"`
+
+	// Run, Skip & Tags select the use cases that are run
+	Run  string   `mapstructure:"run"`
+	Skip string   `mapstructure:"skip"`
+	Tags []string `mapstructure:"tags"`
+
+	// Jitter is the max random delay of each run, e.g. "1m", so that
+	// the runs of several nodes do not start at the same time
+	Jitter string `mapstructure:"jitter"`
+
+	// Retain is the no of the last results of the schedule that are
+	// retained, 10 if not set
+	Retain int `mapstructure:"retain"`
+}
+
+// mergeSchedules merges two lists of schedules. A schedule in b replaces
+// the schedule of the same name in a.
+func mergeSchedules(a, b []*Schedule) []*Schedule {
+	result := make([]*Schedule, 0, len(a)+len(b))
+	result = append(result, a...)
+
+	for _, sb := range b {
+		replaced := false
+		for i, sa := range result {
+			if sa.Name == sb.Name {
+				result[i] = sb
+				replaced = true
+				break
+			}
+		}
+
+		if !replaced {
+			result = append(result, sb)
+		}
+	}
+
+	return result
+}
+
+func parseSchedules(result *[]*Schedule, list *ast.ObjectList) error {
+	list = list.Children()
+	if len(list.Items) == 0 {
+		return nil
+	}
+
+	seen := make(map[string]struct{})
+	for _, item := range list.Items {
+		n := item.Keys[0].Token.Value().(string)
+
+		if _, ok := seen[n]; ok {
+			return fmt.Errorf("schedule '%s' defined more than once", n)
+		}
+		seen[n] = struct{}{}
+
+		var listVal *ast.ObjectList
+		if ot, ok := item.Val.(*ast.ObjectType); ok {
+			listVal = ot.List
+		} else {
+			return fmt.Errorf("schedule '%s': should be an object", n)
+		}
+
+		valid := []string{
+			"cron",
+			"runner",
+			"run",
+			"skip",
+			"tags",
+			"jitter",
+			"retain",
+		}
+		if err := checkHCLKeys(listVal, valid); err != nil {
+			return multierror.Prefix(err, fmt.Sprintf("schedule '%s':", n))
+		}
+
+		var m map[string]interface{}
+		if err := hcl.DecodeObject(&m, item.Val); err != nil {
+			return err
+		}
+
+		schedule := &Schedule{
+			Name: n,
+		}
+		if err := mapstructure.WeakDecode(m, schedule); err != nil {
+			return err
+		}
+
+		if err := checkSchedule(schedule); err != nil {
+			return fmt.Errorf("schedule '%s': %v", n, err)
+		}
+
+		*result = append(*result, schedule)
+	}
+
+	return nil
+}
+
+// checkSchedule validates the schedule
+func checkSchedule(s *Schedule) error {
+	if s.Cron == "" {
+		return fmt.Errorf("cron is required")
+	}
+
+	if _, err := cron.Parse(s.Cron); err != nil {
+		return err
+	}
+
+	if s.Retain < 0 {
+		return fmt.Errorf("retain can not be negative")
+	}
+
+	return checkDuration("jitter", s.Jitter)
+}
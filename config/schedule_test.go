@@ -0,0 +1,97 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSchedules(t *testing.T) {
+	mconfig, err := ParseMtestConfig(strings.NewReader(`
+schedule "smoke" {
+  cron   = "*/15 * * * *"
+  run    = "^smoke"
+  jitter = "1m"
+  retain = 20
+}
+
+schedule "nightly-ebs" {
+  cron   = "@daily"
+  runner = "mserver.runner"
+  tags   = ["ebs"]
+}
+`))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []*Schedule{
+		{Name: "smoke", Cron: "*/15 * * * *", Run: "^smoke", Jitter: "1m", Retain: 20},
+		{Name: "nightly-ebs", Cron: "@daily", Runner: "mserver.runner", Tags: []string{"ebs"}},
+	}
+	if !reflect.DeepEqual(mconfig.Schedules, expected) {
+		t.Fatalf("bad schedules:\nwant: %#v\n got: %#v", expected, mconfig.Schedules)
+	}
+}
+
+func TestParseSchedules_Invalid(t *testing.T) {
+	cases := []struct {
+		Config string
+		Err    string
+	}{
+		{
+			`schedule "a" { cron = "@daily" } schedule "a" { cron = "@daily" }`,
+			"schedule 'a' defined more than once",
+		},
+		{
+			`schedule "a" { every = "1h" }`,
+			"invalid key: every",
+		},
+		{
+			`schedule "a" { run = "smoke" }`,
+			"cron is required",
+		},
+		{
+			`schedule "a" { cron = "0 25 * * *" }`,
+			"hour: value 25 out of range",
+		},
+		{
+			`schedule "a" { cron = "@daily", jitter = "soon" }`,
+			"invalid jitter",
+		},
+		{
+			`schedule "a" { cron = "@daily", retain = -1 }`,
+			"retain can not be negative",
+		},
+	}
+
+	for _, tc := range cases {
+		_, err := ParseMtestConfig(strings.NewReader(tc.Config))
+		if err == nil {
+			t.Fatalf("expected error, got nothing: %s", tc.Config)
+		}
+
+		if !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("expected error containing %q, got %q", tc.Err, err)
+		}
+	}
+}
+
+func TestSchedules_Merge(t *testing.T) {
+	a := &MtestConfig{
+		Schedules: []*Schedule{{Name: "smoke", Cron: "@hourly"}, {Name: "nightly", Cron: "@daily"}},
+	}
+	b := &MtestConfig{
+		Schedules: []*Schedule{{Name: "smoke", Cron: "*/15 * * * *"}, {Name: "weekly", Cron: "@weekly"}},
+	}
+
+	result := a.Merge(b).Schedules
+	expected := []*Schedule{
+		{Name: "smoke", Cron: "*/15 * * * *"},
+		{Name: "nightly", Cron: "@daily"},
+		{Name: "weekly", Cron: "@weekly"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Fatalf("bad merge:\nwant: %#v\n got: %#v", expected, result)
+	}
+}
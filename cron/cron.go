@@ -0,0 +1,230 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLookahead is how far Next looks for a matching time, after which
+// the schedule is taken to never match, e.g. the 30th of February
+const maxLookahead = 5 * 366 * 24 * time.Hour
+
+// descriptors are the shorthands of the common schedules
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// field is the range of the values of a field of a cron expression
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+// Schedule is a parsed cron expression, which provides the times at
+// which it fires.
+//
+// The expression is either the standard 5 fields i.e. minute, hour, day
+// of month, month & day of week, where each field is a '*', a value, a
+// range 'a-b', a step '*/n' or 'a-b/n', or a list of these, e.g.
+// "*/15 * * * *" or "0 2 * * 1-5". A day of week of 7 is Sunday too.
+// The descriptors @yearly, @monthly, @weekly, @daily & @hourly are
+// supported, along with '@every <duration>', e.g. "@every 90s".
+type Schedule struct {
+	expr string
+
+	// every is the interval of an '@every' schedule
+	every time.Duration
+
+	// The values that match each field
+	minute, hour, dom, month, dow map[int]bool
+
+	// Whether the day of month & day of week start with a '*', e.g.
+	// '*' or '*/2'. As per cron, if both do not, a day matches if
+	// either of them matches.
+	domStar, dowStar bool
+}
+
+// Parse parses the cron expression
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	s := &Schedule{expr: expr}
+
+	if strings.HasPrefix(expr, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(expr, "@every ")))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron '%s': %v", expr, err)
+		}
+		if d <= 0 {
+			return nil, fmt.Errorf("invalid cron '%s': interval should be positive", expr)
+		}
+		s.every = d
+		return s, nil
+	}
+
+	spec := expr
+	if d, ok := descriptors[expr]; ok {
+		spec = d
+	}
+
+	parts := strings.Fields(spec)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("invalid cron '%s': expected %d fields, got %d", expr, len(fields), len(parts))
+	}
+
+	values := make([]map[int]bool, len(fields))
+	for i, f := range fields {
+		max := f.max
+		if f.name == "day of week" {
+			max = 7
+		}
+
+		v, err := parseField(parts[i], f.min, max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron '%s': %s: %v", expr, f.name, err)
+		}
+		values[i] = v
+	}
+
+	s.minute, s.hour, s.dom, s.month, s.dow = values[0], values[1], values[2], values[3], values[4]
+	s.domStar = strings.HasPrefix(parts[2], "*")
+	s.dowStar = strings.HasPrefix(parts[4], "*")
+
+	// Sunday is either 0 or 7
+	if s.dow[7] {
+		s.dow[0] = true
+	}
+
+	return s, nil
+}
+
+// parseField parses a field into the values that it matches
+func parseField(s string, min, max int) (map[int]bool, error) {
+	values := make(map[int]bool)
+
+	for _, part := range strings.Split(s, ",") {
+		rng, step := part, 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid step '%s'", part[i+1:])
+			}
+			rng, step = part[:i], n
+		}
+
+		lo, hi := min, max
+		switch {
+		case rng == "*":
+		case strings.Contains(rng, "-"):
+			bounds := strings.SplitN(rng, "-", 2)
+			var err error
+			if lo, err = parseValue(bounds[0], min, max); err != nil {
+				return nil, err
+			}
+			if hi, err = parseValue(bounds[1], min, max); err != nil {
+				return nil, err
+			}
+			if lo > hi {
+				return nil, fmt.Errorf("invalid range '%s'", rng)
+			}
+		default:
+			v, err := parseValue(rng, min, max)
+			if err != nil {
+				return nil, err
+			}
+			lo = v
+			if step == 1 {
+				hi = v
+			}
+		}
+
+		for v := lo; v <= hi; v += step {
+			values[v] = true
+		}
+	}
+
+	return values, nil
+}
+
+func parseValue(s string, min, max int) (int, error) {
+	v, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value '%s'", s)
+	}
+	if v < min || v > max {
+		return 0, fmt.Errorf("value %d out of range [%d, %d]", v, min, max)
+	}
+	return v, nil
+}
+
+// String provides the cron expression of the schedule
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Next provides the first time after t at which the schedule fires. The
+// zero time is returned if the schedule never fires.
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every)
+	}
+
+	loc := t.Location()
+	limit := t.Add(maxLookahead)
+
+	// The schedule fires on whole minutes
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+
+	for t.Before(limit) {
+		if !s.month[int(t.Month())] {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+
+		if !s.hour[t.Hour()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+
+		if !s.minute[t.Minute()] {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+			continue
+		}
+
+		return t
+	}
+
+	return time.Time{}
+}
+
+// dayMatches indicates if the day of t matches the day of month & the
+// day of week of the schedule
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := s.dom[t.Day()]
+	dow := s.dow[int(t.Weekday())]
+
+	if s.domStar || s.dowStar {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,71 @@
+package cron
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSchedule_Next(t *testing.T) {
+	// A Wednesday
+	from := time.Date(2026, time.January, 14, 10, 7, 30, 0, time.UTC)
+
+	cases := []struct {
+		Expr string
+		Next time.Time
+	}{
+		{"* * * * *", time.Date(2026, time.January, 14, 10, 8, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, time.January, 14, 10, 15, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2026, time.January, 15, 2, 0, 0, 0, time.UTC)},
+		{"30 9-17/4 * * *", time.Date(2026, time.January, 14, 13, 30, 0, 0, time.UTC)},
+		{"0 0 * * 6,7", time.Date(2026, time.January, 17, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 1", time.Date(2026, time.January, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 */2 * 1", time.Date(2026, time.January, 19, 0, 0, 0, 0, time.UTC)},
+		{"0 0 */2 * *", time.Date(2026, time.January, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 10 * */7", time.Date(2026, time.May, 10, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{"@hourly", time.Date(2026, time.January, 14, 11, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 90s", from.Add(90 * time.Second)},
+		{"0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tc := range cases {
+		s, err := Parse(tc.Expr)
+		if err != nil {
+			t.Fatalf("%s: err: %s", tc.Expr, err)
+		}
+
+		if next := s.Next(from); !next.Equal(tc.Next) {
+			t.Fatalf("%s: expected %v, got %v", tc.Expr, tc.Next, next)
+		}
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	cases := []struct {
+		Expr string
+		Err  string
+	}{
+		{"* * * *", "expected 5 fields, got 4"},
+		{"60 * * * *", "minute: value 60 out of range [0, 59]"},
+		{"* * 0 * *", "day of month: value 0 out of range [1, 31]"},
+		{"*/0 * * * *", "invalid step '0'"},
+		{"5-1 * * * *", "invalid range '5-1'"},
+		{"a * * * *", "invalid value 'a'"},
+		{"@every", "expected 5 fields, got 1"},
+		{"@every -1m", "interval should be positive"},
+		{"@every soon", "invalid duration"},
+	}
+
+	for _, tc := range cases {
+		_, err := Parse(tc.Expr)
+		if err == nil {
+			t.Fatalf("%s: expected error, got nothing", tc.Expr)
+		}
+
+		if !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("%s: expected error containing %q, got %q", tc.Expr, tc.Err, err)
+		}
+	}
+}
@@ -17,12 +17,18 @@
 package driver
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 
 	"github.com/Sirupsen/logrus"
 )
 
+// DRY_RUN is the config property that is set to "true" when a driver
+// is initialized only to plan the executions. A driver initialized so
+// must not contact its service or change any state.
+const DRY_RUN = "driver.dryrun"
+
 // InitFunc is the initialize function for each Mtest Driver.
 // Each driver must implement this function and register itself
 // through Register().
@@ -34,6 +40,55 @@ type Executor interface {
 	Exec(req Request) (*Response, error)
 }
 
+// ContextExecutor interface is a contract that defines an execution
+// which can be cancelled or timed out via the provided context.
+//
+// Executors that implement this contract are expected to abort their
+// in-flight calls & waits as soon as the context is done.
+type ContextExecutor interface {
+	Executor
+	ExecContext(ctx context.Context, req Request) (*Response, error)
+}
+
+// Planner interface is a contract of an executor that can plan an
+// execution without executing it.
+//
+// A plan is the request as it would be executed i.e. after the driver's
+// defaults have been applied & the request has been validated.
+type Planner interface {
+	Executor
+	Plan(req Request) (Request, error)
+}
+
+// ExecContext executes the request against the executor with the
+// provided context.
+//
+// NOTE: An executor that is not a ContextExecutor is executed in a
+// separate goroutine, which is abandoned when the context is done.
+func ExecContext(ctx context.Context, e Executor, req Request) (*Response, error) {
+	if ce, ok := e.(ContextExecutor); ok {
+		return ce.ExecContext(ctx, req)
+	}
+
+	type result struct {
+		resp *Response
+		err  error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		resp, err := e.Exec(req)
+		done <- result{resp, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.resp, r.err
+	}
+}
+
 // MtestDriver interface is a contract that needs to be
 // implemented by various Mtest Driver implementors.
 //
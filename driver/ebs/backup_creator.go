@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/openebs/mtest/driver"
@@ -31,6 +32,10 @@ func BackupCreatorInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (b *BackupCreator) Exec(req driver.Request) (*driver.Response, error) {
+	return b.ExecContext(context.Background(), req)
+}
+
+func (b *BackupCreator) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 	_, snapExists := req.Options[OPT_SNAPSHOT_ID]
 	if !snapExists {
 		return nil, fmt.Errorf("Snapshot ID not provided")
@@ -49,7 +54,7 @@ func (b *BackupCreator) Exec(req driver.Request) (*driver.Response, error) {
 		return nil, err
 	}
 
-	if err := b.d.client.WaitForSnapshotComplete(snapshot.EBSID); err != nil {
+	if err := b.d.client.WaitForSnapshotComplete(ctx, snapshot.EBSID); err != nil {
 		return nil, err
 	}
 
@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"time"
@@ -34,6 +35,10 @@ func BackupReaderInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (b *BackupReader) Exec(req driver.Request) (*driver.Response, error) {
+	return b.ExecContext(context.Background(), req)
+}
+
+func (b *BackupReader) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 
 	_, exists := req.Options[OPT_BACKUP_URL]
 	if !exists {
@@ -47,7 +52,7 @@ func (b *BackupReader) Exec(req driver.Request) (*driver.Response, error) {
 		return nil, err
 	}
 
-	ebsSnapshot, err := b.d.client.GetSnapshotWithRegion(ebsSnapshotID, region)
+	ebsSnapshot, err := b.d.client.GetSnapshotWithRegion(ctx, ebsSnapshotID, region)
 	if err != nil {
 		return nil, err
 	}
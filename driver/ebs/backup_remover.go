@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/openebs/mtest/driver"
@@ -31,6 +32,10 @@ func BackupRemoverInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (b *BackupRemover) Exec(req driver.Request) (*driver.Response, error) {
+	return b.ExecContext(context.Background(), req)
+}
+
+func (b *BackupRemover) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 
 	_, exists := req.Options[OPT_BACKUP_URL]
 	if !exists {
@@ -44,7 +49,7 @@ func (b *BackupRemover) Exec(req driver.Request) (*driver.Response, error) {
 		return nil, err
 	}
 
-	err = b.d.client.DeleteSnapshotWithRegion(ebsSnapshotID, region)
+	err = b.d.client.DeleteSnapshotWithRegion(ctx, ebsSnapshotID, region)
 	if err != nil {
 		return nil, err
 	}
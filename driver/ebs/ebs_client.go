@@ -1,11 +1,13 @@
 package ebs
 
 import (
+	"context"
 	//"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -14,6 +16,7 @@ import (
 	awsreq "github.com/aws/aws-sdk-go/aws/request"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/openebs/mtest/driver"
 )
 
 const (
@@ -25,6 +28,9 @@ type ebsClient struct {
 	metadataClient *ec2metadata.EC2Metadata
 	ec2Client      *ec2.EC2
 
+	// faults, if set, injects faults into the ec2 requests
+	faults *faultInjector
+
 	InstanceID       string
 	Region           string
 	AvailabilityZone string
@@ -37,6 +43,10 @@ type CreateEBSVolumeRequest struct {
 	VolumeType string
 	Tags       map[string]string
 	KmsKeyID   string
+
+	// Encrypted encrypts the volume with the account's default KMS
+	// key if KmsKeyID is not set
+	Encrypted bool
 }
 
 type CreateSnapshotRequest struct {
@@ -45,8 +55,37 @@ type CreateSnapshotRequest struct {
 	Tags        map[string]string
 }
 
-func sleepBeforeRetry() {
-	time.Sleep(RETRY_INTERVAL * time.Second)
+// sleepBeforeRetry waits for the retry interval. It returns early with
+// the context's error if the context is done in the meantime.
+func sleepBeforeRetry(ctx context.Context) error {
+	t := time.NewTimer(RETRY_INTERVAL * time.Second)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// send sends the aws request after binding it to the context. This
+// aborts an in-flight request when the context is done.
+func send(ctx context.Context, req *awsreq.Request) error {
+	req.Handlers.Send.PushFront(func(r *awsreq.Request) {
+		r.HTTPRequest = r.HTTPRequest.WithContext(ctx)
+	})
+
+	if err := req.Send(); err != nil {
+		// Report the context's error as it is more meaningful
+		// than the error of the aborted request
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+
+	return nil
 }
 
 const awsErrMsgTpl = `CONTEXT: '%s', ERR_CODE: '%s', ERR_MSG: '%s', ERR_ORIG: '%s'`
@@ -61,7 +100,7 @@ func parseAwsError(err error) error {
 		if reqErr, ok := err.(awserr.RequestFailure); ok {
 			message += fmt.Sprintf(awsReqFailedErrMsgTPl, reqErr.StatusCode(), reqErr.RequestID())
 		}
-		return fmt.Errorf(message)
+		return fmt.Errorf("%s", message)
 	}
 	return err
 }
@@ -152,17 +191,42 @@ func NewEBSClient() (*ebsClient, error) {
 	//config := aws.NewConfig().WithRegion(s.Region)
 	//s.ec2Client = ec2.New(session.New(), config)
 	s.ec2Client = ec2.New(oebsSess)
+	attachMetrics(&s.ec2Client.Handlers)
 
 	return s, nil
 }
 
+// injectFaults injects the faults into the requests of the ec2 clients
+func (s *ebsClient) injectFaults(faults []*driver.Fault) {
+	s.faults = newFaultInjector(faults)
+	s.faults.attach(&s.ec2Client.Handlers)
+
+	for _, f := range faults {
+		log.Infof("Injecting fault %s", f)
+	}
+}
+
+// regionClient provides the ec2 client of the region
+func (s *ebsClient) regionClient(region string) *ec2.EC2 {
+	if region == s.Region {
+		return s.ec2Client
+	}
+
+	ec2Client := ec2.New(session.New(), aws.NewConfig().WithRegion(region))
+	attachMetrics(&ec2Client.Handlers)
+	s.faults.attach(&ec2Client.Handlers)
+	return ec2Client
+}
+
 // This involves a actual http client request to AWS / MayaServer
 //func (s *ebsClient) isEC2Instance() bool {
 //	return s.metadataClient.Available()
 //}
 
-func (s *ebsClient) waitForVolumeTransition(volumeID, start, end string) error {
-	volume, err := s.GetVolume(volumeID)
+// waitForVolumeTransition polls the volume till its state moves
+// away from start. The wait is aborted when the context is done.
+func (s *ebsClient) waitForVolumeTransition(ctx context.Context, volumeID, start, end string) error {
+	volume, err := s.GetVolume(ctx, volumeID)
 	if err != nil {
 		return err
 	}
@@ -170,28 +234,30 @@ func (s *ebsClient) waitForVolumeTransition(volumeID, start, end string) error {
 	for *volume.State == start {
 		log.Debugf("Waiting for volume %v state transiting from %v to %v",
 			volumeID, start, end)
-		volume, err = s.GetVolume(volumeID)
+		volume, err = s.GetVolume(ctx, volumeID)
 		if err != nil {
 			return err
 		}
 	}
 	if *volume.State != end {
-		return fmt.Errorf("Cannot finish volume %v state transition, ",
+		return fmt.Errorf("Cannot finish volume %v state transition, "+
 			"from %v to %v, though final state %v",
 			volumeID, start, end, *volume.State)
 	}
 	return nil
 }
 
-func (s *ebsClient) waitForVolumeAttaching(volumeID string) error {
+// waitForVolumeAttaching polls the volume till it is no more in
+// attaching state. The wait is aborted when the context is done.
+func (s *ebsClient) waitForVolumeAttaching(ctx context.Context, volumeID string) error {
 	var attachment *ec2.VolumeAttachment
-	volume, err := s.GetVolume(volumeID)
+	volume, err := s.GetVolume(ctx, volumeID)
 	if err != nil {
 		return err
 	}
 	for len(volume.Attachments) == 0 {
 		log.Debugf("Retry to get attachment of volume")
-		volume, err = s.GetVolume(volumeID)
+		volume, err = s.GetVolume(ctx, volumeID)
 		if err != nil {
 			return err
 		}
@@ -200,14 +266,14 @@ func (s *ebsClient) waitForVolumeAttaching(volumeID string) error {
 
 	for *attachment.State == ec2.VolumeAttachmentStateAttaching {
 		log.Debugf("Waiting for volume %v attaching", volumeID)
-		volume, err := s.GetVolume(volumeID)
+		volume, err := s.GetVolume(ctx, volumeID)
 		if err != nil {
 			return err
 		}
 		if len(volume.Attachments) != 0 {
 			attachment = volume.Attachments[0]
 		} else {
-			return fmt.Errorf("Attaching failed for ", volumeID)
+			return fmt.Errorf("Attaching failed for %v", volumeID)
 		}
 	}
 	if *attachment.State != ec2.VolumeAttachmentStateAttached {
@@ -216,7 +282,7 @@ func (s *ebsClient) waitForVolumeAttaching(volumeID string) error {
 	return nil
 }
 
-func (s *ebsClient) CreateVolume(request *CreateEBSVolumeRequest) (string, error) {
+func (s *ebsClient) CreateVolume(ctx context.Context, request *CreateEBSVolumeRequest) (string, error) {
 	if request == nil {
 		return "", fmt.Errorf("Invalid CreateEBSVolumeRequest")
 	}
@@ -242,6 +308,8 @@ func (s *ebsClient) CreateVolume(request *CreateEBSVolumeRequest) (string, error
 	} else if kmsKeyID != "" {
 		params.KmsKeyId = aws.String(kmsKeyID)
 		params.Encrypted = aws.Bool(true)
+	} else if request.Encrypted {
+		params.Encrypted = aws.Bool(true)
 	}
 
 	if volumeType != "" {
@@ -260,23 +328,28 @@ func (s *ebsClient) CreateVolume(request *CreateEBSVolumeRequest) (string, error
 		}
 	}
 
-	ec2Volume, err := s.ec2Client.CreateVolume(params)
-	if err != nil {
+	req, ec2Volume := s.ec2Client.CreateVolumeRequest(params)
+	if err := send(ctx, req); err != nil {
 		return "", parseAwsError(err)
 	}
 
 	volumeID := *ec2Volume.VolumeId
-	if err = s.waitForVolumeTransition(volumeID, ec2.VolumeStateCreating, ec2.VolumeStateAvailable); err != nil {
+	if err := s.waitForVolumeTransition(ctx, volumeID, ec2.VolumeStateCreating, ec2.VolumeStateAvailable); err != nil {
 		log.Debug("Failed to create volume: ", err)
-		err = s.DeleteVolume(volumeID)
-		if err != nil {
-			log.Errorf("Failed deleting volume: %v", parseAwsError(err))
+
+		// The volume is cleaned up even if the context is done
+		if dErr := s.DeleteVolume(context.Background(), volumeID); dErr != nil {
+			log.Errorf("Failed deleting volume: %v", parseAwsError(dErr))
+		}
+
+		if ctx.Err() != nil {
+			return "", ctx.Err()
 		}
 		return "", fmt.Errorf("Failed creating volume with size %v and snapshot %v",
 			size, snapshotID)
 	}
 	if request.Tags != nil {
-		if err := s.AddTags(volumeID, request.Tags); err != nil {
+		if err := s.AddTags(ctx, volumeID, request.Tags); err != nil {
 			log.Warnf("Unable to tag %v with %v, but continue", volumeID, request.Tags)
 		}
 	}
@@ -284,23 +357,25 @@ func (s *ebsClient) CreateVolume(request *CreateEBSVolumeRequest) (string, error
 	return volumeID, nil
 }
 
-func (s *ebsClient) DeleteVolume(volumeID string) error {
+func (s *ebsClient) DeleteVolume(ctx context.Context, volumeID string) error {
 	params := &ec2.DeleteVolumeInput{
 		VolumeId: aws.String(volumeID),
 	}
-	_, err := s.ec2Client.DeleteVolume(params)
-	return parseAwsError(err)
+	req, _ := s.ec2Client.DeleteVolumeRequest(params)
+	return parseAwsError(send(ctx, req))
 }
 
-func (s *ebsClient) GetVolume(volumeID string) (*ec2.Volume, error) {
-	sleepBeforeRetry()
+func (s *ebsClient) GetVolume(ctx context.Context, volumeID string) (*ec2.Volume, error) {
+	if err := sleepBeforeRetry(ctx); err != nil {
+		return nil, err
+	}
 	params := &ec2.DescribeVolumesInput{
 		VolumeIds: []*string{
 			aws.String(volumeID),
 		},
 	}
-	volumes, err := s.ec2Client.DescribeVolumes(params)
-	if err != nil {
+	req, volumes := s.ec2Client.DescribeVolumesRequest(params)
+	if err := send(ctx, req); err != nil {
 		return nil, parseAwsError(err)
 	}
 	if len(volumes.Volumes) != 1 {
@@ -354,7 +429,7 @@ func getAttachedDev(oldDevList map[string]bool, size int64) (string, error) {
 	return "/dev/" + attachedDev, nil
 }
 
-func (s *ebsClient) getInstanceDevList() (map[string]bool, error) {
+func (s *ebsClient) getInstanceDevList(ctx context.Context) (map[string]bool, error) {
 	params := &ec2.DescribeVolumesInput{
 		Filters: []*ec2.Filter{
 			{
@@ -365,8 +440,8 @@ func (s *ebsClient) getInstanceDevList() (map[string]bool, error) {
 			},
 		},
 	}
-	volumes, err := s.ec2Client.DescribeVolumes(params)
-	if err != nil {
+	req, volumes := s.ec2Client.DescribeVolumesRequest(params)
+	if err := send(ctx, req); err != nil {
 		return nil, parseAwsError(err)
 	}
 	devMap := make(map[string]bool)
@@ -379,14 +454,14 @@ func (s *ebsClient) getInstanceDevList() (map[string]bool, error) {
 	return devMap, nil
 }
 
-func (s *ebsClient) FindFreeDeviceForAttach() (string, error) {
+func (s *ebsClient) FindFreeDeviceForAttach(ctx context.Context) (string, error) {
 	availableDevs := make(map[string]bool)
 	// Recommended available devices for EBS volume from AWS website
 	chars := "fghijklmnop"
 	for i := 0; i < len(chars); i++ {
 		availableDevs["/dev/sd"+string(chars[i])] = true
 	}
-	devMap, err := s.getInstanceDevList()
+	devMap, err := s.getInstanceDevList(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -404,8 +479,39 @@ func (s *ebsClient) FindFreeDeviceForAttach() (string, error) {
 	return "", fmt.Errorf("Cannot find an available device for instance %v", s.InstanceID)
 }
 
-func (s *ebsClient) AttachVolume(volumeID string, size int64) (string, error) {
-	dev, err := s.FindFreeDeviceForAttach()
+// attachLocks serialize the attaches of the volumes to each instance
+// across the clients of the process. The clients of an instance share
+// its devices, hence a free device is found, attached & its block device
+// is identified by one client at a time. The attaches to other instances
+// are not held up. The time spent waiting on the lock is recorded as a
+// wait of the context, so that it can be reported apart from the time
+// taken by EC2.
+var attachLocks = struct {
+	sync.Mutex
+	instances map[string]*sync.Mutex
+}{instances: make(map[string]*sync.Mutex)}
+
+// attachLock provides the lock of the attaches to the instance
+func attachLock(instanceID string) *sync.Mutex {
+	attachLocks.Lock()
+	defer attachLocks.Unlock()
+
+	l, ok := attachLocks.instances[instanceID]
+	if !ok {
+		l = &sync.Mutex{}
+		attachLocks.instances[instanceID] = l
+	}
+	return l
+}
+
+func (s *ebsClient) AttachVolume(ctx context.Context, volumeID string, size int64) (string, error) {
+	l := attachLock(s.InstanceID)
+	start := time.Now()
+	l.Lock()
+	defer l.Unlock()
+	driver.RecordWait(ctx, time.Since(start))
+
+	dev, err := s.FindFreeDeviceForAttach(ctx)
 	if err != nil {
 		return "", err
 	}
@@ -422,11 +528,12 @@ func (s *ebsClient) AttachVolume(volumeID string, size int64) (string, error) {
 		return "", err
 	}
 
-	if _, err := s.ec2Client.AttachVolume(params); err != nil {
+	req, _ := s.ec2Client.AttachVolumeRequest(params)
+	if err := send(ctx, req); err != nil {
 		return "", parseAwsError(err)
 	}
 
-	if err = s.waitForVolumeAttaching(volumeID); err != nil {
+	if err = s.waitForVolumeAttaching(ctx, volumeID); err != nil {
 		return "", err
 	}
 
@@ -437,31 +544,29 @@ func (s *ebsClient) AttachVolume(volumeID string, size int64) (string, error) {
 	return result, nil
 }
 
-func (s *ebsClient) DetachVolume(volumeID string) error {
+func (s *ebsClient) DetachVolume(ctx context.Context, volumeID string) error {
 	params := &ec2.DetachVolumeInput{
 		VolumeId:   aws.String(volumeID),
 		InstanceId: aws.String(s.InstanceID),
 	}
 
-	if _, err := s.ec2Client.DetachVolume(params); err != nil {
+	req, _ := s.ec2Client.DetachVolumeRequest(params)
+	if err := send(ctx, req); err != nil {
 		return parseAwsError(err)
 	}
 
-	return s.waitForVolumeTransition(volumeID, ec2.VolumeStateInUse, ec2.VolumeStateAvailable)
+	return s.waitForVolumeTransition(ctx, volumeID, ec2.VolumeStateInUse, ec2.VolumeStateAvailable)
 }
 
-func (s *ebsClient) GetSnapshotWithRegion(snapshotID, region string) (*ec2.Snapshot, error) {
+func (s *ebsClient) GetSnapshotWithRegion(ctx context.Context, snapshotID, region string) (*ec2.Snapshot, error) {
 	params := &ec2.DescribeSnapshotsInput{
 		SnapshotIds: []*string{
 			aws.String(snapshotID),
 		},
 	}
-	ec2Client := s.ec2Client
-	if region != s.Region {
-		ec2Client = ec2.New(session.New(), aws.NewConfig().WithRegion(region))
-	}
-	snapshots, err := ec2Client.DescribeSnapshots(params)
-	if err != nil {
+	ec2Client := s.regionClient(region)
+	req, snapshots := ec2Client.DescribeSnapshotsRequest(params)
+	if err := send(ctx, req); err != nil {
 		return nil, parseAwsError(err)
 	}
 	if len(snapshots.Snapshots) != 1 {
@@ -470,19 +575,23 @@ func (s *ebsClient) GetSnapshotWithRegion(snapshotID, region string) (*ec2.Snaps
 	return snapshots.Snapshots[0], nil
 }
 
-func (s *ebsClient) GetSnapshot(snapshotID string) (*ec2.Snapshot, error) {
-	sleepBeforeRetry()
-	return s.GetSnapshotWithRegion(snapshotID, s.Region)
+func (s *ebsClient) GetSnapshot(ctx context.Context, snapshotID string) (*ec2.Snapshot, error) {
+	if err := sleepBeforeRetry(ctx); err != nil {
+		return nil, err
+	}
+	return s.GetSnapshotWithRegion(ctx, snapshotID, s.Region)
 }
 
-func (s *ebsClient) WaitForSnapshotComplete(snapshotID string) error {
-	snapshot, err := s.GetSnapshot(snapshotID)
+// WaitForSnapshotComplete polls the snapshot till it is no more
+// pending. The wait is aborted when the context is done.
+func (s *ebsClient) WaitForSnapshotComplete(ctx context.Context, snapshotID string) error {
+	snapshot, err := s.GetSnapshot(ctx, snapshotID)
 	if err != nil {
 		return err
 	}
 	for *snapshot.State == ec2.SnapshotStatePending {
 		log.Debugf("Snapshot %v process %v", *snapshot.SnapshotId, *snapshot.Progress)
-		snapshot, err = s.GetSnapshot(snapshotID)
+		snapshot, err = s.GetSnapshot(ctx, snapshotID)
 		if err != nil {
 			return err
 		}
@@ -490,55 +599,52 @@ func (s *ebsClient) WaitForSnapshotComplete(snapshotID string) error {
 	return nil
 }
 
-func (s *ebsClient) CreateSnapshot(request *CreateSnapshotRequest) (string, error) {
+func (s *ebsClient) CreateSnapshot(ctx context.Context, request *CreateSnapshotRequest) (string, error) {
 	params := &ec2.CreateSnapshotInput{
 		VolumeId:    aws.String(request.VolumeID),
 		Description: aws.String(request.Description),
 	}
-	resp, err := s.ec2Client.CreateSnapshot(params)
-	if err != nil {
+	req, resp := s.ec2Client.CreateSnapshotRequest(params)
+	if err := send(ctx, req); err != nil {
 		return "", parseAwsError(err)
 	}
 	if request.Tags != nil {
-		if err := s.AddTags(*resp.SnapshotId, request.Tags); err != nil {
+		if err := s.AddTags(ctx, *resp.SnapshotId, request.Tags); err != nil {
 			log.Warnf("Unable to tag %v with %v, but continue", *resp.SnapshotId, request.Tags)
 		}
 	}
 	return *resp.SnapshotId, nil
 }
 
-func (s *ebsClient) DeleteSnapshotWithRegion(snapshotID, region string) error {
+func (s *ebsClient) DeleteSnapshotWithRegion(ctx context.Context, snapshotID, region string) error {
 	params := &ec2.DeleteSnapshotInput{
 		SnapshotId: aws.String(snapshotID),
 	}
-	ec2Client := s.ec2Client
-	if region != s.Region {
-		ec2Client = ec2.New(session.New(), aws.NewConfig().WithRegion(region))
-	}
-	_, err := ec2Client.DeleteSnapshot(params)
-	return parseAwsError(err)
+	ec2Client := s.regionClient(region)
+	req, _ := ec2Client.DeleteSnapshotRequest(params)
+	return parseAwsError(send(ctx, req))
 }
 
-func (s *ebsClient) DeleteSnapshot(snapshotID string) error {
-	return s.DeleteSnapshotWithRegion(snapshotID, s.Region)
+func (s *ebsClient) DeleteSnapshot(ctx context.Context, snapshotID string) error {
+	return s.DeleteSnapshotWithRegion(ctx, snapshotID, s.Region)
 }
 
-func (s *ebsClient) CopySnapshot(snapshotID, srcRegion string) (string, error) {
+func (s *ebsClient) CopySnapshot(ctx context.Context, snapshotID, srcRegion string) (string, error) {
 	// Copy to current region
 	params := &ec2.CopySnapshotInput{
 		SourceRegion:     aws.String(srcRegion),
 		SourceSnapshotId: aws.String(snapshotID),
 	}
 
-	resp, err := s.ec2Client.CopySnapshot(params)
-	if err != nil {
+	req, resp := s.ec2Client.CopySnapshotRequest(params)
+	if err := send(ctx, req); err != nil {
 		return "", parseAwsError(err)
 	}
 
 	return *resp.SnapshotId, nil
 }
 
-func (s *ebsClient) AddTags(resourceID string, tags map[string]string) error {
+func (s *ebsClient) AddTags(ctx context.Context, resourceID string, tags map[string]string) error {
 	if tags == nil {
 		return nil
 	}
@@ -558,14 +664,14 @@ func (s *ebsClient) AddTags(resourceID string, tags map[string]string) error {
 	}
 	params.Tags = ec2Tags
 
-	_, err := s.ec2Client.CreateTags(params)
-	if err != nil {
+	req, _ := s.ec2Client.CreateTagsRequest(params)
+	if err := send(ctx, req); err != nil {
 		return parseAwsError(err)
 	}
 	return nil
 }
 
-func (s *ebsClient) GetTags(resourceID string) (map[string]string, error) {
+func (s *ebsClient) GetTags(ctx context.Context, resourceID string) (map[string]string, error) {
 	params := &ec2.DescribeTagsInput{
 		Filters: []*ec2.Filter{
 			{
@@ -577,8 +683,8 @@ func (s *ebsClient) GetTags(resourceID string) (map[string]string, error) {
 		},
 	}
 
-	resp, err := s.ec2Client.DescribeTags(params)
-	if err != nil {
+	req, resp := s.ec2Client.DescribeTagsRequest(params)
+	if err := send(ctx, req); err != nil {
 		return nil, parseAwsError(err)
 	}
 
@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"fmt"
 	"net/url"
 	"regexp"
@@ -31,6 +32,10 @@ type EBSDriver struct {
 	mutex  *sync.RWMutex
 	client *ebsClient
 	Device
+
+	// dryRun is true if the driver was initialized only to plan the
+	// executions of its executors
+	dryRun bool
 }
 
 func init() {
@@ -52,12 +57,25 @@ func makeExecutors() {
 }
 
 // Initialize the EBSDriver as a MtestDriver
+//
+// NOTE: A driver that is initialized for a dry run neither contacts the
+// endpoint nor persists its device. Its executors can only be planned.
 func Init(root string, config map[string]string) (driver.MtestDriver, error) {
-	ebsClient, err := NewEBSClient()
+	dryRun := config[driver.DRY_RUN] == "true"
+
+	faults, err := driver.DecodeFaults(config)
 	if err != nil {
 		return nil, err
 	}
 
+	ebsClient := &ebsClient{}
+	if !dryRun {
+		if ebsClient, err = NewEBSClient(); err != nil {
+			return nil, err
+		}
+		ebsClient.injectFaults(faults)
+	}
+
 	dev := &Device{
 		Root: root,
 	}
@@ -72,8 +90,10 @@ func Init(root string, config map[string]string) (driver.MtestDriver, error) {
 			return nil, err
 		}
 	} else {
-		if err := util.MkdirIfNotExists(root); err != nil {
-			return nil, err
+		if !dryRun {
+			if err := util.MkdirIfNotExists(root); err != nil {
+				return nil, err
+			}
 		}
 
 		if config[EBS_DEFAULT_VOLUME_SIZE] == "" {
@@ -103,8 +123,10 @@ func Init(root string, config map[string]string) (driver.MtestDriver, error) {
 			DefaultKmsKeyID:   kmsKeyId,
 		}
 
-		if err := util.ObjectSave(dev); err != nil {
-			return nil, err
+		if !dryRun {
+			if err := util.ObjectSave(dev); err != nil {
+				return nil, err
+			}
 		}
 	}
 
@@ -112,6 +134,11 @@ func Init(root string, config map[string]string) (driver.MtestDriver, error) {
 		mutex:  &sync.RWMutex{},
 		client: ebsClient,
 		Device: *dev,
+		dryRun: dryRun,
+	}
+
+	if dryRun {
+		return d, nil
 	}
 
 	if err := d.remountVolumes(); err != nil {
@@ -161,6 +188,10 @@ func (d *EBSDriver) Executors(hints ...string) (map[string]driver.Executor, erro
 			return nil, err
 		}
 
+		if d.dryRun {
+			executor = &dryRunExecutor{hint: hint, exec: executor}
+		}
+
 		execs[hint] = executor
 	}
 
@@ -171,6 +202,27 @@ func (d *EBSDriver) Executors(hints ...string) (map[string]driver.Executor, erro
 	return execs, nil
 }
 
+// dryRunExecutor is an executor of a driver that was initialized for a
+// dry run. It can only be planned.
+type dryRunExecutor struct {
+	hint string
+	exec driver.Executor
+}
+
+func (e *dryRunExecutor) Exec(req driver.Request) (*driver.Response, error) {
+	return nil, fmt.Errorf("Executor %s can not be executed in a dry run", e.hint)
+}
+
+// Plan plans the request via the executor, if it is a planner, else
+// the request is executed as is
+func (e *dryRunExecutor) Plan(req driver.Request) (driver.Request, error) {
+	if p, ok := e.exec.(driver.Planner); ok {
+		return p.Plan(req)
+	}
+
+	return req, nil
+}
+
 // Get a volume with device's root as the volume's path
 func (d *EBSDriver) blankVolume(name string) *Volume {
 	return &Volume{
@@ -219,6 +271,9 @@ func (d *EBSDriver) Info() (map[string]string, error) {
 	infos["InstanceID"] = d.client.InstanceID
 	infos["Region"] = d.client.Region
 	infos["AvailiablityZone"] = d.client.AvailabilityZone
+	if d.client.faults != nil {
+		infos["Faults"] = d.client.faults.names()
+	}
 	return infos, nil
 }
 
@@ -231,6 +286,40 @@ func (d *EBSDriver) getSize(opts map[string]string, defaultVolumeSize int64) (in
 	return util.ParseSize(size)
 }
 
+// getEncryption provides the KMS key & the encryption of a new volume.
+// The driver's default KMS key is used unless the encryption is turned
+// off.
+func (d *EBSDriver) getEncryption(opts map[string]string) (string, bool, error) {
+	if opts[OPT_ENCRYPTED] != "" {
+		encrypted, err := strconv.ParseBool(opts[OPT_ENCRYPTED])
+		if err != nil {
+			return "", false, fmt.Errorf("Invalid %s %v", OPT_ENCRYPTED, opts[OPT_ENCRYPTED])
+		}
+
+		if !encrypted {
+			return "", false, nil
+		}
+		return d.DefaultKmsKeyID, true, nil
+	}
+
+	return d.DefaultKmsKeyID, d.DefaultKmsKeyID != "", nil
+}
+
+// getFilesystem provides the filesystem that a new volume is formatted
+// with
+func (d *EBSDriver) getFilesystem(opts map[string]string) (string, error) {
+	fs := opts[OPT_FILESYSTEM]
+	if fs == "" {
+		fs = DEFAULT_FILESYSTEM
+	}
+
+	if err := checkFilesystem(fs); err != nil {
+		return "", err
+	}
+
+	return fs, nil
+}
+
 func (d *EBSDriver) getTypeAndIOPS(opts map[string]string) (string, int64, error) {
 	var (
 		iops int64
@@ -335,7 +424,7 @@ func (d *EBSDriver) getSnapshotAndVolume(snapshotID, volumeID string) (*Snapshot
 	return &snap, volume, nil
 }
 
-func (d *EBSDriver) getSnapshotInfo(id, volumeID string) (map[string]string, error) {
+func (d *EBSDriver) getSnapshotInfo(ctx context.Context, id, volumeID string) (map[string]string, error) {
 	// Snapshot on EBS can be removed by DeleteBackup
 	removed := false
 
@@ -344,7 +433,7 @@ func (d *EBSDriver) getSnapshotInfo(id, volumeID string) (map[string]string, err
 		return nil, err
 	}
 
-	ebsSnapshot, err := d.client.GetSnapshot(snapshot.EBSID)
+	ebsSnapshot, err := d.client.GetSnapshot(ctx, snapshot.EBSID)
 	if err != nil {
 		removed = true
 	}
@@ -41,6 +41,9 @@ const (
 	// Default volume type used by ebs driver
 	DEFAULT_VOLUME_TYPE = "gp2"
 
+	// Default filesystem of the volumes created by ebs driver
+	DEFAULT_FILESYSTEM = "ext4"
+
 	// The mount directory used by ebs driver
 	MOUNTS_DIR = "mounts"
 
@@ -74,6 +77,17 @@ const (
 	// Volume IOPS parameter
 	OPT_VOLUME_IOPS = "VolumeIOPS"
 
+	// Volume encryption parameter. A volume is encrypted by default
+	// if the driver has a default KMS key. If set to false, the volume
+	// is not encrypted even if the driver has a default KMS key.
+	OPT_ENCRYPTED = "Encrypted"
+
+	// KMS key of an encrypted volume as provided by the plan of the
+	// volume. This is not a parameter of a request, as a volume is
+	// encrypted with the driver's default KMS key, if any, else with
+	// the account's default KMS key.
+	OPT_KMS_KEY_ID = "KmsKeyID"
+
 	// Volume Created Time parameter
 	OPT_VOLUME_CREATED_TIME = "VolumeCreatedAt"
 
@@ -187,3 +201,17 @@ func checkVolumeType(volumeType string) error {
 	}
 	return nil
 }
+
+// Verify the filesystem to be one that a new volume can be formatted with
+func checkFilesystem(fs string) error {
+	validFilesystem := map[string]bool{
+		"ext2": true,
+		"ext3": true,
+		"ext4": true,
+		"xfs":  true,
+	}
+	if !validFilesystem[fs] {
+		return fmt.Errorf("Invalid filesystem %v", fs)
+	}
+	return nil
+}
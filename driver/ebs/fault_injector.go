@@ -0,0 +1,210 @@
+package ebs
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/corehandlers"
+	awsreq "github.com/aws/aws-sdk-go/aws/request"
+	"github.com/openebs/mtest/driver"
+)
+
+// Error codes of the injected failures, as returned by EC2
+const (
+	THROTTLE_ERR_CODE     = "RequestLimitExceeded"
+	SERVER_ERR_CODE       = "Unavailable"
+	SEND_REQUEST_ERR_CODE = "RequestError"
+	CANCELED_ERR_CODE     = "RequestCanceled"
+)
+
+// errDropped is the cause of a request that was dropped by a fault
+var errDropped = errors.New("connection dropped by an injected fault")
+
+const ec2ErrorResponseTpl = `<?xml version="1.0" encoding="UTF-8"?>
+<Response><Errors><Error><Code>%s</Code><Message>%s</Message></Error></Errors><RequestID>mtest-fault-%s</RequestID></Response>`
+
+// faultInjector injects faults into the requests of the ec2 clients.
+//
+// The faults are injected by replacing the send handler of a client's
+// handler chain. Hence, the rest of the chain i.e. the error decoding,
+// retries & response decoding of aws-sdk-go run against the faulted
+// requests just as they would against a broken network or service.
+type faultInjector struct {
+	faults []*driver.Fault
+
+	m    sync.Mutex
+	rand *rand.Rand
+}
+
+// newFaultInjector returns a fault injector for the faults. Nil is
+// returned if there are no faults.
+func newFaultInjector(faults []*driver.Fault) *faultInjector {
+	if len(faults) == 0 {
+		return nil
+	}
+
+	return &faultInjector{
+		faults: faults,
+		rand:   rand.New(rand.NewSource(time.Now().UnixNano())),
+	}
+}
+
+// names provides the names of the faults as a comma separated list
+func (fi *faultInjector) names() string {
+	names := make([]string, 0, len(fi.faults))
+	for _, f := range fi.faults {
+		names = append(names, f.Name)
+	}
+
+	return strings.Join(names, ",")
+}
+
+// pick provides the fault to be injected into a request of the
+// operation. Nil is returned if the request should not be faulted.
+func (fi *faultInjector) pick(operation string) *driver.Fault {
+	fi.m.Lock()
+	defer fi.m.Unlock()
+
+	for _, f := range fi.faults {
+		if f.Matches(operation) && fi.rand.Float64() < f.Probability {
+			return f
+		}
+	}
+
+	return nil
+}
+
+// attach replaces the send handler of the handler chain with a send
+// handler that injects the faults. Errors are logged on retries so
+// that the injected faults show up in the logs.
+func (fi *faultInjector) attach(h *awsreq.Handlers) {
+	if fi == nil {
+		return
+	}
+
+	h.Send.Remove(corehandlers.SendHandler)
+	h.Send.PushBackNamed(fi.sendHandler(corehandlers.SendHandler))
+
+	h.Unmarshal.PushFrontNamed(validateXMLHandler)
+
+	h.Retry.PushFrontNamed(errHandler("retry-hook"))
+	h.AfterRetry.PushFrontNamed(errHandler("after-retry-hook"))
+}
+
+// sendHandler wraps the send handler with the injection of faults
+func (fi *faultInjector) sendHandler(send awsreq.NamedHandler) awsreq.NamedHandler {
+	return awsreq.NamedHandler{
+		Name: "mtest.FaultSendHandler",
+		Fn: func(r *awsreq.Request) {
+			f := fi.pick(r.Operation.Name)
+			if f == nil {
+				send.Fn(r)
+				return
+			}
+
+			log.Debugf("Injecting fault %s into %s", f.Name, r.Operation.Name)
+
+			if f.Latency > 0 {
+				if err := sleepContext(r.HTTPRequest.Context(), f.Latency); err != nil {
+					r.HTTPResponse = faultResponse(0, "")
+					r.Error = awserr.New(CANCELED_ERR_CODE, "request context canceled", err)
+					return
+				}
+			}
+
+			switch f.Error {
+			case driver.FAULT_DROP:
+				r.HTTPResponse = faultResponse(0, "")
+				r.Error = awserr.New(SEND_REQUEST_ERR_CODE, "send request failed", errDropped)
+			case driver.FAULT_THROTTLE:
+				r.HTTPResponse = faultResponse(http.StatusBadRequest,
+					fmt.Sprintf(ec2ErrorResponseTpl, THROTTLE_ERR_CODE, "Request limit exceeded.", f.Name))
+			case driver.FAULT_SERVER_ERROR:
+				r.HTTPResponse = faultResponse(http.StatusServiceUnavailable,
+					fmt.Sprintf(ec2ErrorResponseTpl, SERVER_ERR_CODE, "The server is unavailable.", f.Name))
+			case driver.FAULT_CORRUPT:
+				send.Fn(r)
+				if r.Error == nil && r.HTTPResponse.StatusCode < 300 {
+					truncateBody(r.HTTPResponse)
+				}
+			default:
+				send.Fn(r)
+			}
+		},
+	}
+}
+
+// validateXMLHandler fails the request if its response is not well
+// formed XML. aws-sdk-go decodes whatever it can of a malformed
+// response, which would hide a corrupted response from the callers.
+var validateXMLHandler = awsreq.NamedHandler{
+	Name: "mtest.ValidateXMLHandler",
+	Fn: func(r *awsreq.Request) {
+		b, err := ioutil.ReadAll(r.HTTPResponse.Body)
+		r.HTTPResponse.Body.Close()
+		r.HTTPResponse.Body = ioutil.NopCloser(bytes.NewReader(b))
+		if err == nil {
+			err = checkXML(b)
+		}
+
+		if err != nil {
+			r.Error = awserr.New("SerializationError", "failed decoding EC2 Query response", err)
+		}
+	},
+}
+
+// checkXML returns an error if the document is not well formed XML
+func checkXML(b []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	for {
+		_, err := d.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// truncateBody drops the latter half of the response body
+func truncateBody(resp *http.Response) {
+	b, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = ioutil.NopCloser(bytes.NewReader(b[:len(b)/2]))
+}
+
+// faultResponse provides a http response of the status code & body
+func faultResponse(code int, body string) *http.Response {
+	return &http.Response{
+		StatusCode: code,
+		Status:     http.StatusText(code),
+		Header:     http.Header{},
+		Body:       ioutil.NopCloser(bytes.NewReader([]byte(body))),
+	}
+}
+
+// sleepContext waits for the duration. It returns early with the
+// context's error if the context is done in the meantime.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
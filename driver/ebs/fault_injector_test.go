@@ -0,0 +1,165 @@
+package ebs
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/openebs/mtest/driver"
+)
+
+const describeVolumesResponse = `<?xml version="1.0" encoding="UTF-8"?>
+<DescribeVolumesResponse xmlns="http://ec2.amazonaws.com/doc/2016-11-15/">
+  <requestId>req-1</requestId>
+  <volumeSet>
+    <item><volumeId>vol-1</volumeId><status>available</status></item>
+  </volumeSet>
+</DescribeVolumesResponse>`
+
+// newTestClient returns an ec2 client of a fake EC2 server that serves
+// the requests via the handler
+func newTestClient(t *testing.T, handler http.HandlerFunc) *ec2.EC2 {
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+
+	return ec2.New(session.New(&aws.Config{
+		Region:      aws.String("mock-region"),
+		Endpoint:    aws.String(srv.URL),
+		Credentials: credentials.NewStaticCredentials("id", "secret", ""),
+		MaxRetries:  aws.Int(0),
+		Logger:      aws.LoggerFunc(func(...interface{}) {}),
+	}))
+}
+
+// newFaultedClient returns an ec2 client of a fake EC2 server into
+// whose requests the fault is injected. The no of requests that
+// reached the server is counted.
+func newFaultedClient(t *testing.T, f *driver.Fault) (*ec2.EC2, *int32) {
+	var hits int32
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(describeVolumesResponse))
+	})
+
+	newFaultInjector([]*driver.Fault{f}).attach(&client.Handlers)
+	return client, &hits
+}
+
+func describeVolumes(client *ec2.EC2) (*ec2.DescribeVolumesOutput, error) {
+	return client.DescribeVolumes(&ec2.DescribeVolumesInput{
+		VolumeIds: []*string{aws.String("vol-1")},
+	})
+}
+
+func TestFaultInjector_Errors(t *testing.T) {
+	cases := []struct {
+		Error  string
+		Code   string
+		Status int
+		Sent   bool
+	}{
+		{driver.FAULT_THROTTLE, THROTTLE_ERR_CODE, http.StatusBadRequest, false},
+		{driver.FAULT_SERVER_ERROR, SERVER_ERR_CODE, http.StatusServiceUnavailable, false},
+		{driver.FAULT_DROP, SEND_REQUEST_ERR_CODE, 0, false},
+		{driver.FAULT_CORRUPT, "SerializationError", 0, true},
+	}
+
+	for _, tc := range cases {
+		client, hits := newFaultedClient(t, &driver.Fault{
+			Name:        tc.Error,
+			Operations:  []string{"DescribeVolumes"},
+			Probability: 1,
+			Error:       tc.Error,
+		})
+
+		_, err := describeVolumes(client)
+		aerr, ok := err.(awserr.Error)
+		if !ok {
+			t.Fatalf("%s: expected aws error, got %v", tc.Error, err)
+		}
+
+		if aerr.Code() != tc.Code {
+			t.Fatalf("%s: expected error code %s, got %s", tc.Error, tc.Code, aerr.Code())
+		}
+
+		if rerr, ok := err.(awserr.RequestFailure); tc.Status != 0 && (!ok || rerr.StatusCode() != tc.Status) {
+			t.Fatalf("%s: expected status %d, got %v", tc.Error, tc.Status, err)
+		}
+
+		if sent := atomic.LoadInt32(hits) > 0; sent != tc.Sent {
+			t.Fatalf("%s: expected request sent to be %v", tc.Error, tc.Sent)
+		}
+	}
+}
+
+func TestFaultInjector_Latency(t *testing.T) {
+	client, hits := newFaultedClient(t, &driver.Fault{
+		Name:        "slow",
+		Probability: 1,
+		Latency:     50 * time.Millisecond,
+	})
+
+	start := time.Now()
+	out, err := describeVolumes(client)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected latency of at least 50ms, took %v", elapsed)
+	}
+
+	if *hits != 1 || len(out.Volumes) != 1 || *out.Volumes[0].VolumeId != "vol-1" {
+		t.Fatalf("bad response: %v", out)
+	}
+}
+
+func TestFaultInjector_OtherOperations(t *testing.T) {
+	client, hits := newFaultedClient(t, &driver.Fault{
+		Name:        "creates",
+		Operations:  []string{"CreateVolume"},
+		Probability: 1,
+		Error:       driver.FAULT_DROP,
+	})
+
+	if _, err := describeVolumes(client); err != nil {
+		t.Fatalf("expected an operation without faults to succeed, got %s", err)
+	}
+
+	if *hits != 1 {
+		t.Fatalf("expected the request to be sent")
+	}
+}
+
+func TestFaultInjector_Probability(t *testing.T) {
+	fi := newFaultInjector([]*driver.Fault{
+		{Name: "never", Probability: 0, Error: driver.FAULT_DROP},
+		{Name: "half", Probability: 0.5, Error: driver.FAULT_THROTTLE},
+	})
+
+	picked := 0
+	for i := 0; i < 1000; i++ {
+		if f := fi.pick("CreateVolume"); f != nil {
+			if f.Name != "half" {
+				t.Fatalf("expected fault 'half', got %s", f.Name)
+			}
+			picked++
+		}
+	}
+
+	if picked < 400 || picked > 600 {
+		t.Fatalf("expected ~500 faulted requests, got %d", picked)
+	}
+
+	if !strings.Contains(fi.names(), "never,half") {
+		t.Fatalf("bad names: %s", fi.names())
+	}
+}
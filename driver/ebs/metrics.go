@@ -0,0 +1,92 @@
+package ebs
+
+import (
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	awsreq "github.com/aws/aws-sdk-go/aws/request"
+	"github.com/openebs/mtest/metrics"
+)
+
+const (
+	// Metrics of the requests to the EC2 API
+	EC2_REQUESTS_METRIC         = "mtest_ec2_requests_total"
+	EC2_REQUEST_ERRORS_METRIC   = "mtest_ec2_request_errors_total"
+	EC2_REQUEST_DURATION_METRIC = "mtest_ec2_request_duration_seconds"
+
+	// Code of an error that is not an aws error
+	UNKNOWN_ERR_CODE = "Unknown"
+)
+
+// metricsHandler returns a build handler that records the calls, errors
+// & latency of each attempt of an ec2 request into the registry.
+//
+// NOTE: The handlers of an attempt are pushed into the handlers of its
+// request, as the request is the only place to keep the start of the
+// attempt. A build handler runs once per request.
+func metricsHandler(reg *metrics.Registry) awsreq.NamedHandler {
+	calls := reg.Counter(EC2_REQUESTS_METRIC,
+		"No of requests sent to the EC2 API, including retries.", "operation")
+	errs := reg.Counter(EC2_REQUEST_ERRORS_METRIC,
+		"No of requests to the EC2 API that failed, by error code.", "operation", "code")
+	latency := reg.Histogram(EC2_REQUEST_DURATION_METRIC,
+		"Latency of the requests to the EC2 API in seconds.", nil, "operation")
+
+	return awsreq.NamedHandler{
+		Name: "mtest.MetricsHandler",
+		Fn: func(r *awsreq.Request) {
+			var start time.Time
+
+			r.Handlers.Send.PushFront(func(r *awsreq.Request) {
+				start = time.Now()
+				calls.Inc(r.Operation.Name)
+			})
+
+			// An attempt ends with its response, or with its error if
+			// it could not be sent
+			observe := func(r *awsreq.Request) {
+				if start.IsZero() {
+					return
+				}
+				latency.Observe(time.Since(start).Seconds(), r.Operation.Name)
+				start = time.Time{}
+			}
+			r.Handlers.ValidateResponse.PushFront(observe)
+
+			// An attempt that could not be sent, e.g. as its context
+			// is done, fails in the send handlers. The request is not
+			// retried, nor is its response handled, if it is aborted.
+			r.Handlers.Send.PushBack(func(r *awsreq.Request) {
+				if r.Error != nil {
+					observe(r)
+					errs.Inc(r.Operation.Name, errorCode(r.Error))
+				}
+			})
+
+			// The error of a failed response is known once the
+			// response is unmarshalled
+			r.Handlers.UnmarshalError.PushBack(func(r *awsreq.Request) {
+				errs.Inc(r.Operation.Name, errorCode(r.Error))
+			})
+			r.Handlers.Unmarshal.PushBack(func(r *awsreq.Request) {
+				if r.Error != nil {
+					errs.Inc(r.Operation.Name, errorCode(r.Error))
+				}
+			})
+		},
+	}
+}
+
+// attachMetrics records the metrics of the requests of the handlers into
+// the default registry
+func attachMetrics(h *awsreq.Handlers) {
+	h.Build.PushBackNamed(metricsHandler(metrics.Default))
+}
+
+// errorCode provides the code of the aws error
+func errorCode(err error) string {
+	if aerr, ok := err.(awserr.Error); ok {
+		return aerr.Code()
+	}
+	return UNKNOWN_ERR_CODE
+}
@@ -0,0 +1,88 @@
+package ebs
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/openebs/mtest/driver"
+	"github.com/openebs/mtest/metrics"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	client, _ := newFaultedClient(t, &driver.Fault{
+		Name:        "throttle",
+		Operations:  []string{"CreateVolume"},
+		Probability: 1,
+		Error:       driver.FAULT_THROTTLE,
+	})
+	client.Handlers.Build.PushBackNamed(metricsHandler(reg))
+
+	for i := 0; i < 2; i++ {
+		if _, err := describeVolumes(client); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	calls := reg.Counter(EC2_REQUESTS_METRIC, "", "operation")
+	errs := reg.Counter(EC2_REQUEST_ERRORS_METRIC, "", "operation", "code")
+	latency := reg.Histogram(EC2_REQUEST_DURATION_METRIC, "", nil, "operation")
+
+	if v := calls.Value("DescribeVolumes"); v != 2 {
+		t.Fatalf("expected 2 calls, got %v", v)
+	}
+	if n := latency.Count("DescribeVolumes"); n != 2 {
+		t.Fatalf("expected 2 observations, got %d", n)
+	}
+	if v := errs.Value("DescribeVolumes", THROTTLE_ERR_CODE); v != 0 {
+		t.Fatalf("expected no errors, got %v", v)
+	}
+
+	// The injected faults are counted as errors by their code
+	_, err := client.CreateVolume(&ec2.CreateVolumeInput{
+		AvailabilityZone: aws.String("mock-az"),
+	})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	if v := errs.Value("CreateVolume", THROTTLE_ERR_CODE); v != 1 {
+		t.Fatalf("expected 1 error, got %v", v)
+	}
+	if v, n := calls.Value("CreateVolume"), latency.Count("CreateVolume"); v != 1 || n != 1 {
+		t.Fatalf("expected 1 call & observation, got %v & %d", v, n)
+	}
+}
+
+func TestMetricsHandler_Cancelled(t *testing.T) {
+	reg := metrics.NewRegistry()
+
+	client, hits := newFaultedClient(t, &driver.Fault{Name: "none"})
+	client.Handlers.Build.PushBackNamed(metricsHandler(reg))
+
+	// A request whose context is done is not sent, nor retried
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	req, _ := client.DescribeVolumesRequest(&ec2.DescribeVolumesInput{})
+	if err := send(ctx, req); err != context.Canceled {
+		t.Fatalf("expected the request to be cancelled, got %v", err)
+	}
+	if *hits != 0 {
+		t.Fatalf("expected no request to reach the server, got %d", *hits)
+	}
+
+	calls := reg.Counter(EC2_REQUESTS_METRIC, "", "operation")
+	errs := reg.Counter(EC2_REQUEST_ERRORS_METRIC, "", "operation", "code")
+	latency := reg.Histogram(EC2_REQUEST_DURATION_METRIC, "", nil, "operation")
+
+	if v, n := calls.Value("DescribeVolumes"), latency.Count("DescribeVolumes"); v != 1 || n != 1 {
+		t.Fatalf("expected 1 call & observation, got %v & %d", v, n)
+	}
+	if v := errs.Value("DescribeVolumes", "RequestError"); v != 1 {
+		t.Fatalf("expected 1 error, got %v", v)
+	}
+}
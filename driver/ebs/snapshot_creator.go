@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/Sirupsen/logrus"
@@ -34,6 +35,10 @@ func SnapshotCreatorInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (s *SnapshotCreator) Exec(req driver.Request) (*driver.Response, error) {
+	return s.ExecContext(context.Background(), req)
+}
+
+func (s *SnapshotCreator) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 
 	s.d.mutex.Lock()
 	defer s.d.mutex.Unlock()
@@ -67,7 +72,7 @@ func (s *SnapshotCreator) Exec(req driver.Request) (*driver.Response, error) {
 		Description: fmt.Sprintf("Mtest volume snapshot"),
 		Tags:        tags,
 	}
-	ebsSnapshotID, err := s.d.client.CreateSnapshot(request)
+	ebsSnapshotID, err := s.d.client.CreateSnapshot(ctx, request)
 
 	if err != nil {
 		return nil, err
@@ -89,6 +94,9 @@ func (s *SnapshotCreator) Exec(req driver.Request) (*driver.Response, error) {
 	values := make(map[string]interface{})
 	values["snap"] = snapshot
 
+	// The backups of the snapshot share its EBS snapshot & hence its URL
+	values[OPT_BACKUP_URL] = encodeURL(s.d.client.Region, ebsSnapshotID)
+
 	return &driver.Response{
 		Values: values,
 	}, nil
@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"github.com/openebs/mtest/driver"
 	"github.com/openebs/mtest/util"
 )
@@ -30,6 +31,10 @@ func SnapshotListerInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (s *SnapshotLister) Exec(req driver.Request) (*driver.Response, error) {
+	return s.ExecContext(context.Background(), req)
+}
+
+func (s *SnapshotLister) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 
 	s.d.mutex.Lock()
 	defer s.d.mutex.Unlock()
@@ -63,7 +68,7 @@ func (s *SnapshotLister) Exec(req driver.Request) (*driver.Response, error) {
 		}
 
 		for snapshotID := range volume.Snapshots {
-			values[snapshotID], err = s.d.getSnapshotInfo(snapshotID, volumeID)
+			values[snapshotID], err = s.d.getSnapshotInfo(ctx, snapshotID, volumeID)
 			if err != nil {
 				return nil, err
 			}
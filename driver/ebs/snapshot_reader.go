@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"github.com/openebs/mtest/driver"
 	"github.com/openebs/mtest/util"
 )
@@ -30,6 +31,10 @@ func SnapshotReaderInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (s *SnapshotReader) Exec(req driver.Request) (*driver.Response, error) {
+	return s.ExecContext(context.Background(), req)
+}
+
+func (s *SnapshotReader) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 
 	s.d.mutex.Lock()
 	defer s.d.mutex.Unlock()
@@ -40,7 +45,7 @@ func (s *SnapshotReader) Exec(req driver.Request) (*driver.Response, error) {
 		return nil, err
 	}
 
-	info, err := s.d.getSnapshotInfo(id, volumeID)
+	info, err := s.d.getSnapshotInfo(ctx, id, volumeID)
 	if err != nil {
 		return nil, err
 	}
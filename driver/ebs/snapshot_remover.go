@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"github.com/openebs/mtest/driver"
 	"github.com/openebs/mtest/util"
 )
@@ -30,6 +31,10 @@ func SnapshotRemoverInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (s *SnapshotRemover) Exec(req driver.Request) (*driver.Response, error) {
+	return s.ExecContext(context.Background(), req)
+}
+
+func (s *SnapshotRemover) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 
 	s.d.mutex.Lock()
 	defer s.d.mutex.Unlock()
@@ -1,7 +1,9 @@
 package ebs
 
 import (
+	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/openebs/mtest/driver"
 	"github.com/openebs/mtest/util"
@@ -31,14 +33,90 @@ func VolumeCreatorInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 	}, nil
 }
 
+func (v *VolumeCreator) Exec(req driver.Request) (*driver.Response, error) {
+	return v.ExecContext(context.Background(), req)
+}
+
+// Plan provides the request with the size, type, IOPS, encryption &
+// filesystem that the volume would be created with. The defaults of the
+// driver apply to the ones that are not requested. The size of a
+// restored volume defaults to its snapshot's size, which is known only
+// when it is executed.
+func (v *VolumeCreator) Plan(req driver.Request) (driver.Request, error) {
+	planned := driver.Request{
+		Name:    req.Name,
+		Options: make(map[string]string, len(req.Options)),
+	}
+	for k, val := range req.Options {
+		planned.Options[k] = val
+	}
+	opts := planned.Options
+
+	if opts[OPT_BACKUP_URL] != "" && opts[OPT_VOLUME_ID] != "" {
+		return planned, fmt.Errorf("Cannot specify both backup and EBS volume ID")
+	}
+
+	// An existing EBS volume is used as is
+	if opts[OPT_VOLUME_ID] != "" {
+		return planned, nil
+	}
+
+	if opts[OPT_BACKUP_URL] == "" {
+		size, err := v.d.getSize(opts, v.d.DefaultVolumeSize)
+		if err != nil {
+			return planned, err
+		}
+		opts[OPT_SIZE] = strconv.FormatInt(size, 10)
+	}
+
+	volumeType, iops, err := v.d.getTypeAndIOPS(opts)
+	if err != nil {
+		return planned, err
+	}
+
+	opts[OPT_VOLUME_TYPE] = volumeType
+	if iops != 0 {
+		opts[OPT_VOLUME_IOPS] = strconv.FormatInt(iops, 10)
+	}
+
+	// A restored volume has the encryption & filesystem of its snapshot
+	if opts[OPT_BACKUP_URL] != "" {
+		return planned, nil
+	}
+
+	kmsKeyID, encrypted, err := v.d.getEncryption(opts)
+	if err != nil {
+		return planned, err
+	}
+
+	// The key that the volume is encrypted with is shown, while a key
+	// of the request is ignored as is by the execution
+	opts[OPT_ENCRYPTED] = strconv.FormatBool(encrypted)
+	delete(opts, OPT_KMS_KEY_ID)
+	if kmsKeyID != "" {
+		opts[OPT_KMS_KEY_ID] = kmsKeyID
+	}
+
+	if opts[OPT_FILESYSTEM], err = v.d.getFilesystem(opts); err != nil {
+		return planned, err
+	}
+
+	return planned, nil
+}
+
 // This is a loaded function, that caters to various forms of
 // ebs volume creation. In addition, attaching the volume to a
 // device & formatting it against a filesystem.
-func (v *VolumeCreator) Exec(req driver.Request) (*driver.Response, error) {
+func (v *VolumeCreator) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 	var (
 		err        error
 		volumeSize int64
 		format     bool
+		filesystem string
+
+		// created is true if the EBS volume is created by this
+		// request, rather than an existing one
+		created bool
 	)
 
 	v.d.mutex.Lock()
@@ -72,7 +150,7 @@ func (v *VolumeCreator) Exec(req driver.Request) (*driver.Response, error) {
 	if volumeID != "" {
 
 		// FETCH an EXISTING EBS volume
-		ebsVolume, err := v.d.client.GetVolume(volumeID)
+		ebsVolume, err := v.d.client.GetVolume(ctx, volumeID)
 
 		if err != nil {
 			return nil, err
@@ -81,7 +159,7 @@ func (v *VolumeCreator) Exec(req driver.Request) (*driver.Response, error) {
 		volumeSize = *ebsVolume.Size * GB
 		log.Debugf("Found EBS volume %v for volume %v, update tags", volumeID, id)
 
-		if err := v.d.client.AddTags(volumeID, newTags); err != nil {
+		if err := v.d.client.AddTags(ctx, volumeID, newTags); err != nil {
 			log.Debugf("Failed to update tags for volume %v, but continue", volumeID)
 		}
 
@@ -101,12 +179,12 @@ func (v *VolumeCreator) Exec(req driver.Request) (*driver.Response, error) {
 				ebsSnapshotID, region, v.d.client.Region)
 		}
 
-		if err := v.d.client.WaitForSnapshotComplete(ebsSnapshotID); err != nil {
+		if err := v.d.client.WaitForSnapshotComplete(ctx, ebsSnapshotID); err != nil {
 			return nil, err
 		}
 		log.Debugf("Snapshot %v is ready", ebsSnapshotID)
 
-		ebsSnapshot, err := v.d.client.GetSnapshot(ebsSnapshotID)
+		ebsSnapshot, err := v.d.client.GetSnapshot(ctx, ebsSnapshotID)
 		if err != nil {
 			return nil, err
 		}
@@ -133,13 +211,14 @@ func (v *VolumeCreator) Exec(req driver.Request) (*driver.Response, error) {
 			IOPS:       iops,
 			Tags:       newTags,
 		}
-		volumeID, err = v.d.client.CreateVolume(r)
+		volumeID, err = v.d.client.CreateVolume(ctx, r)
 
 		if err != nil {
 			return nil, err
 		}
 
 		log.Debugf("Created volume %v from EBS snapshot %v", id, ebsSnapshotID)
+		created = true
 	} else {
 
 		// CREATE a NEW EBS volume
@@ -153,15 +232,27 @@ func (v *VolumeCreator) Exec(req driver.Request) (*driver.Response, error) {
 			return nil, err
 		}
 
+		kmsKeyID, encrypted, err := v.d.getEncryption(opts)
+		if err != nil {
+			return nil, err
+		}
+
+		// Validated before the creation so that an invalid
+		// filesystem does not leave a volume behind
+		if filesystem, err = v.d.getFilesystem(opts); err != nil {
+			return nil, err
+		}
+
 		r := &CreateEBSVolumeRequest{
 			Size:       volumeSize,
 			VolumeType: volumeType,
 			IOPS:       iops,
 			Tags:       newTags,
-			KmsKeyID:   v.d.DefaultKmsKeyID,
+			KmsKeyID:   kmsKeyID,
+			Encrypted:  encrypted,
 		}
 
-		volumeID, err = v.d.client.CreateVolume(r)
+		volumeID, err = v.d.client.CreateVolume(ctx, r)
 		if err != nil {
 			return nil, err
 		}
@@ -169,10 +260,14 @@ func (v *VolumeCreator) Exec(req driver.Request) (*driver.Response, error) {
 		log.Debugf("Created volume %s from EBS volume %v", id, volumeID)
 		// This is true only for NEWLY created volumes.
 		format = true
+		created = true
 	}
 
-	dev, err := v.d.client.AttachVolume(volumeID, volumeSize)
+	dev, err := v.d.client.AttachVolume(ctx, volumeID, volumeSize)
 	if err != nil {
+		if created {
+			err = v.removeCreated(volumeID, err)
+		}
 		return nil, err
 	}
 
@@ -185,10 +280,31 @@ func (v *VolumeCreator) Exec(req driver.Request) (*driver.Response, error) {
 
 	// Do NOT format EXISTING or snapshot RESTORED volume
 	if format {
-		if _, err := util.Execute("mkfs", []string{"-t", "ext4", dev}); err != nil {
-			return nil, err
+		if _, err := util.Execute("mkfs", []string{"-t", filesystem, dev}); err != nil {
+			return nil, v.removeCreated(volumeID, err)
 		}
 	}
 
 	return &driver.Response{}, util.ObjectSave(volume)
 }
+
+// removeCreated removes the EBS volume that was created for a request
+// which failed later, e.g. as its attach failed or its context is done.
+// The volume is detached, as it may be attached partially, & deleted
+// even if the context is done. The error of the request is provided
+// along with the volume's ID if the volume could not be deleted.
+func (v *VolumeCreator) removeCreated(volumeID string, err error) error {
+	ctx := context.Background()
+
+	if dErr := v.d.client.DetachVolume(ctx, volumeID); dErr != nil {
+		log.Debugf("Failed to detach EBS volume %v, but continue: %v", volumeID, dErr)
+	}
+
+	if dErr := v.d.client.DeleteVolume(ctx, volumeID); dErr != nil {
+		log.Errorf("Failed deleting EBS volume %v: %v", volumeID, dErr)
+		return fmt.Errorf("%v: EBS volume %v is left behind: %v", err, volumeID, dErr)
+	}
+
+	log.Debugf("Deleted EBS volume %v of the failed request", volumeID)
+	return err
+}
@@ -1 +1,263 @@
 package ebs
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/driver"
+)
+
+func TestVolumeCreator_Plan(t *testing.T) {
+	d := &EBSDriver{
+		Device: Device{
+			DefaultVolumeSize: 4 << 30,
+			DefaultVolumeType: "gp2",
+			DefaultKmsKeyID:   "default-key",
+		},
+	}
+	v := &VolumeCreator{d: d}
+
+	cases := []struct {
+		name    string
+		opts    map[string]string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			opts: map[string]string{},
+			want: map[string]string{
+				OPT_SIZE:        "4294967296",
+				OPT_VOLUME_TYPE: "gp2",
+				OPT_ENCRYPTED:   "true",
+				OPT_KMS_KEY_ID:  "default-key",
+				OPT_FILESYSTEM:  "ext4",
+			},
+		},
+		{
+			name: "requested",
+			opts: map[string]string{
+				OPT_SIZE:        "8G",
+				OPT_VOLUME_TYPE: "io1",
+				OPT_VOLUME_IOPS: "100",
+				OPT_FILESYSTEM:  "xfs",
+			},
+			want: map[string]string{
+				OPT_SIZE:        "8589934592",
+				OPT_VOLUME_TYPE: "io1",
+				OPT_VOLUME_IOPS: "100",
+				OPT_ENCRYPTED:   "true",
+				OPT_KMS_KEY_ID:  "default-key",
+				OPT_FILESYSTEM:  "xfs",
+			},
+		},
+		{
+			name: "unencrypted",
+			opts: map[string]string{OPT_ENCRYPTED: "false", OPT_KMS_KEY_ID: "other-key"},
+			want: map[string]string{
+				OPT_SIZE:        "4294967296",
+				OPT_VOLUME_TYPE: "gp2",
+				OPT_ENCRYPTED:   "false",
+				OPT_FILESYSTEM:  "ext4",
+			},
+		},
+		{
+			name: "restored",
+			opts: map[string]string{OPT_BACKUP_URL: "ebs://us-east-1/snap-1"},
+			want: map[string]string{
+				OPT_BACKUP_URL:  "ebs://us-east-1/snap-1",
+				OPT_VOLUME_TYPE: "gp2",
+			},
+		},
+		{
+			name: "existing volume",
+			opts: map[string]string{OPT_VOLUME_ID: "vol-1"},
+			want: map[string]string{OPT_VOLUME_ID: "vol-1"},
+		},
+		{
+			name:    "backup & volume",
+			opts:    map[string]string{OPT_VOLUME_ID: "vol-1", OPT_BACKUP_URL: "ebs://us-east-1/snap-1"},
+			wantErr: true,
+		},
+		{
+			name:    "encryption",
+			opts:    map[string]string{OPT_ENCRYPTED: "maybe"},
+			wantErr: true,
+		},
+		{
+			name:    "filesystem",
+			opts:    map[string]string{OPT_FILESYSTEM: "ntfs"},
+			wantErr: true,
+		},
+		{
+			name:    "iops of gp2",
+			opts:    map[string]string{OPT_VOLUME_IOPS: "100"},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		req := driver.Request{Name: "vol", Options: c.opts}
+
+		got, err := v.Plan(req)
+		if c.wantErr {
+			if err == nil {
+				t.Errorf("%s: expected an error, got %v", c.name, got.Options)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: unexpected error: %s", c.name, err)
+			continue
+		}
+
+		if got.Name != "vol" {
+			t.Errorf("%s: expected name vol, got %s", c.name, got.Name)
+		}
+		if len(got.Options) != len(c.want) {
+			t.Errorf("%s: expected options %v, got %v", c.name, c.want, got.Options)
+		}
+		for k, want := range c.want {
+			if got.Options[k] != want {
+				t.Errorf("%s: expected %s %q, got %q", c.name, k, want, got.Options[k])
+			}
+		}
+	}
+
+	// The request itself is not modified
+	opts := map[string]string{}
+	if _, err := v.Plan(driver.Request{Name: "vol", Options: opts}); err != nil {
+		t.Fatal(err)
+	}
+	if len(opts) != 0 {
+		t.Errorf("expected the request to be intact, got %v", opts)
+	}
+}
+
+func TestVolumeCreator_RemoveCreated(t *testing.T) {
+	var (
+		m       sync.Mutex
+		actions []string
+	)
+
+	// The detach fails, as the volume was not attached, while the delete
+	// succeeds only if the volume is deletable
+	newCreator := func(deletable bool) *VolumeCreator {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			r.ParseForm()
+			m.Lock()
+			actions = append(actions, r.Form.Get("Action"))
+			m.Unlock()
+
+			if r.Form.Get("Action") == "DeleteVolume" && deletable {
+				w.Write([]byte(`<DeleteVolumeResponse><requestId>req-1</requestId><return>true</return></DeleteVolumeResponse>`))
+				return
+			}
+
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`<Response><Errors><Error><Code>IncorrectState</Code><Message>bad state</Message></Error></Errors><RequestID>req-1</RequestID></Response>`))
+		})
+
+		return &VolumeCreator{d: &EBSDriver{client: &ebsClient{ec2Client: client, InstanceID: "i-1"}}}
+	}
+
+	attachErr := fmt.Errorf("attach failed")
+
+	err := newCreator(true).removeCreated("vol-1", attachErr)
+	if err != attachErr {
+		t.Fatalf("expected the attach error, got %v", err)
+	}
+	if strings.Join(actions, ",") != "DetachVolume,DeleteVolume" {
+		t.Fatalf("bad actions: %v", actions)
+	}
+
+	// The volume that is left behind is reported
+	err = newCreator(false).removeCreated("vol-1", attachErr)
+	if err == nil || !strings.Contains(err.Error(), "attach failed") || !strings.Contains(err.Error(), "vol-1 is left behind") {
+		t.Fatalf("expected the volume to be reported, got %v", err)
+	}
+}
+
+func TestEBSClient_AttachVolume_PerInstance(t *testing.T) {
+	var (
+		m          sync.Mutex
+		inflight   = make(map[string]int)
+		overlapped bool
+		concurrent bool
+		once       sync.Once
+	)
+
+	// An attach to i-1 is in flight till the attach to i-2 arrives, which
+	// it does only if the attaches to i-1 do not hold it up. The attaches
+	// fail once they are sent, as there is no block device to identify.
+	other := make(chan struct{})
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		if r.Form.Get("Action") != "AttachVolume" {
+			w.Write([]byte(`<DescribeVolumesResponse><requestId>req-1</requestId><volumeSet></volumeSet></DescribeVolumesResponse>`))
+			return
+		}
+
+		instance := r.Form.Get("InstanceId")
+		m.Lock()
+		inflight[instance]++
+		if inflight[instance] > 1 {
+			overlapped = true
+		}
+		if instance == "i-2" && inflight["i-1"] > 0 {
+			concurrent = true
+		}
+		m.Unlock()
+
+		if instance == "i-2" {
+			once.Do(func() { close(other) })
+		} else {
+			select {
+			case <-other:
+			case <-time.After(5 * time.Second):
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		m.Lock()
+		inflight[instance]--
+		m.Unlock()
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`<Response><Errors><Error><Code>IncorrectState</Code><Message>bad state</Message></Error></Errors><RequestID>req-1</RequestID></Response>`))
+	})
+
+	var wg sync.WaitGroup
+	ctx, wait := driver.WithWaitTimer(context.Background())
+	attach := func(instance string) {
+		defer wg.Done()
+		c := &ebsClient{ec2Client: client, InstanceID: instance}
+		if _, err := c.AttachVolume(ctx, "vol-1", 4<<30); err == nil {
+			t.Errorf("expected the attach to fail")
+		}
+	}
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go attach("i-1")
+	}
+	time.Sleep(10 * time.Millisecond)
+	wg.Add(1)
+	go attach("i-2")
+	wg.Wait()
+
+	if overlapped {
+		t.Fatalf("expected the attaches to an instance to be serialized")
+	}
+	if !concurrent {
+		t.Fatalf("expected the attaches to other instances to not be held up")
+	}
+	if wait.Waited() <= 0 {
+		t.Fatalf("expected the waits for the attach lock to be recorded")
+	}
+}
@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/openebs/mtest/driver"
@@ -31,6 +32,10 @@ func VolumeListerInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (v *VolumeLister) Exec(req driver.Request) (*driver.Response, error) {
+	return v.ExecContext(context.Background(), req)
+}
+
+func (v *VolumeLister) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 
 	volumeIDs, err := v.d.listVolumeNames()
 	if err != nil {
@@ -60,7 +65,7 @@ func (v *VolumeLister) Exec(req driver.Request) (*driver.Response, error) {
 			"uuid": uuid,
 		}
 
-		resp, err := execs[EBS_VOLUME_READ_EXEC].Exec(req2)
+		resp, err := driver.ExecContext(ctx, execs[EBS_VOLUME_READ_EXEC], req2)
 		if err != nil {
 			return nil, err
 		}
@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"time"
@@ -35,6 +36,10 @@ func VolumeReaderInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (v *VolumeReader) Exec(req driver.Request) (*driver.Response, error) {
+	return v.ExecContext(context.Background(), req)
+}
+
+func (v *VolumeReader) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 
 	v.d.mutex.Lock()
 	defer v.d.mutex.Unlock()
@@ -49,7 +54,7 @@ func (v *VolumeReader) Exec(req driver.Request) (*driver.Response, error) {
 		return nil, err
 	}
 
-	ebsVolume, err := v.d.client.GetVolume(volume.EBSID)
+	ebsVolume, err := v.d.client.GetVolume(ctx, volume.EBSID)
 	if err != nil {
 		return nil, err
 	}
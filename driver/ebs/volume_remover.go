@@ -1,6 +1,7 @@
 package ebs
 
 import (
+	"context"
 	"strconv"
 
 	"github.com/openebs/mtest/driver"
@@ -32,6 +33,10 @@ func VolumeRemoverInit(ebsDriver *EBSDriver) (driver.Executor, error) {
 }
 
 func (v *VolumeRemover) Exec(req driver.Request) (*driver.Response, error) {
+	return v.ExecContext(context.Background(), req)
+}
+
+func (v *VolumeRemover) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
 
 	v.d.mutex.Lock()
 	defer v.d.mutex.Unlock()
@@ -47,7 +52,7 @@ func (v *VolumeRemover) Exec(req driver.Request) (*driver.Response, error) {
 
 	referenceOnly, _ := strconv.ParseBool(opts[OPT_REFERENCE_ONLY])
 
-	err = v.d.client.DetachVolume(volume.EBSID)
+	err = v.d.client.DetachVolume(ctx, volume.EBSID)
 	if err != nil {
 		if !referenceOnly {
 			return nil, err
@@ -62,7 +67,7 @@ func (v *VolumeRemover) Exec(req driver.Request) (*driver.Response, error) {
 	}
 
 	if !referenceOnly {
-		err := v.d.client.DeleteVolume(volume.EBSID)
+		err := v.d.client.DeleteVolume(ctx, volume.EBSID)
 		if err != nil {
 			return nil, err
 		}
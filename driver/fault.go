@@ -0,0 +1,176 @@
+package driver
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// Prefix of the driver config properties of the faults, e.g.
+	// fault.<name>.operations
+	FAULT_CFG_PREFIX = "fault."
+
+	// Properties of a fault within the driver config
+	FAULT_OPERATIONS  = "operations"
+	FAULT_PROBABILITY = "probability"
+	FAULT_LATENCY     = "latency"
+	FAULT_ERROR       = "error"
+
+	// The request fails as the service is throttling the requests
+	FAULT_THROTTLE = "throttle"
+
+	// The request fails with an internal error of the service
+	FAULT_SERVER_ERROR = "5xx"
+
+	// The request never reaches the service, as if the connection
+	// was dropped
+	FAULT_DROP = "drop"
+
+	// The request succeeds but its response can not be decoded
+	FAULT_CORRUPT = "corrupt"
+)
+
+// Fault is a failure that a driver injects into the requests it sends
+// to its service. This verifies the behaviour of the service's clients
+// & of the service itself under failures, without a broken network.
+//
+// Faults are provided to a driver via its config. A driver that does
+// not support fault injection ignores them.
+type Fault struct {
+	// Name of the fault, unique amongst the faults of a driver
+	Name string
+
+	// Operations of the service that are faulted, e.g. CreateVolume.
+	// All the operations are faulted if this is empty.
+	Operations []string
+
+	// Probability with which a request of the operations is faulted,
+	// between 0 & 1
+	Probability float64
+
+	// Latency that is added before the request is sent
+	Latency time.Duration
+
+	// Error is the kind of failure, if any, e.g. FAULT_THROTTLE
+	Error string
+}
+
+// IsFaultError returns true if the kind of failure is supported
+func IsFaultError(kind string) bool {
+	switch kind {
+	case FAULT_THROTTLE, FAULT_SERVER_ERROR, FAULT_DROP, FAULT_CORRUPT:
+		return true
+	default:
+		return false
+	}
+}
+
+// Matches returns true if the fault applies to the operation
+func (f *Fault) Matches(operation string) bool {
+	if len(f.Operations) == 0 {
+		return true
+	}
+
+	for _, op := range f.Operations {
+		if op == operation {
+			return true
+		}
+	}
+
+	return false
+}
+
+// String provides a single line & readable form of the fault
+func (f *Fault) String() string {
+	s := fmt.Sprintf("%s: %.0f%% of", f.Name, f.Probability*100)
+
+	if len(f.Operations) == 0 {
+		s += " all operations"
+	} else {
+		s += " " + strings.Join(f.Operations, ",")
+	}
+
+	if f.Latency > 0 {
+		s += fmt.Sprintf(", latency %v", f.Latency)
+	}
+
+	if f.Error != "" {
+		s += ", error " + f.Error
+	}
+
+	return s
+}
+
+// Encode sets the fault as properties of the driver config
+func (f *Fault) Encode(config map[string]string) {
+	prefix := FAULT_CFG_PREFIX + f.Name + "."
+
+	config[prefix+FAULT_OPERATIONS] = strings.Join(f.Operations, ",")
+	config[prefix+FAULT_PROBABILITY] = strconv.FormatFloat(f.Probability, 'f', -1, 64)
+	config[prefix+FAULT_LATENCY] = f.Latency.String()
+	config[prefix+FAULT_ERROR] = f.Error
+}
+
+// DecodeFaults gets the faults that are set as properties of the
+// driver config. The faults are ordered by their names.
+func DecodeFaults(config map[string]string) ([]*Fault, error) {
+	faults := make(map[string]*Fault)
+
+	for k, v := range config {
+		if !strings.HasPrefix(k, FAULT_CFG_PREFIX) {
+			continue
+		}
+
+		rest := k[len(FAULT_CFG_PREFIX):]
+		i := strings.LastIndex(rest, ".")
+		if i <= 0 {
+			return nil, fmt.Errorf("Invalid fault property '%s'", k)
+		}
+		name, prop := rest[:i], rest[i+1:]
+
+		f, exists := faults[name]
+		if !exists {
+			f = &Fault{Name: name}
+			faults[name] = f
+		}
+
+		var err error
+		switch prop {
+		case FAULT_OPERATIONS:
+			if v != "" {
+				f.Operations = strings.Split(v, ",")
+			}
+		case FAULT_PROBABILITY:
+			f.Probability, err = strconv.ParseFloat(v, 64)
+		case FAULT_LATENCY:
+			f.Latency, err = time.ParseDuration(v)
+		case FAULT_ERROR:
+			f.Error = v
+		default:
+			err = fmt.Errorf("unknown property")
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("Invalid fault property '%s': %s", k, err)
+		}
+	}
+
+	result := make([]*Fault, 0, len(faults))
+	for _, f := range faults {
+		if f.Probability < 0 || f.Probability > 1 {
+			return nil, fmt.Errorf("Fault '%s': probability should be between 0 & 1", f.Name)
+		}
+
+		if f.Error != "" && !IsFaultError(f.Error) {
+			return nil, fmt.Errorf("Fault '%s': unsupported error '%s'", f.Name, f.Error)
+		}
+
+		result = append(result, f)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result, nil
+}
@@ -0,0 +1,66 @@
+package driver
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFault_EncodeDecode(t *testing.T) {
+	faults := []*Fault{
+		{
+			Name:        "slow",
+			Probability: 1,
+			Latency:     2 * time.Second,
+		},
+		{
+			Name:        "throttle.creates",
+			Operations:  []string{"CreateVolume", "CreateSnapshot"},
+			Probability: 0.25,
+			Error:       FAULT_THROTTLE,
+		},
+	}
+
+	config := map[string]string{"ebs.defaultvolumesize": "4G"}
+	for _, f := range faults {
+		f.Encode(config)
+	}
+
+	decoded, err := DecodeFaults(config)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !reflect.DeepEqual(decoded, faults) {
+		t.Fatalf("bad faults:\nwant: %v\n got: %v", faults, decoded)
+	}
+
+	if !decoded[1].Matches("CreateSnapshot") || decoded[1].Matches("DeleteVolume") {
+		t.Fatalf("bad operations of %s", decoded[1])
+	}
+
+	if !decoded[0].Matches("DeleteVolume") {
+		t.Fatalf("expected %s to match all operations", decoded[0])
+	}
+}
+
+func TestDecodeFaults_Invalid(t *testing.T) {
+	cases := []struct {
+		Config map[string]string
+		Err    string
+	}{
+		{map[string]string{"fault.x": "1"}, "Invalid fault property"},
+		{map[string]string{"fault.x.color": "red"}, "unknown property"},
+		{map[string]string{"fault.x.latency": "soon"}, "Invalid fault property"},
+		{map[string]string{"fault.x.probability": "2"}, "probability should be between 0 & 1"},
+		{map[string]string{"fault.x.error": "boom"}, "unsupported error 'boom'"},
+	}
+
+	for _, tc := range cases {
+		_, err := DecodeFaults(tc.Config)
+		if err == nil || !strings.Contains(err.Error(), tc.Err) {
+			t.Fatalf("%v: expected error containing %q, got %v", tc.Config, tc.Err, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+// Package mock provides a MtestDriver for the tests of the runners &
+// the commands. It is registered as the "mock" driver on import.
+package mock
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openebs/mtest/driver"
+)
+
+const (
+	// Name of this driver
+	DRIVER_NAME = "mock"
+
+	// Region provided by the info of this driver
+	MOCK_REGION = "mock-region"
+)
+
+// MockDriver is a MtestDriver whose executors echo the request name &
+// options as the response values along with the executor's hint. The
+// executor of a hint behaves as per the hint:
+//
+//	fail   fails always
+//	flaky  fails at its first call & succeeds later
+//	block  blocks till its context is done
+//	slow   succeeds after a short delay, unless its context is done
+//	serial succeeds after a short delay, one call at a time across the
+//	       process & records the time it waited on the other calls
+//
+// Executors of any other hint succeed, unless the hint is one of the
+// comma separated hints of the "fail" key of the driver's config.
+type MockDriver struct {
+	fail map[string]bool
+}
+
+// MockExecutor is the executor of a hint of MockDriver
+type MockExecutor struct {
+	hint  string
+	fail  bool
+	calls int
+}
+
+// serial serializes the calls of the "serial" executors like the
+// attaches of an EC2 instance are
+var serial sync.Mutex
+
+func init() {
+	// Register by passing the name of the driver
+	// and the function definition.
+	driver.Register(DRIVER_NAME, Init)
+}
+
+// Init initializes the MockDriver as a MtestDriver
+func Init(root string, config map[string]string) (driver.MtestDriver, error) {
+	d := &MockDriver{
+		fail: make(map[string]bool),
+	}
+
+	if hints := config["fail"]; hints != "" {
+		for _, hint := range strings.Split(hints, ",") {
+			d.fail[strings.TrimSpace(hint)] = true
+		}
+	}
+
+	return d, nil
+}
+
+func (d *MockDriver) Name() string {
+	return DRIVER_NAME
+}
+
+func (d *MockDriver) Info() (map[string]string, error) {
+	return map[string]string{"Region": MOCK_REGION}, nil
+}
+
+func (d *MockDriver) Executors(hints ...string) (map[string]driver.Executor, error) {
+	execs := make(map[string]driver.Executor)
+	for _, hint := range hints {
+		execs[hint] = &MockExecutor{hint: hint, fail: d.fail[hint]}
+	}
+	return execs, nil
+}
+
+func (e *MockExecutor) ExecContext(ctx context.Context, req driver.Request) (*driver.Response, error) {
+	switch e.hint {
+	case "block":
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case "slow":
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(20 * time.Millisecond):
+		}
+	case "serial":
+		start := time.Now()
+		serial.Lock()
+		defer serial.Unlock()
+		driver.RecordWait(ctx, time.Since(start))
+
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	return e.Exec(req)
+}
+
+func (e *MockExecutor) Exec(req driver.Request) (*driver.Response, error) {
+	e.calls++
+
+	if e.fail || e.hint == "fail" || (e.hint == "flaky" && e.calls < 2) {
+		return nil, fmt.Errorf("mock failure of %s", req.Name)
+	}
+
+	values := map[string]interface{}{
+		"Hint": e.hint,
+	}
+	for k, v := range req.Options {
+		values[k] = v
+	}
+
+	return &driver.Response{
+		Values: values,
+	}, nil
+}
+
+// Config provides the HCL of an mtest config with a scenario per hint.
+// Each scenario is named after its hint & has a single step that is
+// executed by the executor of the hint.
+func Config(hints ...string) string {
+	var s string
+	for _, hint := range hints {
+		s += fmt.Sprintf("scenario %q {\n  step \"s\" {\n    driver = %q\n    executor = %q\n    name = \"vol1\"\n  }\n}\n",
+			hint, DRIVER_NAME, hint)
+	}
+	return s
+}
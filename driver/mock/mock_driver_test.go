@@ -0,0 +1,85 @@
+package mock
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+)
+
+func TestMockDriver_Executors(t *testing.T) {
+	d, err := driver.GetDriver(DRIVER_NAME, "", nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	execs, err := d.Executors("ok", "fail", "flaky", "block")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	resp, err := execs["ok"].Exec(driver.Request{Name: "vol1", Options: map[string]string{"Size": "1G"}})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if resp.Values["Hint"] != "ok" || resp.Values["Size"] != "1G" {
+		t.Fatalf("bad values: %#v", resp.Values)
+	}
+
+	if _, err := execs["fail"].Exec(driver.Request{Name: "vol1"}); err == nil {
+		t.Fatalf("expected an error")
+	}
+
+	if _, err := execs["flaky"].Exec(driver.Request{Name: "vol1"}); err == nil {
+		t.Fatalf("expected an error at the first call")
+	}
+	if _, err := execs["flaky"].Exec(driver.Request{Name: "vol1"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := driver.ExecContext(ctx, execs["block"], driver.Request{Name: "vol1"}); err != context.DeadlineExceeded {
+		t.Fatalf("expected the deadline to exceed, got %v", err)
+	}
+}
+
+func TestMockDriver_FailConfig(t *testing.T) {
+	d, err := driver.GetDriver(DRIVER_NAME, "", map[string]string{"fail": "ok, other"})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	execs, err := d.Executors("ok", "snap")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if _, err := execs["ok"].Exec(driver.Request{Name: "vol1"}); err == nil {
+		t.Fatalf("expected the configured hint to fail")
+	}
+	if _, err := execs["snap"].Exec(driver.Request{Name: "vol1"}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+}
+
+func TestConfig(t *testing.T) {
+	mconfig, err := config.ParseMtestConfig(strings.NewReader(Config("ok", "fail")))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(mconfig.Scenarios) != 2 {
+		t.Fatalf("expected 2 scenarios, got %d", len(mconfig.Scenarios))
+	}
+
+	for i, hint := range []string{"ok", "fail"} {
+		s := mconfig.Scenarios[i]
+		if s.Name != hint || len(s.Steps) != 1 || s.Steps[0].Driver != DRIVER_NAME || s.Steps[0].Executor != hint {
+			t.Fatalf("bad scenario %d: %#v", i, s)
+		}
+	}
+}
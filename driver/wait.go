@@ -0,0 +1,41 @@
+package driver
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+type waitTimerKey struct{}
+
+// WaitTimer accumulates the time that an execution waited on a lock
+// or a queue held by other executions, e.g. the attach lock of an EC2
+// instance. This lets the callers tell the time spent in the service
+// apart from the time spent queueing behind each other.
+type WaitTimer struct {
+	parent *WaitTimer
+	waited int64
+}
+
+// WithWaitTimer provides a context with a new WaitTimer. The waits
+// recorded against the context are added to the new timer as well as
+// to the timers of the parent context, if any.
+func WithWaitTimer(ctx context.Context) (context.Context, *WaitTimer) {
+	parent, _ := ctx.Value(waitTimerKey{}).(*WaitTimer)
+	t := &WaitTimer{parent: parent}
+	return context.WithValue(ctx, waitTimerKey{}, t), t
+}
+
+// RecordWait adds the wait to the WaitTimers of the context. It is a
+// noop if the context has no WaitTimer.
+func RecordWait(ctx context.Context, d time.Duration) {
+	t, _ := ctx.Value(waitTimerKey{}).(*WaitTimer)
+	for ; t != nil; t = t.parent {
+		atomic.AddInt64(&t.waited, int64(d))
+	}
+}
+
+// Waited provides the time waited so far
+func (t *WaitTimer) Waited() time.Duration {
+	return time.Duration(atomic.LoadInt64(&t.waited))
+}
@@ -0,0 +1,27 @@
+package driver
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWaitTimer(t *testing.T) {
+	// Noop without a timer
+	RecordWait(context.Background(), time.Second)
+
+	ctx, op := WithWaitTimer(context.Background())
+	RecordWait(ctx, time.Second)
+
+	stepCtx, step := WithWaitTimer(ctx)
+	RecordWait(stepCtx, 2*time.Second)
+
+	if step.Waited() != 2*time.Second {
+		t.Fatalf("bad step wait: %v", step.Waited())
+	}
+
+	// The parent includes the waits of its children
+	if op.Waited() != 3*time.Second {
+		t.Fatalf("bad op wait: %v", op.Waited())
+	}
+}
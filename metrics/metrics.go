@@ -0,0 +1,286 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// Types of the metrics as per Prometheus text exposition format
+	TYPE_COUNTER   = "counter"
+	TYPE_HISTOGRAM = "histogram"
+
+	// Content type of the Prometheus text exposition format
+	CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// DEFAULT_BUCKETS are the upper bounds, in seconds, of the buckets of a
+// histogram of latencies
+var DEFAULT_BUCKETS = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60}
+
+// Default is the registry where the drivers & runners record their
+// metrics, so that the metrics need not be threaded through them.
+var Default = NewRegistry()
+
+// Registry holds the metrics by their names & exposes them in
+// Prometheus text exposition format.
+type Registry struct {
+	m        sync.Mutex
+	families map[string]*family
+}
+
+// family is a metric along with its series, one per set of label values
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	series  map[string]*series
+}
+
+// series is the value of a metric for a set of label values. A counter
+// has only its value, whereas a histogram has the cumulative counts of
+// its buckets along with the sum & count of its observations.
+type series struct {
+	labels []string
+	value  float64
+	counts []uint64
+	count  uint64
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+// Counter is a metric that only goes up
+type Counter struct {
+	r *Registry
+	f *family
+}
+
+// Histogram is a metric that counts the observations in buckets
+type Histogram struct {
+	r *Registry
+	f *family
+}
+
+// Counter provides the counter of the name. The counter is created if it
+// is not registered.
+//
+// NOTE: A name registered as another type or with other labels panics,
+// as it is a programming error.
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{
+		r: r,
+		f: r.family(name, help, TYPE_COUNTER, labels, nil),
+	}
+}
+
+// Histogram provides the histogram of the name with the given buckets,
+// or DEFAULT_BUCKETS if none. The histogram is created if it is not
+// registered.
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DEFAULT_BUCKETS
+	}
+
+	return &Histogram{
+		r: r,
+		f: r.family(name, help, TYPE_HISTOGRAM, labels, buckets),
+	}
+}
+
+func (r *Registry) family(name, help, typ string, labels []string, buckets []float64) *family {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	if f, ok := r.families[name]; ok {
+		if f.typ != typ || strings.Join(f.labels, ",") != strings.Join(labels, ",") {
+			panic(fmt.Sprintf("metric %s is registered as a %s with labels %v", name, f.typ, f.labels))
+		}
+		return f
+	}
+
+	f := &family{
+		name:    name,
+		help:    help,
+		typ:     typ,
+		labels:  labels,
+		buckets: buckets,
+		series:  make(map[string]*series),
+	}
+	r.families[name] = f
+	return f
+}
+
+// get provides the series of the label values, creating it if needed.
+// The caller must hold the lock of the registry.
+func (f *family) get(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metric %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{
+			labels: append([]string(nil), values...),
+			counts: make([]uint64, len(f.buckets)),
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Inc increments the counter of the label values by 1
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Add adds a non negative value to the counter of the label values
+func (c *Counter) Add(v float64, values ...string) {
+	if v < 0 {
+		return
+	}
+
+	c.r.m.Lock()
+	defer c.r.m.Unlock()
+
+	c.f.get(values).value += v
+}
+
+// Observe records an observation in the histogram of the label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.r.m.Lock()
+	defer h.r.m.Unlock()
+
+	s := h.f.get(values)
+	for i, upper := range h.f.buckets {
+		if v <= upper {
+			s.counts[i]++
+		}
+	}
+	s.count++
+	s.value += v
+}
+
+// Value provides the value of the counter of the label values
+func (c *Counter) Value(values ...string) float64 {
+	c.r.m.Lock()
+	defer c.r.m.Unlock()
+
+	if s, ok := c.f.series[strings.Join(values, "\xff")]; ok {
+		return s.value
+	}
+	return 0
+}
+
+// Count provides the no of observations in the histogram of the label
+// values
+func (h *Histogram) Count(values ...string) uint64 {
+	h.r.m.Lock()
+	defer h.r.m.Unlock()
+
+	if s, ok := h.f.series[strings.Join(values, "\xff")]; ok {
+		return s.count
+	}
+	return 0
+}
+
+// Write writes the metrics in Prometheus text exposition format. The
+// metrics & their series are sorted, so that the output is stable.
+func (r *Registry) Write(w io.Writer) error {
+	r.m.Lock()
+	defer r.m.Unlock()
+
+	names := make([]string, 0, len(r.families))
+	for name := range r.families {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	bw := bufio.NewWriter(w)
+	for _, name := range names {
+		r.families[name].write(bw)
+	}
+	return bw.Flush()
+}
+
+func (f *family) write(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+
+	keys := make([]string, 0, len(f.series))
+	for key := range f.series {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s := f.series[key]
+		if f.typ == TYPE_COUNTER {
+			fmt.Fprintf(w, "%s%s %s\n", f.name, f.labelPairs(s.labels, ""), formatFloat(s.value))
+			continue
+		}
+
+		for i, upper := range f.buckets {
+			fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.labelPairs(s.labels, formatFloat(upper)), s.counts[i])
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", f.name, f.labelPairs(s.labels, "+Inf"), s.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", f.name, f.labelPairs(s.labels, ""), formatFloat(s.value))
+		fmt.Fprintf(w, "%s_count%s %d\n", f.name, f.labelPairs(s.labels, ""), s.count)
+	}
+}
+
+// labelPairs formats the label values along with the upper bound of a
+// bucket, if any
+func (f *family) labelPairs(values []string, le string) string {
+	var pairs []string
+	for i, name := range f.labels {
+		pairs = append(pairs, fmt.Sprintf("%s=\"%s\"", name, escapeLabel(values[i])))
+	}
+	if le != "" {
+		pairs = append(pairs, fmt.Sprintf("le=\"%s\"", le))
+	}
+
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+// ServeHTTP serves the metrics for Prometheus to scrape
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", CONTENT_TYPE)
+	r.Write(w)
+}
+
+func formatFloat(v float64) string {
+	if math.IsInf(v, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var helpReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+
+var labelReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelReplacer.Replace(s)
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRegistry_Write(t *testing.T) {
+	reg := NewRegistry()
+
+	calls := reg.Counter("calls_total", "No of calls.", "op")
+	calls.Inc("Create")
+	calls.Add(2, "Delete")
+	calls.Inc("Create")
+	calls.Add(-1, "Create")
+
+	latency := reg.Histogram("latency_seconds", "Latency\nof calls.", []float64{0.1, 1}, "op")
+	latency.Observe(0.05, "Create")
+	latency.Observe(0.5, "Create")
+	latency.Observe(5, "Create")
+
+	reg.Counter("quoted_total", "", "name").Inc(`a "b"`)
+
+	var buf bytes.Buffer
+	if err := reg.Write(&buf); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := `# HELP calls_total No of calls.
+# TYPE calls_total counter
+calls_total{op="Create"} 2
+calls_total{op="Delete"} 2
+# HELP latency_seconds Latency\nof calls.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{op="Create",le="0.1"} 1
+latency_seconds_bucket{op="Create",le="1"} 2
+latency_seconds_bucket{op="Create",le="+Inf"} 3
+latency_seconds_sum{op="Create"} 5.55
+latency_seconds_count{op="Create"} 3
+# HELP quoted_total 
+# TYPE quoted_total counter
+quoted_total{name="a \"b\""} 1
+`
+	if buf.String() != expected {
+		t.Fatalf("bad output:\nwant: %s\n got: %s", expected, buf.String())
+	}
+
+	if v := calls.Value("Create"); v != 2 {
+		t.Fatalf("expected 2, got %v", v)
+	}
+	if n := latency.Count("Create"); n != 3 {
+		t.Fatalf("expected 3, got %d", n)
+	}
+}
+
+func TestRegistry_SameMetric(t *testing.T) {
+	reg := NewRegistry()
+
+	reg.Counter("calls_total", "No of calls.").Inc()
+	if v := reg.Counter("calls_total", "No of calls.").Value(); v != 1 {
+		t.Fatalf("expected the registered counter, got %v", v)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatalf("expected a panic on a different type")
+		}
+	}()
+	reg.Histogram("calls_total", "No of calls.", nil)
+}
+
+func TestRegistry_ServeHTTP(t *testing.T) {
+	reg := NewRegistry()
+	reg.Counter("calls_total", "No of calls.").Inc()
+
+	srv := httptest.NewServer(reg)
+	defer srv.Close()
+
+	resp, err := srv.Client().Get(srv.URL)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer resp.Body.Close()
+
+	if ct := resp.Header.Get("Content-Type"); ct != CONTENT_TYPE {
+		t.Fatalf("bad content type: %s", ct)
+	}
+
+	b, _ := ioutil.ReadAll(resp.Body)
+	if !strings.Contains(string(b), "calls_total 1\n") {
+		t.Fatalf("bad body: %s", b)
+	}
+}
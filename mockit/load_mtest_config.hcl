@@ -0,0 +1,35 @@
+log_level = "INFO"
+
+scenario "create-remove" {
+  step "create" {
+    driver   = "ebs"
+    executor = "ebs.volume.create.executor"
+    name     = "load-${unique}"
+  }
+
+  step "remove" {
+    driver   = "ebs"
+    executor = "ebs.volume.remove.executor"
+    name     = "${create.Name}"
+  }
+}
+
+load "create-at-8" {
+  driver      = "ebs"
+  executor    = "ebs.volume.create.executor"
+  name        = "load-${unique}"
+  concurrency = 8
+  duration    = "10m"
+
+  options {
+    Size = "1G"
+  }
+}
+
+load "cycle-at-2-per-sec" {
+  scenario       = "create-remove"
+  rate           = 2
+  concurrency    = 16
+  ops            = 500
+  max_error_rate = 0.01
+}
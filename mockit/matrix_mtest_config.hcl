@@ -0,0 +1,55 @@
+log_level = "INFO"
+
+scenario "volume-types" {
+  tags = ["volume", "matrix"]
+
+  matrix {
+    type      = ["gp2", "io1"]
+    size      = ["4G", "100G"]
+    iops      = ["", "100"]
+    encrypted = ["true", "false"]
+
+    exclude {
+      type = "gp2"
+      iops = "100"
+    }
+
+    exclude {
+      type = "io1"
+      iops = ""
+    }
+
+    include {
+      type      = "st1"
+      size      = "500G"
+      iops      = ""
+      encrypted = false
+    }
+  }
+
+  step "create" {
+    driver   = "ebs"
+    executor = "ebs.volume.create.executor"
+    name     = "vol-${matrix.type}-${unique}"
+
+    options {
+      VolumeType = "${matrix.type}"
+      Size       = "${matrix.size}"
+      VolumeIOPS = "${matrix.iops}"
+      Encrypted  = "${matrix.encrypted}"
+    }
+  }
+
+  step "read" {
+    driver   = "ebs"
+    executor = "ebs.volume.read.executor"
+    name     = "${create.Name}"
+    assert   = ["VolumeType == ${matrix.type}"]
+  }
+
+  step "remove" {
+    driver   = "ebs"
+    executor = "ebs.volume.remove.executor"
+    name     = "${create.Name}"
+  }
+}
@@ -0,0 +1,13 @@
+log_level = "INFO"
+
+random {
+  seed            = 1536828311
+  sequences       = 10
+  ops             = 40
+  max_shrink_runs = 100
+
+  options {
+    Size       = "1G"
+    VolumeType = "gp2"
+  }
+}
@@ -0,0 +1,53 @@
+log_level = "INFO"
+
+scenario "snapshot-restore" {
+  tags = ["snapshot", "backup"]
+
+  step "create" {
+    driver   = "ebs"
+    executor = "ebs.volume.create.executor"
+    name     = "vol1"
+
+    options {
+      Size       = "4G"
+      VolumeType = "gp2"
+    }
+  }
+
+  step "snap" {
+    driver   = "ebs"
+    executor = "ebs.snapshot.create.executor"
+    name     = "snap1"
+
+    options {
+      VolumeName = "${create.Name}"
+    }
+  }
+
+  step "backup" {
+    driver   = "ebs"
+    executor = "ebs.backup.create.executor"
+    attempts = 3
+
+    options {
+      SnapshotID = "${snap.Name}"
+      VolumeID   = "${create.Name}"
+    }
+  }
+
+  step "restore" {
+    driver   = "ebs"
+    executor = "ebs.volume.create.executor"
+    name     = "vol2"
+
+    options {
+      BackupURL = "${backup.BackupURL}"
+    }
+  }
+
+  step "remove" {
+    driver   = "ebs"
+    executor = "ebs.volume.remove.executor"
+    name     = "${restore.Name}"
+  }
+}
@@ -0,0 +1,148 @@
+package mtest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/util"
+)
+
+// AssertionResult is the outcome of an assertion on a step's output or
+// of a step's expected error.
+type AssertionResult struct {
+	// The step whose output was asserted
+	Step string
+
+	// The assertion with its value resolved, e.g. State == "available"
+	Assertion string
+
+	// The actual value of the step's output or the step's error
+	Actual string
+
+	// Passed is true if the expectation was met
+	Passed bool
+}
+
+// String provides a single line & readable form of the result
+func (r *AssertionResult) String() string {
+	if r.Passed {
+		return fmt.Sprintf("step '%s': %s passed", r.Step, r.Assertion)
+	}
+
+	return fmt.Sprintf("step '%s': %s failed, got %q", r.Step, r.Assertion, r.Actual)
+}
+
+// assertOutput evaluates the step's assertions against its output. The
+// values of the assertions are resolved against the outputs of the
+// earlier steps. Evaluation stops at the first assertion that can not
+// be evaluated.
+func assertOutput(step *config.Step, out, outputs map[string]interface{}) ([]*AssertionResult, error) {
+	results := make([]*AssertionResult, 0, len(step.Assert))
+
+	for _, s := range step.Assert {
+		a, err := config.ParseAssertion(s)
+		if err != nil {
+			return results, err
+		}
+
+		if a.Value, err = interpolate(a.Value, outputs); err != nil {
+			return results, fmt.Errorf("assertion '%s': %s", s, err)
+		}
+
+		r := &AssertionResult{
+			Step:      step.Name,
+			Assertion: a.String(),
+		}
+
+		actual, found := lookupValue(out, strings.Split(a.Key, "."))
+		if found {
+			r.Actual = fmt.Sprint(actual)
+			if r.Passed, err = compare(r.Actual, a.Op, a.Value); err != nil {
+				return results, fmt.Errorf("assertion '%s': %s", s, err)
+			}
+		} else {
+			r.Actual = "<not found>"
+		}
+
+		results = append(results, r)
+	}
+
+	return results, nil
+}
+
+// assertError evaluates the step's error against its expected error
+func assertError(step *config.Step, err error) *AssertionResult {
+	r := &AssertionResult{
+		Step:      step.Name,
+		Assertion: fmt.Sprintf("error %s %q", config.OP_CONTAINS, step.ExpectError),
+		Actual:    "<no error>",
+	}
+
+	if err != nil {
+		r.Actual = err.Error()
+		r.Passed = strings.Contains(r.Actual, step.ExpectError)
+	}
+
+	return r
+}
+
+// isExpectedError returns true if the step is expected to fail with
+// the error
+func isExpectedError(step *config.Step, err error) bool {
+	return err != nil && step.ExpectError != "" && strings.Contains(err.Error(), step.ExpectError)
+}
+
+// compare compares the actual value with the expected value. Both are
+// compared as numbers if they are numbers, as sizes, e.g. 4G, if they
+// are sizes, else as strings. Strings can be checked for equality
+// only.
+func compare(actual, op, expected string) (bool, error) {
+	if op == config.OP_CONTAINS {
+		return strings.Contains(actual, expected), nil
+	}
+
+	if a, e, ok := numbers(actual, expected); ok {
+		switch op {
+		case config.OP_EQ:
+			return a == e, nil
+		case config.OP_NE:
+			return a != e, nil
+		case config.OP_GT:
+			return a > e, nil
+		case config.OP_GE:
+			return a >= e, nil
+		case config.OP_LT:
+			return a < e, nil
+		case config.OP_LE:
+			return a <= e, nil
+		}
+	}
+
+	switch op {
+	case config.OP_EQ:
+		return actual == expected, nil
+	case config.OP_NE:
+		return actual != expected, nil
+	}
+
+	return false, fmt.Errorf("can not compare '%s' %s '%s' as they are not numbers", actual, op, expected)
+}
+
+// numbers parses both the values as numbers or as sizes
+func numbers(a, b string) (float64, float64, bool) {
+	af, aErr := strconv.ParseFloat(a, 64)
+	bf, bErr := strconv.ParseFloat(b, 64)
+	if aErr == nil && bErr == nil {
+		return af, bf, true
+	}
+
+	as, aErr := util.ParseSize(a)
+	bs, bErr := util.ParseSize(b)
+	if aErr == nil && bErr == nil && a != "" && b != "" {
+		return float64(as), float64(bs), true
+	}
+
+	return 0, 0, false
+}
@@ -0,0 +1,304 @@
+package mtest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/util"
+)
+
+const (
+	// Name of the directory within the state directory that has
+	// the checkpoints of the runs
+	CHECKPOINT_DIR = "checkpoints"
+
+	// File name prefix & suffix of a checkpoint
+	CHECKPOINT_CFG_PREFIX  = "checkpoint_"
+	CHECKPOINT_CFG_POSTFIX = ".json"
+)
+
+// UsecaseCheckpoint is the progress of a use case of a run
+type UsecaseCheckpoint struct {
+	// Name of the use case
+	Usecase string
+
+	// Hash of the definition of the use case's scenario. The progress
+	// is discarded if the scenario has changed since.
+	Hash string
+
+	// The steps of the use case that completed, in their order
+	Steps []string
+
+	// Outputs of the completed steps including the use case's
+	// ${unique} value. These are the names, EBS IDs, backup URLs etc.
+	// of the volumes, snapshots & backups created by the steps.
+	Outputs map[string]interface{}
+
+	// Report of the use case once it has passed
+	Report *Report `json:",omitempty"`
+}
+
+// UnmarshalJSON decodes the numbers of the outputs as json.Number, so
+// that a resolved reference, e.g. ${create.Size}, is as it would have
+// been before the checkpoint.
+func (u *UsecaseCheckpoint) UnmarshalJSON(b []byte) error {
+	type usecaseCheckpoint UsecaseCheckpoint
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	return dec.Decode((*usecaseCheckpoint)(u))
+}
+
+// Checkpoint is the progress of the use cases of a run that is persisted
+// as the use cases run. An interrupted run can be resumed from it, where
+// its passed use cases are not run again & each of its other use cases
+// continues from its last completed step.
+//
+// Checkpoint is safe to use across goroutines.
+type Checkpoint struct {
+	// ID of the run, which is the ID of the run in the history
+	ID string
+
+	// The runner of the run
+	Runner string
+
+	// Config files that were used by the run
+	ConfigFiles []string
+
+	// Start time of the run & the time of the last checkpoint
+	Started time.Time
+	Updated time.Time
+
+	// Progress of the use cases by their names
+	Usecases map[string]*UsecaseCheckpoint
+
+	// Directory where the checkpoint is persisted
+	configPath string
+
+	m sync.Mutex
+}
+
+// ConfigFile provides the file of the checkpoint. This aligns to
+// util.ObjectOperations & hence the checkpoint can be saved & loaded
+// via util.ObjectSave() & util.ObjectLoad().
+func (c *Checkpoint) ConfigFile() (string, error) {
+	if c.ID == "" {
+		return "", fmt.Errorf("BUG: Invalid empty checkpoint id")
+	}
+
+	if c.configPath == "" {
+		return "", fmt.Errorf("BUG: Invalid empty checkpoint config path")
+	}
+
+	return filepath.Join(c.configPath, CHECKPOINT_CFG_PREFIX+c.ID+CHECKPOINT_CFG_POSTFIX), nil
+}
+
+// Report provides the report of the scenario's use case if it has
+// passed & the scenario has not changed since
+func (c *Checkpoint) Report(s *config.Scenario) *Report {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	if u := c.lookup(s); u != nil {
+		return u.Report
+	}
+
+	return nil
+}
+
+// Restore copies the outputs of the completed steps of the scenario
+// into the outputs & provides the no of its steps that can be skipped.
+// Nothing is restored if the scenario has changed since, as the outputs
+// of its completed steps may not hold any more.
+func (c *Checkpoint) Restore(s *config.Scenario, outputs map[string]interface{}) int {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	u := c.lookup(s)
+	if u == nil {
+		return 0
+	}
+
+	done := 0
+	for done < len(s.Steps) && done < len(u.Steps) && s.Steps[done].Name == u.Steps[done] {
+		done++
+	}
+
+	if v, exists := u.Outputs[UNIQUE_REF]; exists {
+		outputs[UNIQUE_REF] = v
+	}
+	for _, step := range u.Steps[:done] {
+		if v, exists := u.Outputs[step]; exists {
+			outputs[step] = v
+		}
+	}
+
+	return done
+}
+
+// StepCompleted records the completion of the step of the scenario's
+// use case along with the outputs of the use case so far & persists the
+// checkpoint
+func (c *Checkpoint) StepCompleted(s *config.Scenario, step string, outputs map[string]interface{}) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	u := c.usecase(s)
+	u.Steps = append(u.Steps, step)
+	u.Outputs = make(map[string]interface{}, len(outputs))
+	for k, v := range outputs {
+		u.Outputs[k] = v
+	}
+
+	return c.save()
+}
+
+// Passed records the report of the scenario's passed use case &
+// persists the checkpoint
+func (c *Checkpoint) Passed(s *config.Scenario, r *Report) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	c.usecase(s).Report = r
+
+	return c.save()
+}
+
+// lookup provides the progress of the scenario's use case, if any. The
+// progress is discarded if the scenario has changed since. The lock
+// must be held.
+func (c *Checkpoint) lookup(s *config.Scenario) *UsecaseCheckpoint {
+	u, exists := c.Usecases[s.Name]
+	if !exists {
+		return nil
+	}
+
+	if u.Hash != scenarioHash(s) {
+		delete(c.Usecases, s.Name)
+		return nil
+	}
+
+	return u
+}
+
+// usecase provides the progress of the scenario's use case, which is
+// started afresh if there is none or if the scenario has changed since.
+// The lock must be held.
+func (c *Checkpoint) usecase(s *config.Scenario) *UsecaseCheckpoint {
+	if c.Usecases == nil {
+		c.Usecases = make(map[string]*UsecaseCheckpoint)
+	}
+
+	u := c.lookup(s)
+	if u == nil {
+		u = &UsecaseCheckpoint{
+			Usecase: s.Name,
+			Hash:    scenarioHash(s),
+		}
+		c.Usecases[s.Name] = u
+	}
+
+	return u
+}
+
+// scenarioHash provides the hash of the definition of the scenario
+func scenarioHash(s *config.Scenario) string {
+	sum := sha256.Sum256([]byte(config.FormatScenarios([]*config.Scenario{s})))
+	return hex.EncodeToString(sum[:])
+}
+
+// save persists the checkpoint. The lock must be held.
+func (c *Checkpoint) save() error {
+	c.Updated = time.Now()
+	return util.ObjectSave(c)
+}
+
+// Checkpoints is the store of the checkpoints of the runs under a state
+// directory. Each checkpoint is written atomically as a JSON file.
+type Checkpoints struct {
+	root string
+}
+
+// NewCheckpoints returns the store of checkpoints within the given state
+// directory. The checkpoints directory is created if it does not exist.
+func NewCheckpoints(stateDir string) (*Checkpoints, error) {
+	if stateDir == "" {
+		return nil, fmt.Errorf("State directory is required for the checkpoints of runs")
+	}
+
+	root := filepath.Join(stateDir, CHECKPOINT_DIR)
+	if err := util.MkdirIfNotExists(root); err != nil {
+		return nil, err
+	}
+
+	return &Checkpoints{
+		root: root,
+	}, nil
+}
+
+// New provides an empty checkpoint of the run. It is persisted as the
+// run progresses or when it is saved.
+func (s *Checkpoints) New(id, runner string, configFiles []string) *Checkpoint {
+	return &Checkpoint{
+		ID:          id,
+		Runner:      runner,
+		ConfigFiles: configFiles,
+		Started:     time.Now(),
+		Usecases:    make(map[string]*UsecaseCheckpoint),
+		configPath:  s.root,
+	}
+}
+
+// Save persists the checkpoint
+func (s *Checkpoints) Save(c *Checkpoint) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return c.save()
+}
+
+// Get loads the checkpoint of the run with the given ID
+func (s *Checkpoints) Get(id string) (*Checkpoint, error) {
+	c := &Checkpoint{
+		ID:         id,
+		configPath: s.root,
+	}
+
+	if err := util.ObjectLoad(c); err != nil {
+		if util.IsNotExistsError(err) {
+			return nil, fmt.Errorf("Checkpoint of run '%s' not found in %s", id, s.root)
+		}
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Delete removes the checkpoint
+func (s *Checkpoints) Delete(c *Checkpoint) error {
+	c.m.Lock()
+	defer c.m.Unlock()
+
+	return util.ObjectDelete(c)
+}
+
+// IDs provides the IDs of the runs that have checkpoints, the oldest
+// first
+func (s *Checkpoints) IDs() ([]string, error) {
+	ids, err := util.ListConfigIDs(s.root, CHECKPOINT_CFG_PREFIX, CHECKPOINT_CFG_POSTFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(ids)
+	return ids, nil
+}
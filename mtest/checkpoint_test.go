@@ -0,0 +1,190 @@
+package mtest
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/config"
+)
+
+func TestCheckpoints_SaveGet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewCheckpoints(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	cp := store.New(NewRunID(time.Now()), "test.runner", []string{"/etc/mtest.hcl"})
+	outputs := map[string]interface{}{
+		UNIQUE_REF: "abc",
+		"create":   map[string]interface{}{"Name": "vol-abc", "Size": int64(4294967296)},
+	}
+	a := &config.Scenario{
+		Name:  "a",
+		Steps: []*config.Step{{Name: "create"}, {Name: "remove"}},
+	}
+	b := &config.Scenario{Name: "b"}
+
+	if err := cp.StepCompleted(a, "create", outputs); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if err := cp.Passed(b, &Report{Runner: "test.runner", Usecase: "b", Status: STATUS_OK, Success: true}); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	ids, err := store.IDs()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if !reflect.DeepEqual(ids, []string{cp.ID}) {
+		t.Fatalf("bad ids: %v", ids)
+	}
+
+	loaded, err := store.Get(cp.ID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if loaded.Runner != "test.runner" || !reflect.DeepEqual(loaded.ConfigFiles, cp.ConfigFiles) {
+		t.Fatalf("bad checkpoint: %#v", loaded)
+	}
+
+	if r := loaded.Report(b); r == nil || !r.Success {
+		t.Fatalf("expected the report of the passed use case, got %#v", r)
+	}
+	if r := loaded.Report(a); r != nil {
+		t.Fatalf("expected no report of the incomplete use case, got %#v", r)
+	}
+
+	// Numbers are resolved as they were before the checkpoint
+	restored := make(map[string]interface{})
+	if done := loaded.Restore(a, restored); done != 1 {
+		t.Fatalf("expected 1 step to be done, got %d", done)
+	}
+
+	size, err := interpolate("${create.Size} ${unique}", restored)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if size != "4294967296 abc" {
+		t.Fatalf("bad restored outputs: %s", size)
+	}
+
+	if err := store.Delete(loaded); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if _, err := store.Get(cp.ID); err == nil {
+		t.Fatalf("expected the checkpoint to be deleted")
+	}
+}
+
+func TestCheckpoint_RestoreChangedScenario(t *testing.T) {
+	scenario := &config.Scenario{
+		Name:  "a",
+		Steps: []*config.Step{{Name: "create"}, {Name: "snap"}},
+	}
+
+	cp := &Checkpoint{}
+	cp.usecase(scenario).Steps = []string{"create"}
+	cp.usecase(scenario).Outputs = map[string]interface{}{"create": "x"}
+
+	outputs := make(map[string]interface{})
+	if done := cp.Restore(scenario, outputs); done != 1 {
+		t.Fatalf("expected 1 step to be done, got %d", done)
+	}
+	if !reflect.DeepEqual(outputs, map[string]interface{}{"create": "x"}) {
+		t.Fatalf("bad outputs: %v", outputs)
+	}
+
+	// The checkpoint of a changed scenario is discarded, even if the
+	// completed steps are unchanged
+	scenario.Steps[1].Options = map[string]string{"Size": "1G"}
+
+	outputs = make(map[string]interface{})
+	if done := cp.Restore(scenario, outputs); done != 0 || len(outputs) != 0 {
+		t.Fatalf("expected nothing to be restored, got %d: %v", done, outputs)
+	}
+	if _, exists := cp.Usecases["a"]; exists {
+		t.Fatalf("expected the checkpoint of the use case to be discarded")
+	}
+}
+
+func TestScenarioExec_RunResume(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	store, err := NewCheckpoints(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	cp := store.New(NewRunID(time.Now()), "test.runner", nil)
+
+	// The snap step fails as per the config of the mock driver
+	failSnap := func(name string) map[string]string {
+		return map[string]string{"fail": "snap"}
+	}
+
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+	exec.Checkpoint = cp
+	exec.DriverConfig = failSnap
+
+	scenario := &config.Scenario{
+		Name: "chain",
+		Steps: []*config.Step{
+			mockStep("create", "create", "vol-${unique}", nil),
+			mockStep("snap", "snap", "snap-of-${create.Name}", nil),
+		},
+	}
+
+	report := exec.Run(context.Background(), scenario)
+	if report.Success {
+		t.Fatalf("expected the run to fail: %#v", report)
+	}
+
+	resumed, err := store.Get(cp.ID)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	unique := resumed.Usecases["chain"].Outputs[UNIQUE_REF]
+
+	// The create step is not run again, else the unique value would
+	// differ
+	exec = NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+	exec.Checkpoint = resumed
+
+	report = exec.Run(context.Background(), scenario)
+	if !report.Success {
+		t.Fatalf("expected the resumed run to pass: %#v", report)
+	}
+
+	outputs := report.Message.(map[string]interface{})
+	snap := outputs["snap"].(map[string]interface{})
+	if outputs[UNIQUE_REF] != unique || snap["Name"] != "snap-of-vol-"+unique.(string) {
+		t.Fatalf("bad outputs: %v", outputs)
+	}
+
+	// A passed use case is not run again
+	exec.DriverConfig = failSnap
+	if report = exec.Run(context.Background(), scenario); !report.Success {
+		t.Fatalf("expected the passed report: %#v", report)
+	}
+
+	// A changed use case is run again
+	scenario.Steps[1] = mockStep("snap", "fail", "snap-of-${create.Name}", nil)
+	if report = exec.Run(context.Background(), scenario); report.Success {
+		t.Fatalf("expected the changed use case to run again: %#v", report)
+	}
+}
@@ -0,0 +1,261 @@
+package mtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+// ReportDiff lines up the reports of the same use case of two runs.
+// Either of the reports is nil if the use case was not run.
+//
+// A use case has several reports in a run if it was repeated, e.g. via
+// -repeat, -soak or -until-failure. Its reports are then aggregated, i.e.
+// the use case failed if any of its reports failed.
+type ReportDiff struct {
+	Runner  string
+	Usecase string
+
+	// Report of the earlier i.e. baseline run. This is the first failed
+	// report of the use case, if any, else its last report.
+	Before *Report
+
+	// Report of the later run, picked as the earlier one is
+	After *Report
+
+	// No of reports of the use case & no of the failed ones in each run
+	BeforeRuns     int
+	BeforeFailures int
+	AfterRuns      int
+	AfterFailures  int
+
+	// Mean duration of the use case's reports in each run
+	BeforeDuration time.Duration
+	AfterDuration  time.Duration
+}
+
+// DurationChange provides the change of the use case's duration as a
+// fraction of its earlier duration, e.g. 0.5 if it took 50% longer.
+func (d *ReportDiff) DurationChange() float64 {
+	if d.Before == nil || d.After == nil || d.BeforeDuration <= 0 {
+		return 0
+	}
+
+	return float64(d.AfterDuration-d.BeforeDuration) / float64(d.BeforeDuration)
+}
+
+// DurationString provides the change of the use case's duration as a
+// percentage along with the durations
+func (d *ReportDiff) DurationString() string {
+	if d.Before == nil || d.After == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%+.0f%% (%v -> %v)", d.DurationChange()*100,
+		d.BeforeDuration.Round(time.Millisecond), d.AfterDuration.Round(time.Millisecond))
+}
+
+// String provides a single line & readable form of the diff
+func (d *ReportDiff) String() string {
+	status := func(r *Report, runs, failures int, duration time.Duration) string {
+		if r == nil {
+			return "-"
+		}
+		if runs > 1 {
+			return fmt.Sprintf("%s (%d of %d runs failed, mean %v)", r.Status, failures, runs, duration)
+		}
+		return fmt.Sprintf("%s (%v)", r.Status, duration)
+	}
+
+	return fmt.Sprintf("%s %s: %s -> %s", d.Runner, d.Usecase,
+		status(d.Before, d.BeforeRuns, d.BeforeFailures, d.BeforeDuration),
+		status(d.After, d.AfterRuns, d.AfterFailures, d.AfterDuration))
+}
+
+// RunDiff is the comparison of the reports of two runs.
+type RunDiff struct {
+	// Use cases that passed earlier but not anymore
+	NewlyFailing []*ReportDiff
+
+	// Use cases that failed earlier but pass now
+	NewlyPassing []*ReportDiff
+
+	// Use cases that were run earlier but not anymore
+	Missing []*ReportDiff
+
+	// Use cases that were not run earlier
+	Added []*ReportDiff
+
+	// Use cases that passed in both the runs, but whose duration
+	// changed by more than the threshold
+	Slower []*ReportDiff
+	Faster []*ReportDiff
+
+	// No of use cases that have no significant change
+	Unchanged int
+}
+
+// HasRegressions indicates if the later run regressed i.e. a use case
+// fails now or is not run anymore
+func (d *RunDiff) HasRegressions() bool {
+	return len(d.NewlyFailing) > 0 || len(d.Missing) > 0
+}
+
+// reportKey lines up the reports of a use case across the runs
+type reportKey struct {
+	runner  string
+	usecase string
+}
+
+// reportRuns aggregates the reports of a use case in a run
+type reportRuns struct {
+	runner  string
+	usecase string
+
+	// First failed report, if any, else the last report
+	report *Report
+
+	runs     int
+	failures int
+	total    time.Duration
+}
+
+func (r *reportRuns) add(rpt *Report) {
+	r.runs++
+	r.total += rpt.Duration
+	if !rpt.Success {
+		r.failures++
+	}
+
+	if r.report == nil || r.report.Success {
+		r.report = rpt
+	}
+}
+
+func (r *reportRuns) mean() time.Duration {
+	if r.runs == 0 {
+		return 0
+	}
+	return r.total / time.Duration(r.runs)
+}
+
+// groupReports aggregates the reports by their Runner & Usecase. The
+// groups are in the order of their first reports.
+func groupReports(reports []*Report) ([]*reportRuns, map[reportKey]*reportRuns) {
+	var groups []*reportRuns
+	byKey := make(map[reportKey]*reportRuns, len(reports))
+
+	for _, r := range reports {
+		if r == nil {
+			continue
+		}
+
+		k := reportKey{r.Runner, r.Usecase}
+		g, ok := byKey[k]
+		if !ok {
+			g = &reportRuns{runner: r.Runner, usecase: r.Usecase}
+			byKey[k] = g
+			groups = append(groups, g)
+		}
+		g.add(r)
+	}
+
+	return groups, byKey
+}
+
+// DiffReports compares the reports of the earlier run with the reports
+// of the later run. Reports are lined up by their Runner & Usecase. The
+// reports of a repeated use case are aggregated, i.e. it failed if any
+// of its reports failed & its durations are compared by their mean.
+//
+// A change in duration is significant if it is more than the threshold,
+// which is a fraction of the earlier duration, e.g. 0.2 for 20%. The
+// durations are not compared if the threshold is not positive.
+func DiffReports(before, after []*Report, threshold float64) *RunDiff {
+	diff := &RunDiff{}
+
+	earlierGroups, earlier := groupReports(before)
+	laterGroups, later := groupReports(after)
+
+	for _, g := range laterGroups {
+		d := &ReportDiff{
+			Runner:        g.runner,
+			Usecase:       g.usecase,
+			After:         g.report,
+			AfterRuns:     g.runs,
+			AfterFailures: g.failures,
+			AfterDuration: g.mean(),
+		}
+
+		if e, ok := earlier[reportKey{g.runner, g.usecase}]; ok {
+			d.Before = e.report
+			d.BeforeRuns = e.runs
+			d.BeforeFailures = e.failures
+			d.BeforeDuration = e.mean()
+		}
+
+		passed := g.failures == 0
+		switch {
+		case d.Before == nil:
+			diff.Added = append(diff.Added, d)
+		case d.BeforeFailures == 0 && !passed:
+			diff.NewlyFailing = append(diff.NewlyFailing, d)
+		case d.BeforeFailures > 0 && passed:
+			diff.NewlyPassing = append(diff.NewlyPassing, d)
+		case passed && threshold > 0 && d.DurationChange() > threshold:
+			diff.Slower = append(diff.Slower, d)
+		case passed && threshold > 0 && d.DurationChange() < -threshold:
+			diff.Faster = append(diff.Faster, d)
+		default:
+			diff.Unchanged++
+		}
+	}
+
+	// Retain the order of the earlier run for the missing ones
+	for _, e := range earlierGroups {
+		if _, ok := later[reportKey{e.runner, e.usecase}]; !ok {
+			diff.Missing = append(diff.Missing, &ReportDiff{
+				Runner:         e.runner,
+				Usecase:        e.usecase,
+				Before:         e.report,
+				BeforeRuns:     e.runs,
+				BeforeFailures: e.failures,
+				BeforeDuration: e.mean(),
+			})
+		}
+	}
+
+	return diff
+}
+
+// LoadReports loads the reports of a run from a results file. The
+// file is either the JSON of the reports or a run persisted in the
+// history.
+func LoadReports(path string) ([]*Report, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	b = bytes.TrimSpace(b)
+	if len(b) == 0 {
+		return nil, fmt.Errorf("Results file %s is empty", path)
+	}
+
+	var reports []*Report
+	if b[0] == '[' {
+		err = json.Unmarshal(b, &reports)
+	} else {
+		var run RunRecord
+		err = json.Unmarshal(b, &run)
+		reports = run.Reports
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("Error decoding results file %s: %s", path, err)
+	}
+
+	return reports, nil
+}
@@ -0,0 +1,156 @@
+package mtest
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/util"
+)
+
+func TestDiffReports(t *testing.T) {
+	before := []*Report{
+		{Runner: "r", Usecase: "regressed", Success: true, Status: STATUS_OK},
+		{Runner: "r", Usecase: "fixed", Status: STATUS_FAILED},
+		{Runner: "r", Usecase: "removed", Success: true, Status: STATUS_OK},
+		{Runner: "r", Usecase: "slower", Success: true, Status: STATUS_OK, Duration: 10 * time.Second},
+		{Runner: "r", Usecase: "faster", Success: true, Status: STATUS_OK, Duration: 10 * time.Second},
+		{Runner: "r", Usecase: "same", Success: true, Status: STATUS_OK, Duration: 10 * time.Second},
+	}
+
+	after := []*Report{
+		{Runner: "r", Usecase: "regressed", Status: STATUS_TIMEOUT},
+		{Runner: "r", Usecase: "fixed", Success: true, Status: STATUS_OK},
+		{Runner: "r", Usecase: "slower", Success: true, Status: STATUS_OK, Duration: 15 * time.Second},
+		{Runner: "r", Usecase: "faster", Success: true, Status: STATUS_OK, Duration: 5 * time.Second},
+		{Runner: "r", Usecase: "same", Success: true, Status: STATUS_OK, Duration: 11 * time.Second},
+		{Runner: "r", Usecase: "new", Success: true, Status: STATUS_OK},
+	}
+
+	diff := DiffReports(before, after, 0.2)
+
+	check := func(name string, diffs []*ReportDiff, usecase string) {
+		if len(diffs) != 1 || diffs[0].Usecase != usecase {
+			t.Fatalf("%s: expected %s, got %v", name, usecase, diffs)
+		}
+	}
+
+	check("newly failing", diff.NewlyFailing, "regressed")
+	check("newly passing", diff.NewlyPassing, "fixed")
+	check("missing", diff.Missing, "removed")
+	check("added", diff.Added, "new")
+	check("slower", diff.Slower, "slower")
+	check("faster", diff.Faster, "faster")
+
+	if diff.Unchanged != 1 {
+		t.Fatalf("expected 1 unchanged, got %d", diff.Unchanged)
+	}
+
+	if !diff.HasRegressions() {
+		t.Fatalf("expected regressions")
+	}
+
+	if DiffReports(before, before, 0.2).HasRegressions() {
+		t.Fatalf("expected no regressions against itself")
+	}
+}
+
+func TestDiffReports_Repeated(t *testing.T) {
+	rpt := func(usecase string, success bool, d time.Duration) *Report {
+		status := STATUS_OK
+		if !success {
+			status = STATUS_FAILED
+		}
+		return &Report{Runner: "r", Usecase: usecase, Success: success, Status: status, Duration: d}
+	}
+
+	// Reports of the use cases as repeated via -repeat=3
+	before := []*Report{
+		rpt("flaky", true, time.Second), rpt("stable", true, 10*time.Second), rpt("broken", false, time.Second),
+		rpt("flaky", true, time.Second), rpt("stable", true, 10*time.Second), rpt("broken", true, time.Second),
+		rpt("flaky", true, time.Second), rpt("stable", true, 10*time.Second), rpt("broken", false, time.Second),
+	}
+	after := []*Report{
+		rpt("flaky", true, time.Second), rpt("stable", true, 10*time.Second), rpt("broken", false, time.Second),
+		rpt("flaky", false, time.Second), rpt("stable", true, 10*time.Second), rpt("broken", false, time.Second),
+		rpt("flaky", true, time.Second), rpt("stable", true, 16*time.Second), rpt("broken", false, time.Second),
+	}
+
+	diff := DiffReports(before, after, 0.1)
+
+	// A single failure of a repeated use case is a failure of the use
+	// case, even if its later reports passed
+	if len(diff.NewlyFailing) != 1 || diff.NewlyFailing[0].Usecase != "flaky" {
+		t.Fatalf("expected flaky to be newly failing, got %v", diff.NewlyFailing)
+	}
+
+	d := diff.NewlyFailing[0]
+	if d.BeforeRuns != 3 || d.BeforeFailures != 0 || d.AfterRuns != 3 || d.AfterFailures != 1 || d.After.Success {
+		t.Fatalf("bad aggregate: %s", d)
+	}
+
+	// The durations are compared by their mean, i.e. 10s -> 12s
+	if len(diff.Slower) != 1 || diff.Slower[0].Usecase != "stable" || diff.Slower[0].AfterDuration != 12*time.Second {
+		t.Fatalf("expected stable to be slower, got %v", diff.Slower)
+	}
+
+	// broken failed in both the runs
+	if len(diff.NewlyPassing) != 0 || len(diff.Added) != 0 || len(diff.Missing) != 0 || diff.Unchanged != 1 {
+		t.Fatalf("bad diff: %#v", diff)
+	}
+
+	if DiffReports(before, before, 0.1).HasRegressions() {
+		t.Fatalf("expected no regressions against itself")
+	}
+}
+
+func TestLoadReports(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	reports := []*Report{
+		{Runner: "r", Usecase: "a", Success: true, Status: STATUS_OK},
+	}
+
+	file := filepath.Join(dir, "results.json")
+	if err := util.SaveConfig(file, reports); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	loaded, err := LoadReports(file)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(loaded) != 1 || loaded[0].Usecase != "a" {
+		t.Fatalf("bad reports: %#v", loaded)
+	}
+
+	// A run persisted in the history is loaded too
+	h, err := NewHistory(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	run := NewRunRecord("r", time.Now(), reports)
+	if err := h.Save(run); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	runFile, err := run.ConfigFile()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	loaded, err = LoadReports(runFile)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(loaded) != 1 || loaded[0].Usecase != "a" {
+		t.Fatalf("bad reports: %#v", loaded)
+	}
+}
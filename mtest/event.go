@@ -0,0 +1,302 @@
+package mtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+	"github.com/openebs/mtest/driver/ebs"
+)
+
+// EventType is the type of an event of a run
+type EventType string
+
+const (
+	// Start & end of a run
+	EVENT_RUN_STARTED  EventType = "run-started"
+	EVENT_RUN_FINISHED EventType = "run-finished"
+
+	// Start & end of a use case
+	EVENT_USECASE_STARTED  EventType = "usecase-started"
+	EVENT_USECASE_FINISHED EventType = "usecase-finished"
+
+	// Start & end of a step of a use case
+	EVENT_STEP_STARTED  EventType = "step-started"
+	EVENT_STEP_FINISHED EventType = "step-finished"
+
+	// A volume, snapshot or backup was created or deleted by a step
+	EVENT_RESOURCE_CREATED EventType = "resource-created"
+	EVENT_RESOURCE_DELETED EventType = "resource-deleted"
+)
+
+const (
+	// Kinds of the resources of the events
+	RESOURCE_VOLUME   = "volume"
+	RESOURCE_SNAPSHOT = "snapshot"
+	RESOURCE_BACKUP   = "backup"
+
+	// No of the last events that are sent to a handler when it is
+	// registered
+	DEFAULT_EVENT_BUFFER = 512
+)
+
+// Resource is a volume, snapshot or backup that was created or deleted
+type Resource struct {
+	Kind string
+	Name string `json:",omitempty"`
+
+	// ID of the resource, e.g. the URL of a backup
+	ID string `json:",omitempty"`
+}
+
+// Event is a typed event of the progress of a run. The fields that do
+// not apply to the type of the event are left empty.
+type Event struct {
+	Type EventType
+	Time time.Time
+
+	// The runner & the worker, if any, that published the event
+	Runner string
+	Worker string `json:",omitempty"`
+
+	// The use case & the step along with the step's executor
+	Usecase  string `json:",omitempty"`
+	Step     string `json:",omitempty"`
+	Executor string `json:",omitempty"`
+
+	// Outcome of a finished run, use case or step
+	Status   string        `json:",omitempty"`
+	Error    string        `json:",omitempty"`
+	Attempts int           `json:",omitempty"`
+	Duration time.Duration `json:",omitempty"`
+
+	// The resource that was created or deleted
+	Resource *Resource `json:",omitempty"`
+}
+
+// EventHandler interface provides a blueprint for clients that want
+// to subscribe to the events of runs,
+//
+//	e.g.
+//	  to render the progress on a terminal or to stream the events
+//	  to a file or over HTTP
+type EventHandler interface {
+	HandleEvent(*Event)
+}
+
+// EventBroker publishes the events to its handlers. It maintains a
+// circular buffer of the events, so that a handler that is registered
+// later gets the last events too.
+//
+// NOTE: The handlers are invoked synchronously & one at a time, hence
+// they should not block.
+type EventBroker struct {
+	sync.Mutex
+	events   []*Event
+	index    int
+	handlers map[EventHandler]struct{}
+}
+
+// NewEventBroker creates an EventBroker with the given buffer capacity
+func NewEventBroker(bufCap int) *EventBroker {
+	return &EventBroker{
+		events:   make([]*Event, bufCap),
+		handlers: make(map[EventHandler]struct{}),
+	}
+}
+
+// RegisterHandler adds a handler to receive the events, and sends the
+// **last buffered events** to the handler.
+func (b *EventBroker) RegisterHandler(h EventHandler) {
+	b.Lock()
+	defer b.Unlock()
+
+	// Do nothing if already registered
+	if _, ok := b.handlers[h]; ok {
+		return
+	}
+
+	b.handlers[h] = struct{}{}
+
+	// Send the old events
+	if b.events[b.index] != nil {
+		for i := b.index; i < len(b.events); i++ {
+			h.HandleEvent(b.events[i])
+		}
+	}
+	for i := 0; i < b.index; i++ {
+		h.HandleEvent(b.events[i])
+	}
+}
+
+// DeregisterHandler removes a handler and prevents more invocations
+func (b *EventBroker) DeregisterHandler(h EventHandler) {
+	b.Lock()
+	defer b.Unlock()
+
+	delete(b.handlers, h)
+}
+
+// Publish sends the event to the handlers. The time of the event is
+// set if it is not set. Publish on a nil broker does nothing, so that
+// the runners need not check if the events are being published.
+func (b *EventBroker) Publish(ev *Event) {
+	if b == nil {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	b.events[b.index] = ev
+	b.index = (b.index + 1) % len(b.events)
+
+	for h := range b.handlers {
+		h.HandleEvent(ev)
+	}
+}
+
+// `EventingRunner` is a Runner that publishes the events of its use
+// cases & their steps as they progress.
+type EventingRunner interface {
+	Runner
+
+	// SetEventBroker sets the broker of the events
+	SetEventBroker(b *EventBroker)
+}
+
+// JSONEventHandler writes each event as a line of JSON
+type JSONEventHandler struct {
+	enc *json.Encoder
+}
+
+// NewJSONEventHandler returns a handler that writes the events to the
+// writer as newline delimited JSON
+func NewJSONEventHandler(w io.Writer) *JSONEventHandler {
+	return &JSONEventHandler{
+		enc: json.NewEncoder(w),
+	}
+}
+
+// HandleEvent writes the event. A failure to write is ignored, as it
+// should not fail the run.
+func (h *JSONEventHandler) HandleEvent(ev *Event) {
+	h.enc.Encode(ev)
+}
+
+// String provides a single line & readable form of the event
+func (ev *Event) String() string {
+	s := string(ev.Type)
+	if ev.Usecase != "" {
+		s += " " + ev.Usecase
+	}
+	if ev.Step != "" {
+		s += fmt.Sprintf(": step '%s'", ev.Step)
+	}
+	if ev.Resource != nil {
+		s += fmt.Sprintf(": %s %s", ev.Resource.Kind, ev.Resource.Name)
+	}
+	if ev.Status != "" {
+		s += fmt.Sprintf(" [%s] (%v)", ev.Status, ev.Duration)
+	}
+	if ev.Error != "" {
+		s += ": " + ev.Error
+	}
+
+	return s
+}
+
+// runFinishedEvent provides the event of the end of the run of the
+// runner, which is OK only if all its use cases passed
+func runFinishedEvent(runner string, reports []*Report, err error, d time.Duration) *Event {
+	ev := &Event{
+		Type:     EVENT_RUN_FINISHED,
+		Runner:   runner,
+		Status:   STATUS_OK,
+		Duration: d,
+	}
+
+	if err != nil {
+		ev.Status = STATUS_FAILED
+		ev.Error = err.Error()
+		return ev
+	}
+
+	for _, r := range reports {
+		if r != nil && !r.Success {
+			ev.Status = STATUS_FAILED
+			break
+		}
+	}
+
+	return ev
+}
+
+// usecaseFinishedEvent provides the event of the end of the use case of
+// the report
+func usecaseFinishedEvent(report *Report) *Event {
+	ev := &Event{
+		Type:     EVENT_USECASE_FINISHED,
+		Runner:   report.Runner,
+		Usecase:  report.Usecase,
+		Status:   report.Status,
+		Duration: report.Duration,
+	}
+	if !report.Success && report.Message != nil {
+		ev.Error = fmt.Sprint(report.Message)
+	}
+
+	return ev
+}
+
+// resourceExecutor is an executor that creates or deletes a resource
+type resourceExecutor struct {
+	kind  string
+	event EventType
+}
+
+// resourceExecutors are the executors of the EBS driver that create or
+// delete the volumes, snapshots & backups
+var resourceExecutors = map[string]resourceExecutor{
+	ebs.EBS_VOLUME_CREATE_EXEC: {RESOURCE_VOLUME, EVENT_RESOURCE_CREATED},
+	ebs.EBS_VOLUME_REMOVE_EXEC: {RESOURCE_VOLUME, EVENT_RESOURCE_DELETED},
+	ebs.EBS_SNAP_CREATE_EXEC:   {RESOURCE_SNAPSHOT, EVENT_RESOURCE_CREATED},
+	ebs.EBS_SNAP_REMOVE_EXEC:   {RESOURCE_SNAPSHOT, EVENT_RESOURCE_DELETED},
+	ebs.EBS_BACKUP_CREATE_EXEC: {RESOURCE_BACKUP, EVENT_RESOURCE_CREATED},
+	ebs.EBS_BACKUP_REMOVE_EXEC: {RESOURCE_BACKUP, EVENT_RESOURCE_DELETED},
+}
+
+// stepResource provides the resource that was created or deleted by the
+// successful execution of the step, if any, along with the type of its
+// event
+func stepResource(step *config.Step, req driver.Request, resp *driver.Response) (*Resource, EventType, bool) {
+	re, ok := resourceExecutors[step.Executor]
+	if !ok {
+		return nil, "", false
+	}
+
+	res := &Resource{
+		Kind: re.kind,
+		Name: req.Name,
+	}
+
+	// A backup is identified by its URL
+	if re.kind == RESOURCE_BACKUP {
+		res.ID = req.Options[ebs.OPT_BACKUP_URL]
+		if resp != nil {
+			if url, exists := resp.Values[ebs.OPT_BACKUP_URL]; exists {
+				res.ID = fmt.Sprint(url)
+			}
+		}
+	}
+
+	return res, re.event, true
+}
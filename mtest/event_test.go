@@ -0,0 +1,143 @@
+package mtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver/ebs"
+)
+
+// recordingHandler records the events it handles
+type recordingHandler struct {
+	events []*Event
+}
+
+func (h *recordingHandler) HandleEvent(ev *Event) {
+	h.events = append(h.events, ev)
+}
+
+func (h *recordingHandler) types() []EventType {
+	var types []EventType
+	for _, ev := range h.events {
+		types = append(types, ev.Type)
+	}
+	return types
+}
+
+func TestEventBroker(t *testing.T) {
+	b := NewEventBroker(2)
+
+	// Publish on a nil broker is a no-op
+	var nb *EventBroker
+	nb.Publish(&Event{Type: EVENT_RUN_STARTED})
+
+	b.Publish(&Event{Type: EVENT_RUN_STARTED})
+	b.Publish(&Event{Type: EVENT_USECASE_STARTED})
+	b.Publish(&Event{Type: EVENT_USECASE_FINISHED})
+
+	// The last buffered events are sent on registration
+	h := &recordingHandler{}
+	b.RegisterHandler(h)
+	b.RegisterHandler(h)
+
+	expected := []EventType{EVENT_USECASE_STARTED, EVENT_USECASE_FINISHED}
+	if !reflect.DeepEqual(h.types(), expected) {
+		t.Fatalf("bad events:\nwant: %v\n got: %v", expected, h.types())
+	}
+
+	b.Publish(&Event{Type: EVENT_RUN_FINISHED})
+	if len(h.events) != 3 || h.events[2].Time.IsZero() {
+		t.Fatalf("bad events: %v", h.types())
+	}
+
+	b.DeregisterHandler(h)
+	b.Publish(&Event{Type: EVENT_RUN_STARTED})
+	if len(h.events) != 3 {
+		t.Fatalf("expected no events after deregistration, got %v", h.types())
+	}
+}
+
+func TestScenarioExec_RunEvents(t *testing.T) {
+	h := &recordingHandler{}
+	b := NewEventBroker(DEFAULT_EVENT_BUFFER)
+	b.RegisterHandler(h)
+
+	e := NewScenarioExec("test", log.New(ioutil.Discard, "", 0))
+	e.Worker = "worker-0"
+	e.Events = b
+
+	s := &config.Scenario{
+		Name: "events",
+		Steps: []*config.Step{
+			mockStep("create", ebs.EBS_VOLUME_CREATE_EXEC, "vol1", nil),
+			mockStep("backup", ebs.EBS_BACKUP_CREATE_EXEC, "b1", map[string]string{ebs.OPT_BACKUP_URL: "ebs://r/snap-1"}),
+			mockStep("fail", "fail", "vol1", nil),
+		},
+	}
+
+	if report := e.Run(context.Background(), s); report.Success {
+		t.Fatalf("expected failure, got %s", report)
+	}
+
+	expected := []EventType{
+		EVENT_USECASE_STARTED,
+		EVENT_STEP_STARTED, EVENT_RESOURCE_CREATED, EVENT_STEP_FINISHED,
+		EVENT_STEP_STARTED, EVENT_RESOURCE_CREATED, EVENT_STEP_FINISHED,
+		EVENT_STEP_STARTED, EVENT_STEP_FINISHED,
+		EVENT_USECASE_FINISHED,
+	}
+	if !reflect.DeepEqual(h.types(), expected) {
+		t.Fatalf("bad events:\nwant: %v\n got: %v", expected, h.types())
+	}
+
+	for _, ev := range h.events {
+		if ev.Runner != "test" || ev.Worker != "worker-0" || ev.Usecase != "events" {
+			t.Fatalf("bad event: %#v", ev)
+		}
+	}
+
+	if res := h.events[2].Resource; res == nil || res.Kind != RESOURCE_VOLUME || res.Name != "vol1" {
+		t.Fatalf("bad volume: %#v", res)
+	}
+	if res := h.events[5].Resource; res == nil || res.Kind != RESOURCE_BACKUP || res.ID != "ebs://r/snap-1" {
+		t.Fatalf("bad backup: %#v", res)
+	}
+
+	if ev := h.events[3]; ev.Step != "create" || ev.Status != STATUS_OK || ev.Attempts != 1 {
+		t.Fatalf("bad step event: %#v", ev)
+	}
+	if ev := h.events[8]; ev.Step != "fail" || ev.Status != STATUS_FAILED || !strings.Contains(ev.Error, "mock failure") {
+		t.Fatalf("bad step event: %#v", ev)
+	}
+	if ev := h.events[9]; ev.Status != STATUS_FAILED || ev.Error == "" {
+		t.Fatalf("bad use case event: %#v", ev)
+	}
+}
+
+func TestJSONEventHandler(t *testing.T) {
+	var buf bytes.Buffer
+	h := NewJSONEventHandler(&buf)
+
+	h.HandleEvent(&Event{Type: EVENT_RUN_STARTED, Runner: "test"})
+	h.HandleEvent(&Event{Type: EVENT_RESOURCE_DELETED, Resource: &Resource{Kind: RESOURCE_VOLUME, Name: "vol1"}})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %q", buf.String())
+	}
+
+	var ev Event
+	if err := json.Unmarshal([]byte(lines[1]), &ev); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if ev.Type != EVENT_RESOURCE_DELETED || ev.Resource == nil || ev.Resource.Name != "vol1" {
+		t.Fatalf("bad event: %#v", ev)
+	}
+}
@@ -0,0 +1,151 @@
+package mtest
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RunStatus is the status of a run that was started asynchronously
+type RunStatus struct {
+	// The runner of the run
+	Runner string
+
+	// Running is true till the runner returns
+	Running bool
+
+	// Cancelled is true if the run was aborted, either by a cancel or
+	// by its context
+	Cancelled bool
+
+	// Start & end times of the run
+	Started time.Time
+	Ended   time.Time
+
+	// Error, if any, due to which the runner failed
+	Error string
+
+	// Summary of the reports of the use cases that completed so far
+	Summary *Summary
+}
+
+// RunHandle is the handle of a run that was started asynchronously,
+// via which the run can be waited for, queried & cancelled. The reports
+// of the use cases are available as soon as they complete if the runner
+// is a StreamingRunner, else after the run.
+//
+// RunHandle is safe to use across multiple goroutines.
+type RunHandle struct {
+	runner  string
+	started time.Time
+
+	// cancel aborts the run
+	cancel context.CancelFunc
+
+	// done is closed once the run returns
+	done chan struct{}
+
+	m         sync.Mutex
+	reports   []*Report
+	ended     time.Time
+	err       error
+	cancelled bool
+}
+
+// newRunHandle returns the handle of a run of the runner that is aborted
+// via the cancel function
+func newRunHandle(runner string, cancel context.CancelFunc) *RunHandle {
+	return &RunHandle{
+		runner:  runner,
+		started: time.Now(),
+		cancel:  cancel,
+		done:    make(chan struct{}),
+	}
+}
+
+// Done returns a channel that is closed once the run returns
+func (h *RunHandle) Done() <-chan struct{} {
+	return h.done
+}
+
+// Wait waits for the run to return & provides its reports, or the
+// error if the runner failed
+func (h *RunHandle) Wait() ([]*Report, error) {
+	<-h.done
+
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	if h.err != nil {
+		return nil, h.err
+	}
+	return h.reports, nil
+}
+
+// Reports provides the reports of the use cases that completed so far
+func (h *RunHandle) Reports() []*Report {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	return append([]*Report(nil), h.reports...)
+}
+
+// Status provides the status of the run
+func (h *RunHandle) Status() *RunStatus {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	s := &RunStatus{
+		Runner:    h.runner,
+		Running:   h.ended.IsZero(),
+		Cancelled: h.cancelled,
+		Started:   h.started,
+		Ended:     h.ended,
+		Summary:   Summarize(h.reports),
+	}
+	if h.err != nil {
+		s.Error = h.err.Error()
+	}
+
+	return s
+}
+
+// Cancel aborts the run, without waiting for the run to return. The
+// run returns with the reports of its aborted use cases.
+func (h *RunHandle) Cancel() {
+	h.m.Lock()
+	if h.ended.IsZero() {
+		h.cancelled = true
+	}
+	h.m.Unlock()
+
+	h.cancel()
+}
+
+// add adds the report of a use case that completed
+func (h *RunHandle) add(r *Report) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.reports = append(h.reports, r)
+}
+
+// finish records the outcome of the run once the runner returns. The
+// reports of the runner replace the ones that were added during the
+// run, unless the runner failed.
+func (h *RunHandle) finish(reports []*Report, err, ctxErr error) {
+	h.m.Lock()
+	defer h.m.Unlock()
+
+	h.ended = time.Now()
+	if err != nil {
+		h.err = err
+	} else {
+		h.reports = reports
+	}
+	if ctxErr != nil {
+		h.cancelled = true
+	}
+
+	close(h.done)
+}
@@ -0,0 +1,128 @@
+package mtest
+
+import (
+	"io/ioutil"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/config"
+)
+
+func TestMtest_StartAsync(t *testing.T) {
+	maker := &MtestMake{
+		runner: &MserverRunner{
+			logger: log.New(ioutil.Discard, "", 0),
+			scenarios: []*config.Scenario{
+				{
+					Name:  "ok",
+					Steps: []*config.Step{mockStep("create", "create", "vol1", nil)},
+				},
+				{
+					Name:  "stuck",
+					Steps: []*config.Step{mockStep("stuck", "block", "vol2", nil)},
+				},
+			},
+		},
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	h, err := mt.StartAsync()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// The report of the first use case is available while the second
+	// is in progress
+	deadline := time.Now().Add(5 * time.Second)
+	for len(h.Reports()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("the first use case was not reported")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	// The run is queried without waiting for it
+	if !mt.IsRunning() {
+		t.Fatalf("expected mtest to be running")
+	}
+	if s := h.Status(); !s.Running || s.Cancelled || s.Runner != MTEST_MSERVER_RUNNER_NAME || s.Summary.Passed != 1 {
+		t.Fatalf("bad status: %#v: %#v", s, s.Summary)
+	}
+	if _, err := mt.StartAsync(); err == nil {
+		t.Fatalf("expected an error on a start during the run")
+	}
+
+	h.Cancel()
+
+	reports, err := h.Wait()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(reports) != 2 || !reports[0].Success || reports[1].Status != STATUS_CANCELLED {
+		t.Fatalf("bad reports: %#v", reports)
+	}
+
+	s := h.Status()
+	if s.Running || !s.Cancelled || s.Ended.IsZero() || s.Summary.Cancelled != 1 {
+		t.Fatalf("bad status: %#v: %#v", s, s.Summary)
+	}
+
+	if mt.IsRunning() {
+		t.Fatalf("expected mtest to not be running")
+	}
+}
+
+func TestMtest_StopDoesNotBlock(t *testing.T) {
+	maker := &MtestMake{
+		runner: &MserverRunner{
+			logger: log.New(ioutil.Discard, "", 0),
+			scenarios: []*config.Scenario{
+				{
+					Name:  "stuck",
+					Steps: []*config.Step{mockStep("stuck", "block", "vol1", nil)},
+				},
+			},
+		},
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	h, err := mt.StartAsync()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// IsRunning & Stop are invoked while the run is in progress
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for i := 0; i < 10; i++ {
+			mt.IsRunning()
+		}
+		mt.Stop()
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Stop blocked on the run")
+	}
+
+	select {
+	case <-h.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Stop did not abort the run")
+	}
+
+	if s := h.Status(); !s.Cancelled || s.Summary.Cancelled != 1 {
+		t.Fatalf("bad status: %#v: %#v", s, s.Summary)
+	}
+}
@@ -0,0 +1,214 @@
+package mtest
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openebs/mtest/util"
+)
+
+const (
+	// Name of the directory within the state directory that has
+	// the persisted runs
+	HISTORY_DIR = "history"
+
+	// File name prefix & suffix of a persisted run
+	RUN_CFG_PREFIX  = "run_"
+	RUN_CFG_POSTFIX = ".json"
+
+	// Layout of the timestamp part of a run's ID. This keeps the
+	// IDs in the order of the runs when sorted.
+	RUN_ID_TIME_LAYOUT = "20060102-150405"
+)
+
+// RunRecord is a run of a runner that is persisted in the history.
+type RunRecord struct {
+	// ID of the run, which starts with the run's start time
+	ID string
+
+	// The runner that was run
+	Runner string
+
+	// Config files that were used by the run
+	ConfigFiles []string
+
+	// Start & end times of the run
+	Started time.Time
+	Ended   time.Time
+
+	// Time taken by the run
+	Duration time.Duration
+
+	// Summary of the reports
+	Summary *Summary
+
+	// Reports of the run's use cases
+	Reports []*Report
+
+	// Version & revision of mtest that did the run
+	Version  string
+	Revision string
+
+	// Directory where the run is persisted
+	configPath string
+}
+
+// NewRunRecord returns a new RunRecord with a new ID for the reports
+// of a run that started at the given time.
+func NewRunRecord(runner string, started time.Time, reports []*Report) *RunRecord {
+	return &RunRecord{
+		ID:       NewRunID(started),
+		Runner:   runner,
+		Started:  started,
+		Ended:    time.Now(),
+		Duration: time.Since(started),
+		Summary:  Summarize(reports),
+		Reports:  reports,
+	}
+}
+
+// NewRunID provides a new ID for a run that started at the given time
+func NewRunID(started time.Time) string {
+	suffix := strings.Replace(util.NewUUID(), "-", "", -1)
+
+	return started.UTC().Format(RUN_ID_TIME_LAYOUT) + "-" + suffix[:8]
+}
+
+// ConfigFile provides the file of the run. This aligns to
+// util.ObjectOperations & hence the run can be saved & loaded
+// via util.ObjectSave() & util.ObjectLoad().
+func (r *RunRecord) ConfigFile() (string, error) {
+	if r.ID == "" {
+		return "", fmt.Errorf("BUG: Invalid empty run id")
+	}
+
+	if r.configPath == "" {
+		return "", fmt.Errorf("BUG: Invalid empty run config path")
+	}
+
+	return filepath.Join(r.configPath, RUN_CFG_PREFIX+r.ID+RUN_CFG_POSTFIX), nil
+}
+
+// History is the store of the runs that are persisted under a state
+// directory. Each run is written atomically as a JSON file.
+type History struct {
+	root string
+}
+
+// NewHistory returns the history of runs within the given state
+// directory. The history directory is created if it does not exist.
+func NewHistory(stateDir string) (*History, error) {
+	if stateDir == "" {
+		return nil, fmt.Errorf("State directory is required for the history of runs")
+	}
+
+	root := filepath.Join(stateDir, HISTORY_DIR)
+	if err := util.MkdirIfNotExists(root); err != nil {
+		return nil, err
+	}
+
+	return &History{
+		root: root,
+	}, nil
+}
+
+// Dir provides the directory that has the persisted runs
+func (h *History) Dir() string {
+	return h.root
+}
+
+// Save persists the run
+func (h *History) Save(r *RunRecord) error {
+	r.configPath = h.root
+	return util.ObjectSave(r)
+}
+
+// Get loads the run with the given ID
+func (h *History) Get(id string) (*RunRecord, error) {
+	r := &RunRecord{
+		ID:         id,
+		configPath: h.root,
+	}
+
+	if err := util.ObjectLoad(r); err != nil {
+		if util.IsNotExistsError(err) {
+			return nil, fmt.Errorf("Run '%s' not found in %s", id, h.root)
+		}
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// IDs provides the IDs of the persisted runs, the oldest first
+func (h *History) IDs() ([]string, error) {
+	ids, err := util.ListConfigIDs(h.root, RUN_CFG_PREFIX, RUN_CFG_POSTFIX)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// List loads all the persisted runs, the oldest first
+func (h *History) List() ([]*RunRecord, error) {
+	ids, err := h.IDs()
+	if err != nil {
+		return nil, err
+	}
+
+	runs := make([]*RunRecord, 0, len(ids))
+	for _, id := range ids {
+		r, err := h.Get(id)
+		if err != nil {
+			return nil, err
+		}
+		runs = append(runs, r)
+	}
+
+	return runs, nil
+}
+
+// Latest loads the most recent run. Nil is returned if there are
+// no persisted runs.
+func (h *History) Latest() (*RunRecord, error) {
+	ids, err := h.IDs()
+	if err != nil || len(ids) == 0 {
+		return nil, err
+	}
+
+	return h.Get(ids[len(ids)-1])
+}
+
+// Prune removes the runs that started before the given time while
+// retaining the latest keep no of runs irrespective of their age.
+// A zero before time prunes by count only. The IDs of the removed
+// runs are returned.
+func (h *History) Prune(keep int, before time.Time) ([]string, error) {
+	runs, err := h.List()
+	if err != nil {
+		return nil, err
+	}
+
+	var pruned []string
+	for i, r := range runs {
+		if len(runs)-i <= keep {
+			break
+		}
+
+		if !before.IsZero() && !r.Started.Before(before) {
+			continue
+		}
+
+		if err := util.ObjectDelete(r); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, r.ID)
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,121 @@
+package mtest
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistory_SaveGetList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	h, err := NewHistory(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	start := time.Now().Add(-time.Hour)
+	var ids []string
+	for i := 0; i < 3; i++ {
+		r := NewRunRecord("test.runner", start.Add(time.Duration(i)*time.Minute), []*Report{
+			{Runner: "test.runner", Usecase: "a", Status: STATUS_OK, Success: true},
+		})
+		r.ConfigFiles = []string{"/etc/mtest.hcl"}
+
+		if err := h.Save(r); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+		ids = append(ids, r.ID)
+	}
+
+	runs, err := h.List()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var listed []string
+	for _, r := range runs {
+		listed = append(listed, r.ID)
+	}
+	if !reflect.DeepEqual(listed, ids) {
+		t.Fatalf("bad order:\nwant: %v\n got: %v", ids, listed)
+	}
+
+	run, err := h.Get(ids[1])
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if run.Runner != "test.runner" || len(run.Reports) != 1 || run.Reports[0].Usecase != "a" {
+		t.Fatalf("bad run: %#v", run)
+	}
+
+	if run.Summary == nil || !run.Summary.Success {
+		t.Fatalf("bad summary: %#v", run.Summary)
+	}
+
+	latest, err := h.Latest()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if latest.ID != ids[2] {
+		t.Fatalf("bad latest: %s", latest.ID)
+	}
+
+	if _, err := h.Get("20000101-000000-deadbeef"); err == nil {
+		t.Fatalf("expected error, got nothing")
+	}
+}
+
+func TestHistory_Prune(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mtest")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	h, err := NewHistory(dir)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	now := time.Now()
+	ages := []time.Duration{72 * time.Hour, 48 * time.Hour, 24 * time.Hour, time.Hour}
+	for _, age := range ages {
+		if err := h.Save(NewRunRecord("test.runner", now.Add(-age), nil)); err != nil {
+			t.Fatalf("err: %s", err)
+		}
+	}
+
+	// Only the runs older than 36h are pruned
+	pruned, err := h.Prune(0, now.Add(-36*time.Hour))
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(pruned) != 2 {
+		t.Fatalf("expected 2 pruned runs, got %v", pruned)
+	}
+
+	// The latest run is retained
+	pruned, err = h.Prune(1, time.Time{})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(pruned) != 1 {
+		t.Fatalf("expected 1 pruned run, got %v", pruned)
+	}
+
+	ids, err := h.IDs()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	if len(ids) != 1 {
+		t.Fatalf("expected 1 run, got %v", ids)
+	}
+}
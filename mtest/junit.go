@@ -0,0 +1,152 @@
+package mtest
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// junitTestSuites is the root element of a JUnit XML report
+type junitTestSuites struct {
+	XMLName xml.Name          `xml:"testsuites"`
+	Suites  []*junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite represents the use cases of a single runner
+type junitTestSuite struct {
+	Name      string           `xml:"name,attr"`
+	Tests     int              `xml:"tests,attr"`
+	Failures  int              `xml:"failures,attr"`
+	Time      string           `xml:"time,attr"`
+	TestCases []*junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase represents a single use case
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	Classname string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+// junitFailure represents the failure of a use case
+type junitFailure struct {
+	Message  string `xml:"message,attr"`
+	Type     string `xml:"type,attr"`
+	Contents string `xml:",chardata"`
+}
+
+// WriteJUnit writes the reports as JUnit XML.
+//
+// Each runner is written as a testsuite & each of its use cases as a
+// testcase. A use case that did not succeed has a failure whose type
+// is the report's status. The failure's message is the failed step &
+// its error, while its contents are the report's message in full.
+func WriteJUnit(w io.Writer, reports []*Report) error {
+	suites := &junitTestSuites{}
+
+	// Suites are placed in the order their runners are seen
+	byRunner := make(map[string]*junitTestSuite)
+	durations := make(map[string]time.Duration)
+
+	for _, r := range reports {
+		if r == nil {
+			continue
+		}
+
+		suite, exists := byRunner[r.Runner]
+		if !exists {
+			suite = &junitTestSuite{
+				Name: r.Runner,
+			}
+			byRunner[r.Runner] = suite
+			suites.Suites = append(suites.Suites, suite)
+		}
+
+		tc := &junitTestCase{
+			Name:      r.Usecase,
+			Classname: r.Runner,
+			Time:      junitTime(r.Duration),
+		}
+
+		if !r.Success {
+			tc.Failure = &junitFailure{
+				Message:  junitFailureMessage(r),
+				Type:     r.Status,
+				Contents: junitFailureContents(r),
+			}
+			suite.Failures++
+		}
+
+		suite.Tests++
+		suite.TestCases = append(suite.TestCases, tc)
+		durations[r.Runner] += r.Duration
+	}
+
+	for _, suite := range suites.Suites {
+		suite.Time = junitTime(durations[suite.Name])
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// junitFailureMessage provides the failed step or operation of the
+// report along with its error. The report's status is provided if the
+// failure is not known, e.g. as the use case timed out before a step
+// failed.
+func junitFailureMessage(r *Report) string {
+	switch m := r.Message.(type) {
+	case string:
+		// The message of a failed scenario is its failed step & error
+		if m != "" {
+			return m
+		}
+	case *RandomResult:
+		if m.FailedOp > 0 && m.FailedOp <= len(m.Ops) {
+			return fmt.Sprintf("op %d (%s): %s", m.FailedOp, m.Ops[m.FailedOp-1], m.Error)
+		}
+	case *LoadResult:
+		if m.Failures > 0 {
+			return fmt.Sprintf("%d of %d ops failed: %s", m.Failures, m.Ops, m.LastError)
+		}
+	}
+
+	return fmt.Sprintf("Use case %s", r.Status)
+}
+
+// junitFailureContents provides the report's message in a readable form,
+// i.e. via its String() if it has one, else as JSON
+func junitFailureContents(r *Report) string {
+	switch m := r.Message.(type) {
+	case nil:
+		return ""
+	case string:
+		return m
+	case fmt.Stringer:
+		return m.String()
+	}
+
+	b, err := json.MarshalIndent(r.Message, "", "  ")
+	if err != nil {
+		return fmt.Sprintf("%v", r.Message)
+	}
+	return string(b)
+}
+
+// junitTime formats the duration in seconds as expected by JUnit
+func junitTime(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
@@ -0,0 +1,110 @@
+package mtest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteJUnit(t *testing.T) {
+	reports := []*Report{
+		{
+			Runner:   "a.runner",
+			Usecase:  "create",
+			Status:   STATUS_OK,
+			Success:  true,
+			Duration: 1500 * time.Millisecond,
+		},
+		nil,
+		{
+			Runner:   "a.runner",
+			Usecase:  "snapshot",
+			Message:  "step 'snap': <boom> & more",
+			Status:   STATUS_TIMEOUT,
+			Success:  false,
+			Duration: 2 * time.Second,
+		},
+		{
+			Runner:  "b.runner",
+			Usecase: "remove",
+			Status:  STATUS_OK,
+			Success: true,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, reports); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		`<?xml version="1.0" encoding="UTF-8"?>`,
+		`<testsuite name="a.runner" tests="2" failures="1" time="3.500">`,
+		`<testcase name="create" classname="a.runner" time="1.500"></testcase>`,
+		`<failure message="step &#39;snap&#39;: &lt;boom&gt; &amp; more" type="TIMEOUT">step &#39;snap&#39;: &lt;boom&gt; &amp; more</failure>`,
+		`<testsuite name="b.runner" tests="1" failures="0" time="0.000">`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Fatalf("expected %q in:\n%s", e, out)
+		}
+	}
+
+	if strings.Index(out, `name="a.runner"`) > strings.Index(out, `name="b.runner"`) {
+		t.Fatalf("expected suites in the order of the runners:\n%s", out)
+	}
+}
+
+func TestWriteJUnit_Failures(t *testing.T) {
+	reports := []*Report{
+		{
+			Runner:  MTEST_RANDOM_RUNNER_NAME,
+			Usecase: "random.seed.7",
+			Status:  STATUS_FAILED,
+			Message: &RandomResult{
+				Seed:     7,
+				Ops:      []*RandomOp{{Kind: RANDOM_OP_CREATE_VOLUME, Volume: "vol-1"}, {Kind: RANDOM_OP_REMOVE_VOLUME, Volume: "vol-1"}},
+				FailedOp: 2,
+				Error:    "volume vol-1 not found",
+			},
+		},
+		{
+			Runner:  MTEST_LOAD_RUNNER_NAME,
+			Usecase: "create",
+			Status:  STATUS_FAILED,
+			Message: &LoadResult{Ops: 4, Failures: 2, LastError: "throttled"},
+		},
+		{
+			// A report of a results file, whose message is decoded as is
+			Runner:  "a.runner",
+			Usecase: "decoded",
+			Status:  STATUS_FAILED,
+			Message: map[string]interface{}{"Error": "boom"},
+		},
+		{
+			Runner:  "a.runner",
+			Usecase: "cancelled",
+			Status:  STATUS_CANCELLED,
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteJUnit(&buf, reports); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	out := buf.String()
+
+	expected := []string{
+		`<failure message="op 2 (remove-volume vol-1): volume vol-1 not found" type="FAILED">seed 7, op 2 of 2 failed: volume vol-1 not found</failure>`,
+		`<failure message="2 of 4 ops failed: throttled" type="FAILED">4 ops, 2 failures`,
+		`<failure message="Use case FAILED" type="FAILED">{&#xA;  &#34;Error&#34;: &#34;boom&#34;&#xA;}</failure>`,
+		`<failure message="Use case CANCELLED" type="CANCELLED"></failure>`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(out, e) {
+			t.Fatalf("expected %q in:\n%s", e, out)
+		}
+	}
+}
@@ -0,0 +1,476 @@
+package mtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+)
+
+const (
+	// Name of the load testing runner
+	MTEST_LOAD_RUNNER_NAME = "load.runner"
+)
+
+// LoadResult is the outcome of a load test. This is the message of
+// the load test's report.
+type LoadResult struct {
+	// Rate & concurrency at which the operations were started
+	Rate        float64
+	Concurrency int
+
+	// No of completed operations including the failed ones
+	Ops int
+
+	// No of failed operations
+	Failures int
+
+	// No of operations that were not started at their scheduled time
+	// as all the workers were busy. This is applicable to a rate only.
+	Dropped int
+
+	// Failed operations as a fraction of the completed ones
+	ErrorRate float64
+
+	// Completed operations per second. This includes the time that
+	// the operations waited on each other.
+	Throughput float64
+
+	// Time taken by the load test
+	Elapsed time.Duration
+
+	// Latencies of the operations, excluding the time that they
+	// waited on each other
+	Latency *OpStats
+
+	// Time that the operations waited on each other, e.g. as the
+	// volume attaches to an EC2 instance are serialized
+	Wait *OpStats
+
+	// Latencies of each executor that was executed by the operations,
+	// excluding the waits
+	Executors []*OpStats
+
+	// Waits of each executor that was executed by the operations
+	ExecutorWaits []*OpStats
+
+	// Error of the last failed operation, if any
+	LastError string
+}
+
+// String provides a single line & readable form of the result
+func (r *LoadResult) String() string {
+	s := fmt.Sprintf("%d ops, %d failures (%.1f%%), %d dropped, %.2f ops/sec",
+		r.Ops, r.Failures, r.ErrorRate*100, r.Dropped, r.Throughput)
+
+	if r.Latency != nil {
+		s += fmt.Sprintf(", p50 %v, p90 %v, p99 %v, max %v",
+			r.Latency.P50, r.Latency.P90, r.Latency.P99, r.Latency.Max)
+	}
+
+	if r.Wait != nil && r.Wait.Max > 0 {
+		s += fmt.Sprintf(", waited on serialized calls p50 %v, p90 %v, p99 %v, max %v",
+			r.Wait.P50, r.Wait.P90, r.Wait.P99, r.Wait.Max)
+	}
+
+	return s
+}
+
+// LoadRunner executes the load tests of the config one after the
+// other. Each load test starts operations at a rate or keeps a no of
+// operations in flight & records their latencies, error rate &
+// throughput.
+type LoadRunner struct {
+	logger *log.Logger
+	Progress
+
+	// The load tests that will be run
+	loads []*config.Load
+
+	// The scenarios that are referred by the load tests
+	scenarios map[string]*config.Scenario
+
+	// Deadline of the entire run, no deadline if 0
+	runTimeout time.Duration
+
+	// Default deadline of each step, no deadline if 0
+	stepTimeout time.Duration
+
+	// Version & revision of mtest that are set on the reports
+	version  string
+	revision string
+
+	// Provides the config of a driver
+	driverConfig func(name string) map[string]string
+
+	// handler, if set, handles the report of each load test
+	handler ReportHandler
+
+	// events, if set, gets the start & end of each load test
+	events *EventBroker
+}
+
+func init() {
+	// Register by passing the name of this runner
+	// and its factory function definition.
+	RegisterRunner(MTEST_LOAD_RUNNER_NAME, NewLoadRunMaker)
+}
+
+// NewLoadRunMaker returns an instance of MtestMake that aligns to
+// MtestMaker interface. The load tests of the mtest config are run as
+// use cases.
+func NewLoadRunMaker(logWriter io.Writer, mconfig *config.MtestConfig) (MtestMaker, error) {
+
+	if logWriter == nil {
+		return nil, fmt.Errorf("Log writer is required to create a LoadRunner")
+	}
+
+	if mconfig == nil || len(mconfig.Loads) == 0 {
+		return nil, fmt.Errorf("No load tests declared in the config")
+	}
+
+	runner := &LoadRunner{
+		logger:       log.New(logWriter, "", log.LstdFlags|log.Lmicroseconds),
+		loads:        mconfig.Loads,
+		scenarios:    make(map[string]*config.Scenario),
+		version:      mconfig.VersionString(),
+		revision:     mconfig.Revision,
+		driverConfig: mconfig.DriverConfig,
+	}
+
+	// The use cases of a matrix scenario are referred by their names
+	// e.g. volume-types[type=gp2]
+	for _, s := range config.ExpandScenarios(mconfig.Scenarios) {
+		runner.scenarios[s.Name] = s
+	}
+
+	selector, err := NewSelector(mconfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if runner.loads = selector.Loads(runner.loads); len(runner.loads) == 0 {
+		return nil, fmt.Errorf("No load tests match the selection %s", selector)
+	}
+
+	for _, l := range runner.loads {
+		if _, exists := runner.scenarios[l.Scenario]; l.Scenario != "" && !exists {
+			return nil, fmt.Errorf("load '%s': scenario '%s' is not declared", l.Name, l.Scenario)
+		}
+	}
+
+	if runner.runTimeout, err = parseTimeout(mconfig.RunTimeout); err != nil {
+		return nil, err
+	}
+
+	if runner.stepTimeout, err = parseTimeout(mconfig.StepTimeout); err != nil {
+		return nil, err
+	}
+
+	return &MtestMake{
+		runner: runner,
+	}, nil
+}
+
+func (r *LoadRunner) Name() string {
+	return MTEST_LOAD_RUNNER_NAME
+}
+
+func (r *LoadRunner) Logger() *log.Logger {
+	return r.logger
+}
+
+// IsParallel is true, as the operations of a load test are run
+// concurrently
+func (r *LoadRunner) IsParallel() bool {
+	return true
+}
+
+// SetReportHandler sets the handler which gets the report of each
+// load test as soon as the load test completes
+func (r *LoadRunner) SetReportHandler(h ReportHandler) {
+	r.handler = h
+}
+
+// SetEventBroker sets the broker which gets the start & end of each
+// load test. The events of the individual operations are not published,
+// as they are far too many.
+func (r *LoadRunner) SetEventBroker(b *EventBroker) {
+	r.events = b
+}
+
+// Run runs the load tests
+func (r *LoadRunner) Run() ([]*Report, error) {
+	return r.RunContext(context.Background())
+}
+
+// RunContext runs the load tests. The in-flight operations are aborted
+// & no more operations are started when the context is done.
+func (r *LoadRunner) RunContext(ctx context.Context) ([]*Report, error) {
+	r.Start()
+	defer r.Stop()
+
+	if r.runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.runTimeout)
+		defer cancel()
+	}
+
+	reports := make([]*Report, 0, len(r.loads))
+	for _, l := range r.loads {
+		report := r.runLoad(ctx, l)
+		if r.handler != nil {
+			r.handler(report)
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// Plan provides the plans of the load tests. The drivers are initialized
+// for a dry run & hence nothing is executed.
+func (r *LoadRunner) Plan() ([]*Plan, error) {
+	exec := NewScenarioExec(MTEST_LOAD_RUNNER_NAME, r.logger)
+	exec.DriverConfig = r.driverConfig
+	exec.DryRun = true
+
+	plans := make([]*Plan, 0, len(r.loads))
+	for _, l := range r.loads {
+		plan, err := exec.Plan(r.scenario(l))
+		if err != nil {
+			return nil, err
+		}
+
+		plan.Usecase = l.Name
+		plan.Tags = l.Tags
+		plan.Notes = []string{loadNote(l)}
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+// scenario provides the scenario that is run as an operation of the
+// load test
+func (r *LoadRunner) scenario(l *config.Load) *config.Scenario {
+	if scenario, exists := r.scenarios[l.Scenario]; exists {
+		return scenario
+	}
+
+	return &config.Scenario{
+		Name:  l.Name,
+		Steps: []*config.Step{l.Step()},
+	}
+}
+
+// loadNote describes the load of the load test
+func loadNote(l *config.Load) string {
+	concurrency := l.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	s := fmt.Sprintf("concurrency %d", concurrency)
+	if l.Rate > 0 {
+		s = fmt.Sprintf("rate %v ops/sec, ", l.Rate) + s
+	}
+	if l.Duration != "" {
+		s += ", for " + l.Duration
+	}
+	if l.Ops > 0 {
+		s += fmt.Sprintf(", max %d ops", l.Ops)
+	}
+	if l.Scenario != "" {
+		s += ", scenario " + l.Scenario
+	}
+
+	return s
+}
+
+// runLoad runs a load test & reports its result
+func (r *LoadRunner) runLoad(ctx context.Context, l *config.Load) *Report {
+	scenario := r.scenario(l)
+
+	concurrency := l.Concurrency
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	report := &Report{
+		Runner:   MTEST_LOAD_RUNNER_NAME,
+		Usecase:  l.Name,
+		Started:  time.Now(),
+		Version:  r.version,
+		Revision: r.revision,
+	}
+
+	r.logger.Printf("[INFO] load: %s: starting with rate %v & concurrency %d", l.Name, l.Rate, concurrency)
+	r.events.Publish(&Event{
+		Type:    EVENT_USECASE_STARTED,
+		Runner:  MTEST_LOAD_RUNNER_NAME,
+		Usecase: l.Name,
+	})
+
+	executors := NewLatencies()
+	executorWaits := NewLatencies()
+	ops := NewLatencies()
+	waits := NewLatencies()
+
+	var (
+		m       sync.Mutex
+		lastErr string
+		wg      sync.WaitGroup
+	)
+
+	tokens := make(chan struct{})
+	// Each worker gets its own driver instances, whose volume attaches
+	// are still serialized per EC2 instance by the ebs client
+	execs := make([]*ScenarioExec, concurrency)
+	for w := range execs {
+		execs[w] = NewScenarioExec(MTEST_LOAD_RUNNER_NAME, r.logger)
+		execs[w].StepTimeout = r.stepTimeout
+		execs[w].Version = r.version
+		execs[w].Revision = r.revision
+		execs[w].Latencies = executors
+		execs[w].Waits = executorWaits
+		execs[w].DriverConfig = r.driverConfig
+		execs[w].Worker = fmt.Sprintf("%s-%d", l.Name, w)
+
+		wg.Add(1)
+		go func(e *ScenarioExec) {
+			defer wg.Done()
+
+			for range tokens {
+				opCtx, wait := driver.WithWaitTimer(ctx)
+				start := time.Now()
+				rpt := e.Run(opCtx, scenario)
+
+				var err error
+				if !rpt.Success {
+					err = fmt.Errorf("%v", rpt.Message)
+
+					m.Lock()
+					lastErr = err.Error()
+					m.Unlock()
+				}
+				ops.Record(l.Name, time.Since(start)-wait.Waited(), err)
+				waits.Record(l.Name, wait.Waited(), err)
+			}
+		}(execs[w])
+	}
+
+	dropped := schedule(ctx, l, tokens)
+	close(tokens)
+	wg.Wait()
+
+	result := &LoadResult{
+		Rate:        l.Rate,
+		Concurrency: concurrency,
+		Dropped:     dropped,
+		Elapsed:     time.Since(report.Started),
+		Executors:   executors.Stats(),
+		LastError:   lastErr,
+	}
+
+	if stats := waits.Stats(); len(stats) > 0 && stats[0].Max > 0 {
+		result.Wait = stats[0]
+		result.ExecutorWaits = executorWaits.Stats()
+	}
+
+	if stats := ops.Stats(); len(stats) > 0 {
+		result.Latency = stats[0]
+		result.Ops = stats[0].Count
+		result.Failures = stats[0].Failures
+		result.ErrorRate = float64(result.Failures) / float64(result.Ops)
+		result.Throughput = float64(result.Ops) / result.Elapsed.Seconds()
+	}
+
+	report.Message = result
+	report.Info = execs[0].driverInfo(scenario.Steps[0].Driver)
+	report.Ended = time.Now()
+	report.Duration = report.Ended.Sub(report.Started)
+
+	switch {
+	case ctx.Err() != nil:
+		report.Status = failedStatus(ctx)
+	case result.Ops == 0 || result.ErrorRate > l.MaxErrorRate:
+		report.Status = STATUS_FAILED
+	default:
+		report.Status = STATUS_OK
+		report.Success = true
+	}
+
+	r.logger.Printf("[INFO] load: %s: %s", l.Name, result)
+	if stats := executors.String(); stats != "" {
+		r.logger.Printf("[INFO] load: %s: latencies: %s", l.Name, stats)
+	}
+	if result.Wait != nil {
+		r.logger.Printf("[INFO] load: %s: the ops were serialized in part, e.g. the volume attaches to an EC2 instance, & the latencies exclude the waits: %s",
+			l.Name, executorWaits)
+	}
+
+	r.events.Publish(usecaseFinishedEvent(report))
+	return report
+}
+
+// schedule hands out a token to the workers for every operation that
+// is to be started. The tokens are handed out at the load test's rate,
+// if any, else as soon as a worker is free. The scheduling ends when
+// the load test's duration elapses, its ops have been started or the
+// context is done.
+//
+// The no of operations that were dropped as all the workers were busy
+// is returned.
+func schedule(ctx context.Context, l *config.Load, tokens chan<- struct{}) int {
+	var deadline <-chan time.Time
+	if d, err := time.ParseDuration(l.Duration); err == nil && d > 0 {
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		deadline = timer.C
+	}
+
+	var tick <-chan time.Time
+	if l.Rate > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / l.Rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	started, dropped := 0, 0
+	for l.Ops == 0 || started < l.Ops {
+		if tick == nil {
+			select {
+			case <-ctx.Done():
+				return dropped
+			case <-deadline:
+				return dropped
+			case tokens <- struct{}{}:
+				started++
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return dropped
+		case <-deadline:
+			return dropped
+		case <-tick:
+		}
+
+		select {
+		case tokens <- struct{}{}:
+			started++
+		default:
+			dropped++
+		}
+	}
+
+	return dropped
+}
@@ -0,0 +1,182 @@
+package mtest
+
+import (
+	"io/ioutil"
+	"log"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver/mock"
+)
+
+func newTestLoadRunner(t *testing.T, mconfig *config.MtestConfig) *LoadRunner {
+	maker, err := NewLoadRunMaker(ioutil.Discard, mconfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	r := maker.(*MtestMake).runner.(*LoadRunner)
+	r.logger = log.New(ioutil.Discard, "", 0)
+	return r
+}
+
+func TestLoadRunner_Concurrency(t *testing.T) {
+	r := newTestLoadRunner(t, &config.MtestConfig{
+		Loads: []*config.Load{
+			{
+				Name:        "create",
+				Driver:      mock.DRIVER_NAME,
+				Executor:    "slow",
+				Request:     "load-${unique}",
+				Concurrency: 4,
+				Ops:         12,
+			},
+		},
+	})
+
+	reports, err := r.Run()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(reports) != 1 || !reports[0].Success {
+		t.Fatalf("bad reports: %#v", reports)
+	}
+
+	res := reports[0].Message.(*LoadResult)
+	if res.Ops != 12 || res.Failures != 0 || res.Dropped != 0 || res.Concurrency != 4 {
+		t.Fatalf("bad result: %s", res)
+	}
+
+	if res.Throughput <= 0 || res.Latency == nil || res.Latency.P50 <= 0 {
+		t.Fatalf("bad latencies: %s", res)
+	}
+
+	if res.Wait != nil {
+		t.Fatalf("expected no waits: %s", res)
+	}
+
+	// 12 ops of 20ms each with 4 workers must take ~60ms, not ~240ms
+	if res.Elapsed >= 200*time.Millisecond {
+		t.Fatalf("expected the ops to run concurrently, took %v", res.Elapsed)
+	}
+
+	if len(res.Executors) != 1 || res.Executors[0].Op != "slow" || res.Executors[0].Count != 12 {
+		t.Fatalf("bad executor stats: %v", res.Executors)
+	}
+}
+
+func TestLoadRunner_SerializedWaits(t *testing.T) {
+	r := newTestLoadRunner(t, &config.MtestConfig{
+		Loads: []*config.Load{
+			{
+				Name:        "attach",
+				Driver:      mock.DRIVER_NAME,
+				Executor:    "serial",
+				Request:     "load-${unique}",
+				Concurrency: 4,
+				Ops:         8,
+			},
+		},
+	})
+
+	reports, err := r.Run()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	res := reports[0].Message.(*LoadResult)
+	if !reports[0].Success || res.Ops != 8 {
+		t.Fatalf("bad result: %s", res)
+	}
+
+	// 8 serialized calls of 20ms each must take ~160ms, most of
+	// which the queued ops spent waiting on each other
+	if res.Wait == nil || res.Wait.Max < 60*time.Millisecond {
+		t.Fatalf("expected the waits to be reported: %s", res)
+	}
+
+	if res.Latency.Max >= 60*time.Millisecond {
+		t.Fatalf("expected the latencies to exclude the waits: %s", res)
+	}
+
+	if len(res.ExecutorWaits) != 1 || res.ExecutorWaits[0].Op != "serial" || res.ExecutorWaits[0].Max != res.Wait.Max {
+		t.Fatalf("bad executor waits: %v", res.ExecutorWaits)
+	}
+
+	if !strings.Contains(res.String(), "waited on serialized calls") {
+		t.Fatalf("bad result: %s", res)
+	}
+}
+
+func TestLoadRunner_RateDropsWhenBusy(t *testing.T) {
+	r := newTestLoadRunner(t, &config.MtestConfig{
+		Loads: []*config.Load{
+			{
+				Name:     "rate",
+				Driver:   mock.DRIVER_NAME,
+				Executor: "slow",
+				Rate:     500,
+				Duration: "100ms",
+			},
+		},
+	})
+
+	reports, err := r.Run()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	res := reports[0].Message.(*LoadResult)
+	if res.Ops == 0 || res.Dropped == 0 {
+		t.Fatalf("expected ops to be dropped by a single busy worker: %s", res)
+	}
+}
+
+func TestLoadRunner_ErrorRate(t *testing.T) {
+	r := newTestLoadRunner(t, &config.MtestConfig{
+		Scenarios: []*config.Scenario{
+			{
+				Name:  "failing",
+				Steps: []*config.Step{mockStep("create", "fail", "vol1", nil)},
+			},
+		},
+		Loads: []*config.Load{
+			{
+				Name:         "failing",
+				Scenario:     "failing",
+				Ops:          5,
+				MaxErrorRate: 0.5,
+			},
+		},
+	})
+
+	reports, err := r.Run()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	res := reports[0].Message.(*LoadResult)
+	if reports[0].Success || reports[0].Status != STATUS_FAILED || res.ErrorRate != 1 {
+		t.Fatalf("bad report: %#v: %s", reports[0], res)
+	}
+
+	if !strings.Contains(res.LastError, "mock failure of vol1") {
+		t.Fatalf("bad last error: %q", res.LastError)
+	}
+}
+
+func TestNewLoadRunMaker_Invalid(t *testing.T) {
+	if _, err := NewLoadRunMaker(ioutil.Discard, &config.MtestConfig{}); err == nil {
+		t.Fatalf("expected error without load tests, got nothing")
+	}
+
+	_, err := NewLoadRunMaker(ioutil.Discard, &config.MtestConfig{
+		Loads: []*config.Load{{Name: "a", Scenario: "unicorn", Ops: 1}},
+	})
+	if err == nil || !strings.Contains(err.Error(), "scenario 'unicorn' is not declared") {
+		t.Fatalf("expected undeclared scenario error, got %v", err)
+	}
+}
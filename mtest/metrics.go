@@ -0,0 +1,74 @@
+package mtest
+
+import (
+	"github.com/openebs/mtest/metrics"
+)
+
+const (
+	// Metrics of the runs & their use cases
+	RUNS_METRIC              = "mtest_runs_total"
+	USECASES_METRIC          = "mtest_usecases_total"
+	USECASES_PASSED_METRIC   = "mtest_usecases_passed_total"
+	USECASES_FAILED_METRIC   = "mtest_usecases_failed_total"
+	STEP_DURATION_METRIC     = "mtest_step_duration_seconds"
+	RESOURCES_CREATED_METRIC = "mtest_resources_created_total"
+	RESOURCES_DELETED_METRIC = "mtest_resources_deleted_total"
+)
+
+// MetricsHandler records the events of the runs as counters &
+// histograms of a metrics registry
+type MetricsHandler struct {
+	runs     *metrics.Counter
+	usecases *metrics.Counter
+	passed   *metrics.Counter
+	failed   *metrics.Counter
+	steps    *metrics.Histogram
+	created  *metrics.Counter
+	deleted  *metrics.Counter
+}
+
+// NewMetricsHandler returns a handler that records into the registry
+func NewMetricsHandler(reg *metrics.Registry) *MetricsHandler {
+	return &MetricsHandler{
+		runs: reg.Counter(RUNS_METRIC,
+			"No of runs that finished, by status.", "runner", "status"),
+		usecases: reg.Counter(USECASES_METRIC,
+			"No of use cases that were run.", "runner"),
+		passed: reg.Counter(USECASES_PASSED_METRIC,
+			"No of use cases that passed.", "runner"),
+		failed: reg.Counter(USECASES_FAILED_METRIC,
+			"No of use cases that failed, by status.", "runner", "status"),
+		steps: reg.Histogram(STEP_DURATION_METRIC,
+			"Duration of the steps in seconds, including their retries.", nil, "executor", "status"),
+		created: reg.Counter(RESOURCES_CREATED_METRIC,
+			"No of volumes, snapshots & backups created by the steps.", "kind"),
+		deleted: reg.Counter(RESOURCES_DELETED_METRIC,
+			"No of volumes, snapshots & backups deleted by the steps.", "kind"),
+	}
+}
+
+// HandleEvent records the end of a run, use case or step, and the
+// resources that were created or deleted
+func (h *MetricsHandler) HandleEvent(ev *Event) {
+	switch ev.Type {
+	case EVENT_RUN_FINISHED:
+		h.runs.Inc(ev.Runner, ev.Status)
+
+	case EVENT_USECASE_FINISHED:
+		h.usecases.Inc(ev.Runner)
+		if ev.Status == STATUS_OK {
+			h.passed.Inc(ev.Runner)
+		} else {
+			h.failed.Inc(ev.Runner, ev.Status)
+		}
+
+	case EVENT_STEP_FINISHED:
+		h.steps.Observe(ev.Duration.Seconds(), ev.Executor, ev.Status)
+
+	case EVENT_RESOURCE_CREATED:
+		h.created.Inc(ev.Resource.Kind)
+
+	case EVENT_RESOURCE_DELETED:
+		h.deleted.Inc(ev.Resource.Kind)
+	}
+}
@@ -0,0 +1,44 @@
+package mtest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/driver/ebs"
+	"github.com/openebs/mtest/metrics"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	reg := metrics.NewRegistry()
+	h := NewMetricsHandler(reg)
+
+	for _, ev := range []*Event{
+		{Type: EVENT_STEP_FINISHED, Executor: ebs.EBS_VOLUME_CREATE_EXEC, Status: STATUS_OK, Duration: time.Second},
+		{Type: EVENT_RESOURCE_CREATED, Resource: &Resource{Kind: RESOURCE_VOLUME, Name: "vol1"}},
+		{Type: EVENT_USECASE_FINISHED, Runner: "test", Status: STATUS_OK},
+		{Type: EVENT_USECASE_FINISHED, Runner: "test", Status: STATUS_TIMEOUT},
+		{Type: EVENT_RUN_FINISHED, Runner: "test", Status: STATUS_FAILED},
+	} {
+		h.HandleEvent(ev)
+	}
+
+	var buf bytes.Buffer
+	if err := reg.Write(&buf); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for _, want := range []string{
+		`mtest_usecases_total{runner="test"} 2`,
+		`mtest_usecases_passed_total{runner="test"} 1`,
+		`mtest_usecases_failed_total{runner="test",status="TIMEOUT"} 1`,
+		`mtest_runs_total{runner="test",status="FAILED"} 1`,
+		`mtest_resources_created_total{kind="volume"} 1`,
+		`mtest_step_duration_seconds_sum{executor="` + ebs.EBS_VOLUME_CREATE_EXEC + `",status="OK"} 1`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Fatalf("expected metric %q, got %s", want, buf.String())
+		}
+	}
+}
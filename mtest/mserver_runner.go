@@ -1,14 +1,16 @@
 package mtest
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"strconv"
+	"sync"
+	"time"
 
-	"github.com/openebs/mtest/driver"
-	"github.com/openebs/mtest/driver/ebs"
+	"github.com/openebs/mtest/config"
 )
 
 // MserverRunnerName provides a name of Mserver runner
@@ -21,28 +23,128 @@ const (
 
 	// Constant to name the volume creation use-case
 	MSERVER_VOLUME_CREATE_USECASE = "mserver.volume.create.usecase"
+
+	// Constant to name the volume soak use-case
+	MSERVER_VOLUME_SOAK_USECASE = "mserver.volume.soak.usecase"
+
+	// Default wait between the attempts of a step
+	DEFAULT_STEP_RETRY_INTERVAL = 5 * time.Second
+
+	// Default interval of the interim summaries while soaking
+	DEFAULT_SOAK_INTERVAL = 5 * time.Minute
+
+	// No of the last reports of a soak that are kept, as are the first
+	// ones of its failed use cases
+	DEFAULT_SOAK_REPORTS = 100
 )
 
 // A MserverRunner structure definition
 type MserverRunner struct {
 	logger *log.Logger
 	Parallel
-	inprogress bool
+	Progress
+
+	// The scenarios i.e. use cases that will be run
+	scenarios []*config.Scenario
+
+	// Deadline of the entire run, no deadline if 0
+	runTimeout time.Duration
+
+	// Default deadline of each step, no deadline if 0
+	stepTimeout time.Duration
+
+	// Wall clock duration for which the use cases are run in
+	// cycles, the use cases are run once if 0
+	soak time.Duration
+
+	// Interval of the interim summaries while soaking
+	soakInterval time.Duration
+
+	// Version & revision of mtest that are set on the reports
+	version  string
+	revision string
+
+	// Provides the config of a driver, if set
+	driverConfig func(name string) map[string]string
+
+	// handler, if set, handles the report of each use case
+	handler ReportHandler
+
+	// checkpoint, if set, records the progress of the use cases
+	checkpoint *Checkpoint
+
+	// events, if set, gets the events of the use cases
+	events *EventBroker
+}
+
+func init() {
+	// Register by passing the name of this runner
+	// and its factory function definition.
+	RegisterRunner(MTEST_MSERVER_RUNNER_NAME, NewMserverRunMaker)
 }
 
 // NewMserverRunMaker returns an instance of MtestMake that
 // aligns to MtestMaker interface.
-func NewMserverRunMaker(logWriter io.Writer) (MtestMaker, error) {
+//
+// The scenarios of the provided mtest config are run as use cases,
+// where a scenario with a matrix is run as a use case per combination
+// of its parameters. Default scenarios are run if the config does not
+// have any. These differ when soaking, as they are run repeatedly.
+func NewMserverRunMaker(logWriter io.Writer, mconfig *config.MtestConfig) (MtestMaker, error) {
 
 	if logWriter == nil {
 		return nil, fmt.Errorf("Log writer is required to create a MServerRunner")
 	}
 
+	runner := &MserverRunner{
+		logger:    log.New(logWriter, "", log.LstdFlags|log.Lmicroseconds),
+		scenarios: DefaultScenarios(),
+	}
+
+	if mconfig != nil {
+		if len(mconfig.Scenarios) > 0 {
+			runner.scenarios = mconfig.Scenarios
+		}
+
+		runner.version = mconfig.VersionString()
+		runner.revision = mconfig.Revision
+		runner.driverConfig = mconfig.DriverConfig
+
+		var err error
+		if runner.runTimeout, err = parseTimeout(mconfig.RunTimeout); err != nil {
+			return nil, err
+		}
+
+		if runner.stepTimeout, err = parseTimeout(mconfig.StepTimeout); err != nil {
+			return nil, err
+		}
+
+		if runner.soak, err = parseTimeout(mconfig.Soak); err != nil {
+			return nil, err
+		}
+
+		if runner.soakInterval, err = parseTimeout(mconfig.SoakInterval); err != nil {
+			return nil, err
+		}
+
+		if runner.soak > 0 && len(mconfig.Scenarios) == 0 {
+			runner.scenarios = DefaultSoakScenarios()
+		}
+
+		runner.scenarios = config.ExpandScenarios(runner.scenarios)
+
+		selector, err := NewSelector(mconfig)
+		if err != nil {
+			return nil, err
+		}
+
+		if runner.scenarios = selector.Scenarios(runner.scenarios); len(runner.scenarios) == 0 {
+			return nil, fmt.Errorf("No use cases match the selection %s", selector)
+		}
+	}
+
 	return &MtestMake{
-		runner: &MserverRunner{
-			logger:     log.New(logWriter, "", log.LstdFlags|log.Lmicroseconds),
-			inprogress: false,
-		},
+		runner: runner,
 	}, nil
 }
 
@@ -54,99 +156,280 @@ func (r *MserverRunner) Logger() *log.Logger {
 	return r.logger
 }
 
+// SetReportHandler sets the handler which gets the report of each
+// use case as soon as the use case completes
+func (r *MserverRunner) SetReportHandler(h ReportHandler) {
+	r.handler = h
+}
+
+// SetEventBroker sets the broker which gets the events of the use cases
+// & their steps as they progress
+func (r *MserverRunner) SetEventBroker(b *EventBroker) {
+	r.events = b
+}
+
+// SetCheckpoint sets the checkpoint of the run. Checkpoints are not
+// kept while soaking, as the use cases are run afresh in each cycle.
+func (r *MserverRunner) SetCheckpoint(c *Checkpoint) {
+	r.checkpoint = c
+}
+
 // Run runs the use cases against a running Mserver process
 func (r *MserverRunner) Run() ([]*Report, error) {
+	return r.RunContext(context.Background())
+}
+
+// RunContext runs the use cases against a running Mserver process.
+// The use cases are aborted when the context is done or when the run's
+// deadline is exceeded.
+//
+// The use cases are run in cycles if a soak duration has been set.
+func (r *MserverRunner) RunContext(ctx context.Context) ([]*Report, error) {
 	r.Start()
 	defer r.Stop()
 
-	runThreads := os.Getenv("MTEST_MSERVER_RUNNER_THREADS")
-	if runThreads == "" {
-		runThreads = "0"
+	// The no of threads of the environment applies only if the
+	// parallelism was not set explicitly
+	if !r.IsParallelismSet() {
+		runThreads := os.Getenv("MTEST_MSERVER_RUNNER_THREADS")
+		if runThreads == "" {
+			runThreads = "0"
+		}
+
+		// basic base-10 string parse
+		threads, err := strconv.Atoi(runThreads)
+		if err != nil {
+			return nil, err
+		}
+
+		r.forks = threads
 	}
 
-	// basic base-10 string parse
-	threads, err := strconv.Atoi(runThreads)
-	if err != nil {
-		return nil, err
+	if r.runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.runTimeout)
+		defer cancel()
 	}
 
-	r.Parallel = Parallel{
-		forks: threads,
+	execs := r.newScenarioExecs()
+
+	if r.soak > 0 {
+		return r.soakUseCases(ctx, execs)
 	}
 
 	// Do the real run of use cases
-	return r.runUseCases()
+	return r.runUseCases(ctx, execs), nil
 }
 
-// IsComplete returns if the runner has executed all its
-// test cases.
-func (r *MserverRunner) IsComplete() bool {
-	return !r.inprogress
+// newScenarioExecs creates a scenario executor per worker.
+//
+// Each worker gets its own scenario executor & hence its own driver
+// instances, so that the use cases of the workers run side by side.
+// The drivers serialize what the instances share, e.g. the ebs driver
+// attaches one volume at a time to an EC2 instance.
+func (r *MserverRunner) newScenarioExecs() []*ScenarioExec {
+	workers := r.Workers(len(r.scenarios))
+	execs := make([]*ScenarioExec, workers)
+	for w := range execs {
+		execs[w] = NewScenarioExec(MTEST_MSERVER_RUNNER_NAME, r.logger)
+		execs[w].StepTimeout = r.stepTimeout
+		execs[w].Version = r.version
+		execs[w].Revision = r.revision
+		execs[w].DriverConfig = r.driverConfig
+		execs[w].Events = r.events
+		if r.soak == 0 {
+			execs[w].Checkpoint = r.checkpoint
+		}
+		if r.IsParallel() {
+			execs[w].Worker = fmt.Sprintf("worker-%d", w)
+		}
+	}
+
+	if r.IsParallel() {
+		r.logger.Printf("[INFO] Running %d use case(s) with %d worker(s)", len(r.scenarios), workers)
+	}
+
+	return execs
 }
 
-func (r *MserverRunner) Start() {
-	r.inprogress = true
+// Plan provides the plans of the use cases. The drivers are initialized
+// for a dry run & hence nothing is executed.
+func (r *MserverRunner) Plan() ([]*Plan, error) {
+	exec := NewScenarioExec(MTEST_MSERVER_RUNNER_NAME, r.logger)
+	exec.DriverConfig = r.driverConfig
+	exec.DryRun = true
+
+	var notes []string
+	if r.soak > 0 {
+		notes = append(notes, fmt.Sprintf("run in cycles for %v", r.soak))
+	}
+	if r.IsParallel() {
+		notes = append(notes, fmt.Sprintf("run with %d worker(s)", r.Workers(len(r.scenarios))))
+	}
+
+	plans := make([]*Plan, 0, len(r.scenarios))
+	for _, s := range r.scenarios {
+		plan, err := exec.Plan(s)
+		if err != nil {
+			return nil, err
+		}
+		plan.Notes = notes
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
 }
 
-func (r *MserverRunner) Stop() {
-	r.inprogress = false
+func (r *MserverRunner) runUseCases(ctx context.Context, execs []*ScenarioExec) []*Report {
+
+	// Each scenario is a use case whose steps are executed
+	// as a **chain of execution**. Reports are placed in the
+	// order of the scenarios irrespective of their completion.
+	reports := make([]*Report, len(r.scenarios))
+	r.Dispatch(len(r.scenarios), func(worker, i int) {
+		reports[i] = execs[worker].Run(ctx, r.scenarios[i])
+		if r.handler != nil {
+			r.handler(reports[i])
+		}
+	})
+
+	return reports
 }
 
-func (r *MserverRunner) runUseCases() ([]*Report, error) {
+// soakUseCases runs the use cases in cycles till the soak duration
+// elapses. A cycle that has started is run to its completion, while
+// the soak is aborted when the context is done.
+//
+// Interim summaries of the use cases & the latencies of the executors
+// are logged at every soak interval. The summaries cover all the
+// cycles, whereas only the last DEFAULT_SOAK_REPORTS reports & as many
+// of the first failed ones are returned.
+func (r *MserverRunner) soakUseCases(ctx context.Context, execs []*ScenarioExec) ([]*Report, error) {
+	latencies := NewLatencies()
+	for _, e := range execs {
+		e.Latencies = latencies
+	}
 
-	// Get the ebs driver
-	ebsDriver, err := driver.GetDriver(ebs.DRIVER_NAME, "", make(map[string]string))
-	if err != nil {
-		return nil, err
+	interval := r.soakInterval
+	if interval <= 0 {
+		interval = DEFAULT_SOAK_INTERVAL
 	}
 
-	// The usecases can optionally be sent by the caller/client
-	// TODO
-	//    The usecase names & corresponding executions should be invoked
-	// in sequence with various options/flags to handle this **chain of
-	// execution**.
-	usecases := []string{ebs.EBS_VOLUME_CREATE_EXEC}
+	start := time.Now()
+	deadline := start.Add(r.soak)
+	r.logger.Printf("[INFO] soak: running use cases in cycles for %v", r.soak)
+
+	var (
+		m       sync.Mutex
+		summary = Summarize(nil)
+		reports = newSoakReports(DEFAULT_SOAK_REPORTS)
+		cycles  int
+	)
 
-	// Get the executors corresponding to each use-case
-	mapExecs, err := ebsDriver.Executors(usecases...)
-	if err != nil {
-		return nil, err
+	summarize := func() {
+		m.Lock()
+		s := *summary
+		completed := cycles
+		m.Unlock()
+
+		r.logger.Printf("[INFO] soak: %v elapsed, %d cycle(s), %s", time.Since(start).Round(time.Second), completed, &s)
+		if stats := latencies.String(); stats != "" {
+			r.logger.Printf("[INFO] soak: latencies: %s", stats)
+		}
 	}
 
-	// The volume executor
-	volCtr := mapExecs[ebs.EBS_VOLUME_CREATE_EXEC]
+	doneCh := make(chan struct{})
+	defer close(doneCh)
 
-	// Execute
-	resp, err := volCtr.Exec(driver.Request{
-		Name: "vol1",
-		//Options: map[string]string{
-		//},
-	})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
 
-	reports := make([]*Report, len(usecases))
+		for {
+			select {
+			case <-doneCh:
+				return
+			case <-ticker.C:
+				summarize()
+			}
+		}
+	}()
+
+	for ctx.Err() == nil && time.Now().Before(deadline) {
+		cycle := r.runUseCases(ctx, execs)
 
-	if err == nil {
-		report := &Report{
-			Runner:  MTEST_MSERVER_RUNNER_NAME,
-			Usecase: MSERVER_VOLUME_CREATE_USECASE,
-			Message: resp,
-			Status:  "OK",
-			Success: true,
+		m.Lock()
+		for _, rpt := range cycle {
+			summary.add(rpt)
+			reports.add(rpt)
 		}
+		cycles++
+		m.Unlock()
+	}
+
+	summarize()
+	return reports.list(), nil
+}
+
+// soakReports keeps the last reports of a soak along with its first
+// failed ones, so that a long soak does not hold all its reports
+type soakReports struct {
+	size int
 
-		reports = append(reports, report)
+	// The first failed reports
+	failed []*Report
+
+	// The last reports as a ring, where next is the index of the
+	// oldest report once the ring is full
+	last []*Report
+	next int
+}
+
+func newSoakReports(size int) *soakReports {
+	return &soakReports{size: size}
+}
+
+func (k *soakReports) add(r *Report) {
+	if r == nil {
+		return
+	}
 
-	} else {
-		report := &Report{
-			Runner:  MTEST_MSERVER_RUNNER_NAME,
-			Usecase: MSERVER_VOLUME_CREATE_USECASE,
-			Message: err.Error(),
-			Status:  "FAILED",
-			Success: false,
+	if !r.Success && len(k.failed) < k.size {
+		k.failed = append(k.failed, r)
+	}
+
+	if len(k.last) < k.size {
+		k.last = append(k.last, r)
+		return
+	}
+	k.last[k.next] = r
+	k.next = (k.next + 1) % k.size
+}
+
+// list provides the kept reports in the order they were added
+func (k *soakReports) list() []*Report {
+	last := append(append([]*Report(nil), k.last[k.next:]...), k.last[:k.next]...)
+
+	kept := make(map[*Report]struct{}, len(last))
+	for _, r := range last {
+		kept[r] = struct{}{}
+	}
+
+	var reports []*Report
+	for _, r := range k.failed {
+		if _, ok := kept[r]; !ok {
+			reports = append(reports, r)
 		}
+	}
+
+	return append(reports, last...)
+}
 
-		reports = append(reports, report)
+// parseTimeout parses the timeout, if set, as a duration
+func parseTimeout(timeout string) (time.Duration, error) {
+	if timeout == "" {
+		return 0, nil
 	}
 
-	return reports, nil
+	return time.ParseDuration(timeout)
 }
@@ -1 +1,247 @@
 package mtest
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/config"
+)
+
+func TestMserverRunner_RunParallel(t *testing.T) {
+	var scenarios []*config.Scenario
+	for i := 0; i < 6; i++ {
+		hint := "create"
+		if i == 2 {
+			hint = "fail"
+		}
+
+		scenarios = append(scenarios, &config.Scenario{
+			Name: fmt.Sprintf("usecase-%d", i),
+			Steps: []*config.Step{
+				mockStep("create", hint, fmt.Sprintf("vol%d", i), nil),
+			},
+		})
+	}
+
+	r := &MserverRunner{
+		logger:    log.New(ioutil.Discard, "", 0),
+		scenarios: scenarios,
+	}
+
+	os.Setenv("MTEST_MSERVER_RUNNER_THREADS", "3")
+	defer os.Unsetenv("MTEST_MSERVER_RUNNER_THREADS")
+
+	reports, err := r.Run()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !r.IsParallel() {
+		t.Fatalf("expected the runner to be parallel")
+	}
+
+	if len(reports) != len(scenarios) {
+		t.Fatalf("expected %d reports, got %d", len(scenarios), len(reports))
+	}
+
+	// Reports must be in the order of the scenarios
+	for i, rpt := range reports {
+		if rpt == nil {
+			t.Fatalf("report %d is nil", i)
+		}
+
+		if rpt.Usecase != scenarios[i].Name {
+			t.Fatalf("report %d: expected use case %s, got %s", i, scenarios[i].Name, rpt.Usecase)
+		}
+
+		if rpt.Success != (i != 2) {
+			t.Fatalf("report %d: bad success %v", i, rpt.Success)
+		}
+	}
+
+	if !r.IsComplete() {
+		t.Fatalf("expected the runner to be complete")
+	}
+}
+
+func TestMserverRunner_SetParallelism(t *testing.T) {
+	r := &MserverRunner{
+		logger: log.New(ioutil.Discard, "", 0),
+		scenarios: []*config.Scenario{
+			{
+				Name:  "usecase",
+				Steps: []*config.Step{mockStep("create", "create", "vol1", nil)},
+			},
+		},
+	}
+
+	os.Setenv("MTEST_MSERVER_RUNNER_THREADS", "0")
+	defer os.Unsetenv("MTEST_MSERVER_RUNNER_THREADS")
+
+	// The explicit parallelism is not overridden by the environment
+	r.SetParallelism(2)
+	if _, err := r.Run(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if !r.IsParallel() || r.Workers(4) != 2 {
+		t.Fatalf("expected 2 workers, got %d", r.Workers(4))
+	}
+}
+
+func TestMserverRunner_Soak(t *testing.T) {
+	var buf syncBuffer
+
+	r := &MserverRunner{
+		logger: log.New(&buf, "", 0),
+		scenarios: []*config.Scenario{
+			{
+				Name: "cycle",
+				Steps: []*config.Step{
+					mockStep("create", "create", "vol-${unique}", nil),
+					mockStep("remove", "remove", "${create.Name}", nil),
+				},
+			},
+		},
+		soak:         100 * time.Millisecond,
+		soakInterval: 20 * time.Millisecond,
+	}
+
+	reports, err := r.Run()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(reports) < 2 || len(reports) > DEFAULT_SOAK_REPORTS {
+		t.Fatalf("expected multiple cycles & at most %d reports, got %d report(s)", DEFAULT_SOAK_REPORTS, len(reports))
+	}
+
+	// Each cycle must have used its own volume name
+	names := make(map[string]struct{})
+	for _, rpt := range reports {
+		outputs := rpt.Message.(map[string]interface{})
+		names[outputs["create"].(map[string]interface{})["Name"].(string)] = struct{}{}
+	}
+	if len(names) != len(reports) {
+		t.Fatalf("expected %d unique volume names, got %d", len(reports), len(names))
+	}
+
+	// The final summary covers all the cycles, even the ones whose
+	// reports were not kept
+	logs := buf.String()
+	var cycles, total, passed int
+	final := logs[strings.LastIndex(logs, "elapsed, ")+len("elapsed, "):]
+	if _, err := fmt.Sscanf(final, "%d cycle(s), %d use case(s): %d passed",
+		&cycles, &total, &passed); err != nil {
+		t.Fatalf("expected the final summary in logs: %v:\n%s", err, logs)
+	}
+	if cycles < len(reports) || total != cycles || passed != cycles {
+		t.Fatalf("bad final summary of %d report(s): %s", len(reports), final)
+	}
+
+	if !strings.Contains(logs, "soak: latencies: create: ") {
+		t.Fatalf("expected the latencies in logs:\n%s", logs)
+	}
+}
+
+func TestSoakReports(t *testing.T) {
+	k := newSoakReports(3)
+
+	var added []*Report
+	for i := 0; i < 10; i++ {
+		r := &Report{Usecase: fmt.Sprintf("uc%d", i), Success: i != 1 && i != 2 && i != 4 && i != 8}
+		added = append(added, r)
+		k.add(r)
+	}
+
+	// The first 3 failed reports, followed by the last 3 reports
+	expected := []*Report{added[1], added[2], added[4], added[7], added[8], added[9]}
+	reports := k.list()
+	if len(reports) != len(expected) {
+		t.Fatalf("expected %d reports, got %d", len(expected), len(reports))
+	}
+	for i, r := range reports {
+		if r != expected[i] {
+			t.Fatalf("expected report %s at %d, got %s", expected[i].Usecase, i, r.Usecase)
+		}
+	}
+
+	// A failed report is not repeated if it is one of the last ones
+	k = newSoakReports(3)
+	k.add(added[0])
+	k.add(added[1])
+	if reports := k.list(); len(reports) != 2 || reports[0] != added[0] || reports[1] != added[1] {
+		t.Fatalf("bad reports: %#v", reports)
+	}
+}
+
+// syncBuffer is a bytes.Buffer that is safe to use across goroutines
+type syncBuffer struct {
+	m   sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.m.Lock()
+	defer b.m.Unlock()
+	return b.buf.String()
+}
+
+func TestMserverRunner_Matrix(t *testing.T) {
+	create := mockStep("create", "create", "vol-${matrix.type}", map[string]string{
+		"Size": "${matrix.size}",
+	})
+	create.Assert = []string{"Size == ${matrix.size}"}
+
+	mconfig := &config.MtestConfig{
+		Scenarios: []*config.Scenario{{
+			Name: "types",
+			Matrix: &config.Matrix{
+				Axes: []*config.MatrixAxis{
+					{Name: "type", Values: []string{"gp2", "io1"}},
+					{Name: "size", Values: []string{"4G", "8G"}},
+				},
+				Exclude: []map[string]string{{"type": "io1", "size": "4G"}},
+			},
+			Steps: []*config.Step{create},
+		}},
+		Skip: "8G",
+	}
+
+	maker, err := NewMserverRunMaker(ioutil.Discard, mconfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	reports, err := mt.Start()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	// Each combination is a use case that can be selected by its name
+	if len(reports) != 1 || reports[0].Usecase != "types[type=gp2,size=4G]" {
+		t.Fatalf("bad reports: %v", reports)
+	}
+
+	if !reports[0].Success || reports[0].Request.Name != "vol-gp2" {
+		t.Fatalf("bad report: %v", reports[0])
+	}
+}
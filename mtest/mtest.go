@@ -13,9 +13,28 @@
 package mtest
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/openebs/mtest/driver"
+)
+
+const (
+	// Status of a use case that ran successfully
+	STATUS_OK = "OK"
+
+	// Status of a use case that failed
+	STATUS_FAILED = "FAILED"
+
+	// Status of a use case that did not complete within its deadline
+	STATUS_TIMEOUT = "TIMEOUT"
+
+	// Status of a use case that was aborted by a stop of the run
+	STATUS_CANCELLED = "CANCELLED"
 )
 
 // A Report is the Runner's run report structure for individual report fields.
@@ -35,6 +54,50 @@ type Report struct {
 
 	// Overall run status as a flag
 	Success bool
+
+	// Time taken to run the use case
+	Duration time.Duration
+
+	// Start & end times of the use case
+	Started time.Time
+	Ended   time.Time
+
+	// No of times the last executed step of the use case was executed
+	Attempts int
+
+	// No of retries of all the executed steps of the use case
+	Retries int `json:",omitempty"`
+
+	// The request that was sent by the last executed step
+	Request *driver.Request
+
+	// Info of the driver used by the last executed step, e.g. region,
+	// availability zone, instance id & defaults of the EBS driver
+	Info map[string]string
+
+	// Outcomes of the assertions & expected errors of the executed
+	// steps of the use case
+	Assertions []*AssertionResult `json:",omitempty"`
+
+	// Version & revision of mtest that ran the use case
+	Version  string
+	Revision string
+
+	// Worker is the address of the mtest agent that ran the use case,
+	// if the use case was run by a coordinator. The addresses of the
+	// agents that ran its steps are comma separated if its steps were
+	// placed on several agents.
+	Worker string `json:",omitempty"`
+}
+
+// String provides a single line & readable form of the report
+func (r *Report) String() string {
+	msg := ""
+	if r.Message != nil {
+		msg = fmt.Sprintf(": %v", r.Message)
+	}
+
+	return fmt.Sprintf("%s %s [%s] (%v)%s", r.Runner, r.Usecase, r.Status, r.Duration, msg)
 }
 
 // `Runner` is the interface for any OpenEBS program. A Runner will
@@ -65,6 +128,72 @@ type Runner interface {
 	Stop()
 }
 
+// `ContextRunner` is a Runner whose run can be cancelled or timed out
+// via the provided context.
+//
+// A ContextRunner is expected to abort its in-flight executions as soon
+// as the context is done & report them accordingly.
+type ContextRunner interface {
+	Runner
+
+	// RunContext is the context aware variant of Run()
+	RunContext(ctx context.Context) ([]*Report, error)
+}
+
+// ReportHandler handles the report of a use case as soon as the
+// use case completes.
+//
+// NOTE: A handler may be invoked from multiple goroutines when the use
+// cases are run in parallel.
+type ReportHandler func(r *Report)
+
+// `StreamingRunner` is a Runner that delivers the report of each of its
+// use cases to a handler as soon as the use case completes.
+type StreamingRunner interface {
+	Runner
+
+	// SetReportHandler sets the handler of the reports
+	SetReportHandler(h ReportHandler)
+}
+
+// `CheckpointingRunner` is a Runner that records the progress of its use
+// cases in a checkpoint, which lets an interrupted run be resumed.
+type CheckpointingRunner interface {
+	Runner
+
+	// SetCheckpoint sets the checkpoint of the run
+	SetCheckpoint(c *Checkpoint)
+}
+
+// RunContext runs the runner with the provided context.
+//
+// NOTE: A runner that is not a ContextRunner is run in a separate
+// goroutine. It is stopped & abandoned when the context is done.
+func RunContext(ctx context.Context, r Runner) ([]*Report, error) {
+	if cr, ok := r.(ContextRunner); ok {
+		return cr.RunContext(ctx)
+	}
+
+	type result struct {
+		reports []*Report
+		err     error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		reports, err := r.Run()
+		done <- result{reports, err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		r.Stop()
+		return nil, ctx.Err()
+	case res := <-done:
+		return res.reports, res.err
+	}
+}
+
 // `Parallel` provides shared parallelism logic to be used by Mtest
 // runners to execute test cases in a parallel manner.
 //
@@ -77,8 +206,11 @@ type Runner interface {
 //         ...
 //     }
 type Parallel struct {
-	// TODO No of goroutines ??
+	// No of goroutines that can execute the test cases
 	forks int
+
+	// set is true once the no of forks is set via SetParallelism
+	set bool
 }
 
 // SetParallelism sets the parallel IsParallel will check when called.
@@ -87,6 +219,13 @@ type Parallel struct {
 // in parallel.
 func (p *Parallel) SetParallelism(forks int) {
 	p.forks = forks
+	p.set = true
+}
+
+// IsParallelismSet returns if the no of forks was set explicitly via
+// SetParallelism
+func (p *Parallel) IsParallelismSet() bool {
+	return p.set
 }
 
 // IsParallel returns if the no of forks is greater than 0
@@ -97,6 +236,91 @@ func (p *Parallel) IsParallel() bool {
 	return false
 }
 
+// Workers returns the no of goroutines that will be used to
+// execute the provided count of test cases.
+func (p *Parallel) Workers(count int) int {
+	if !p.IsParallel() || count < 1 {
+		return 1
+	}
+
+	if p.forks < count {
+		return p.forks
+	}
+
+	return count
+}
+
+// Dispatch executes fn for each of the count test cases. The test
+// cases are picked up in their order by a bounded pool of Workers(count)
+// goroutines. fn is provided with the id of the worker (starting from
+// 0) & the index of the test case.
+//
+// Dispatch returns after all the test cases have been executed.
+// Test cases are executed serially by a single worker if the runner
+// is not parallel.
+func (p *Parallel) Dispatch(count int, fn func(worker, index int)) {
+	workers := p.Workers(count)
+
+	if workers == 1 {
+		for i := 0; i < count; i++ {
+			fn(0, i)
+		}
+		return
+	}
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for i := range indexes {
+				fn(worker, i)
+			}
+		}(w)
+	}
+
+	for i := 0; i < count; i++ {
+		indexes <- i
+	}
+	close(indexes)
+
+	wg.Wait()
+}
+
+// `Progress` provides the race free progress of a runner, which is
+// queried by Mtest while the runner runs.
+//
+// Usage:
+//    this struct as an anonymous field within the runner's struct.
+//
+// Example:
+//     type JivaRunner struct {
+//         Progress
+//         ...
+//     }
+type Progress struct {
+	// 1 while the runner is executing its test cases
+	inprogress int32
+}
+
+// IsComplete returns if the runner has executed all its
+// test cases.
+func (p *Progress) IsComplete() bool {
+	return atomic.LoadInt32(&p.inprogress) == 0
+}
+
+// Start marks the runner as executing its test cases
+func (p *Progress) Start() {
+	atomic.StoreInt32(&p.inprogress, 1)
+}
+
+// Stop marks the runner as done with its test cases
+func (p *Progress) Stop() {
+	atomic.StoreInt32(&p.inprogress, 0)
+}
+
 // Blueprint to create Mtest structure.
 type MtestMaker interface {
 	Make() (*Mtest, error)
@@ -108,6 +332,14 @@ type MtestMake struct {
 	logger *log.Logger
 }
 
+// NewMtestMake returns a MtestMake of the runner. This lets the runners
+// of other packages register their factories.
+func NewMtestMake(runner Runner) *MtestMake {
+	return &MtestMake{
+		runner: runner,
+	}
+}
+
 // The interface method
 func (t *MtestMake) Make() (*Mtest, error) {
 	if t.runner == nil {
@@ -126,10 +358,11 @@ func (t *MtestMake) Make() (*Mtest, error) {
 		logger:  t.logger,
 		runner:  t.runner,
 		running: false,
+		events:  NewEventBroker(DEFAULT_EVENT_BUFFER),
 	}, nil
 }
 
-// Mtest provides a synchronous start of runners.
+// Mtest provides a synchronous & an asynchronous start of runners.
 //
 // Mtest is safe to use across multiple goroutines and will manage to
 // provide the current status of execution via report.
@@ -139,6 +372,18 @@ type Mtest struct {
 	running bool
 	m       sync.Mutex
 
+	// handle of the current run, if any, which aborts the run
+	handle *RunHandle
+
+	// handler, if set, handles the report of each use case
+	handler ReportHandler
+
+	// checkpoint, if set, records the progress of the run
+	checkpoint *Checkpoint
+
+	// events publishes the progress of the runs to its handlers
+	events *EventBroker
+
 	runner Runner
 }
 
@@ -164,35 +409,172 @@ func (t *Mtest) SetRunner(newRunner Runner) (Runner, error) {
 	return nil, fmt.Errorf("Can not set a new runner when mtest is running")
 }
 
+// SetReportHandler sets the handler that is invoked with the report of
+// each use case. The reports are handled as soon as their use cases
+// complete if the runner is a StreamingRunner, else after the run.
+func (t *Mtest) SetReportHandler(h ReportHandler) error {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if t.isRunning() {
+		return fmt.Errorf("Can not set a report handler when mtest is running")
+	}
+
+	t.handler = h
+	return nil
+}
+
+// SetCheckpoint sets the checkpoint that records the progress of the
+// runs. A run resumes from the progress that the checkpoint already has.
+func (t *Mtest) SetCheckpoint(c *Checkpoint) error {
+	t.m.Lock()
+	defer t.m.Unlock()
+
+	if t.isRunning() {
+		return fmt.Errorf("Can not set a checkpoint when mtest is running")
+	}
+
+	if _, ok := t.runner.(CheckpointingRunner); !ok {
+		return fmt.Errorf("Runner '%s' does not support checkpoints", t.runner.Name())
+	}
+
+	t.checkpoint = c
+	return nil
+}
+
+// RegisterEventHandler adds a handler that gets the events of the runs
+// i.e. the start & end of each run, use case & step & the resources that
+// were created or deleted. The last events, if any, are sent to the
+// handler right away. The events of the use cases & steps are published
+// only if the runner is an EventingRunner.
+func (t *Mtest) RegisterEventHandler(h EventHandler) {
+	t.events.RegisterHandler(h)
+}
+
+// DeregisterEventHandler removes the handler of the events
+func (t *Mtest) DeregisterEventHandler(h EventHandler) {
+	t.events.DeregisterHandler(h)
+}
+
 // Start will start this Mtest's associated runner, will return
 // the result as reports, or error if the runner failed.
 func (t *Mtest) Start() ([]*Report, error) {
+	return t.StartContext(context.Background())
+}
+
+// StartContext is the context aware variant of Start(). The run is
+// aborted when either the provided context is done or Stop() is
+// invoked.
+func (t *Mtest) StartContext(ctx context.Context) ([]*Report, error) {
+	h, err := t.StartAsyncContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.Wait()
+}
+
+// StartAsync starts this Mtest's associated runner in the background &
+// returns the handle of the run. The run can be waited for, queried &
+// cancelled via its handle.
+func (t *Mtest) StartAsync() (*RunHandle, error) {
+	return t.StartAsyncContext(context.Background())
+}
+
+// StartAsyncContext is the context aware variant of StartAsync(). The
+// run is aborted when either the provided context is done, Stop() is
+// invoked or the run's handle is cancelled.
+func (t *Mtest) StartAsyncContext(ctx context.Context) (*RunHandle, error) {
 	t.m.Lock()
 	defer t.m.Unlock()
 
-	// If not running then run
-	if !t.isRunning() {
-		t.running = true
-		reports, err := t.runner.Run()
-		if err != nil {
-			return nil, err
+	if t.isRunning() {
+		return nil, fmt.Errorf("Mtest is already running")
+	}
+
+	// The run is asynchronous, hence mtest can be started again
+	// once the run returns
+	t.running = true
+
+	ctx, cancel := context.WithCancel(ctx)
+	h := newRunHandle(t.runner.Name(), cancel)
+	t.handle = h
+
+	// The reports of a runner that streams are collected by the
+	// handle as they come, so that they can be queried during the run
+	handler := t.handler
+	sr, streaming := t.runner.(StreamingRunner)
+	if streaming {
+		sr.SetReportHandler(func(r *Report) {
+			h.add(r)
+			if handler != nil {
+				handler(r)
+			}
+		})
+	}
+
+	if cr, ok := t.runner.(CheckpointingRunner); ok {
+		cr.SetCheckpoint(t.checkpoint)
+	}
+
+	if er, ok := t.runner.(EventingRunner); ok {
+		er.SetEventBroker(t.events)
+	}
+
+	t.events.Publish(&Event{
+		Type:   EVENT_RUN_STARTED,
+		Runner: t.runner.Name(),
+	})
+
+	go t.run(ctx, h, streaming, handler)
+
+	return h, nil
+}
+
+// run runs the runner & finishes the run's handle once the runner
+// returns
+func (t *Mtest) run(ctx context.Context, h *RunHandle, streaming bool, handler ReportHandler) {
+	defer h.cancel()
+
+	reports, err := RunContext(ctx, t.runner)
+	t.events.Publish(runFinishedEvent(t.runner.Name(), reports, err, time.Since(h.started)))
+
+	// Reports of a runner that does not stream are
+	// handled after the run
+	if err == nil && !streaming && handler != nil {
+		for _, r := range reports {
+			if r != nil {
+				handler(r)
+			}
 		}
+	}
 
+	t.m.Lock()
+	if err == nil {
 		t.reports = reports
-	} else {
-		return nil, fmt.Errorf("Mtest is already running")
 	}
+	t.running = false
+	t.handle = nil
+	t.m.Unlock()
 
-	return t.reports, nil
+	// The handle is finished last, so that mtest can be started again
+	// once the run is waited for
+	h.finish(reports, err, ctx.Err())
 }
 
-// Signals a stop of mtest
+// Signals a stop of mtest. This aborts the current run if any, without
+// waiting for the run to return. The run returns with the reports of
+// its aborted use cases, which can be waited for via the run's handle.
 func (t *Mtest) Stop() {
 	t.m.Lock()
 	defer t.m.Unlock()
 
+	if t.handle != nil {
+		t.handle.Cancel()
+		return
+	}
+
 	t.runner.Stop()
-	t.running = false
 }
 
 // IsRunning is a public safe version of isRunning()
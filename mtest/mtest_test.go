@@ -1 +1,266 @@
 package mtest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/config"
+)
+
+func TestParallel_Workers(t *testing.T) {
+	cases := []struct {
+		Forks   int
+		Count   int
+		Workers int
+	}{
+		{0, 5, 1},
+		{1, 5, 1},
+		{3, 5, 3},
+		{8, 5, 5},
+		{4, 0, 1},
+	}
+
+	for _, tc := range cases {
+		p := &Parallel{}
+		p.SetParallelism(tc.Forks)
+
+		if w := p.Workers(tc.Count); w != tc.Workers {
+			t.Fatalf("forks: %d, count: %d: want %d workers, got %d", tc.Forks, tc.Count, tc.Workers, w)
+		}
+	}
+}
+
+func TestParallel_Dispatch(t *testing.T) {
+	p := &Parallel{}
+	p.SetParallelism(3)
+
+	var (
+		m       sync.Mutex
+		active  int
+		highest int
+		workers = make(map[int]struct{})
+	)
+
+	visited := make([]bool, 10)
+	p.Dispatch(len(visited), func(worker, i int) {
+		m.Lock()
+		active++
+		if active > highest {
+			highest = active
+		}
+		workers[worker] = struct{}{}
+		m.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+		visited[i] = true
+
+		m.Lock()
+		active--
+		m.Unlock()
+	})
+
+	for i, v := range visited {
+		if !v {
+			t.Fatalf("test case %d was not executed", i)
+		}
+	}
+
+	if highest > 3 {
+		t.Fatalf("expected at most 3 concurrent executions, got %d", highest)
+	}
+
+	if len(workers) != 3 {
+		t.Fatalf("expected 3 workers, got %d", len(workers))
+	}
+}
+
+func TestParallel_DispatchSerial(t *testing.T) {
+	p := &Parallel{}
+
+	var order []int
+	p.Dispatch(4, func(worker, i int) {
+		if worker != 0 {
+			t.Fatalf("expected worker 0, got %d", worker)
+		}
+		order = append(order, i)
+	})
+
+	for i, v := range order {
+		if i != v {
+			t.Fatalf("expected serial order, got %v", order)
+		}
+	}
+}
+
+func TestMtest_StopAbortsRun(t *testing.T) {
+	maker := &MtestMake{
+		runner: &MserverRunner{
+			logger: log.New(ioutil.Discard, "", 0),
+			scenarios: []*config.Scenario{
+				{
+					Name:  "stuck",
+					Steps: []*config.Step{mockStep("stuck", "block", "vol1", nil)},
+				},
+			},
+		},
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	type result struct {
+		reports []*Report
+		err     error
+	}
+
+	doneCh := make(chan result, 1)
+	go func() {
+		reports, err := mt.Start()
+		doneCh <- result{reports, err}
+	}()
+
+	// Let the run start
+	time.Sleep(50 * time.Millisecond)
+	mt.Stop()
+
+	select {
+	case res := <-doneCh:
+		if res.err != nil {
+			t.Fatalf("err: %s", res.err)
+		}
+
+		if len(res.reports) != 1 || res.reports[0].Status != STATUS_CANCELLED {
+			t.Fatalf("bad reports: %#v", res.reports)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Stop did not abort the run")
+	}
+}
+
+func TestMtest_ReportHandler(t *testing.T) {
+	var scenarios []*config.Scenario
+	for i := 0; i < 3; i++ {
+		scenarios = append(scenarios, &config.Scenario{
+			Name:  fmt.Sprintf("usecase-%d", i),
+			Steps: []*config.Step{mockStep("create", "create", "vol1", nil)},
+		})
+	}
+
+	maker := &MtestMake{
+		runner: &MserverRunner{
+			logger:    log.New(ioutil.Discard, "", 0),
+			scenarios: scenarios,
+		},
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var (
+		m       sync.Mutex
+		handled []string
+	)
+	err = mt.SetReportHandler(func(r *Report) {
+		m.Lock()
+		defer m.Unlock()
+		handled = append(handled, r.Usecase)
+	})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	reports, err := mt.Start()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(handled) != len(reports) {
+		t.Fatalf("expected %d handled reports, got %v", len(reports), handled)
+	}
+}
+
+func TestMtest_Events(t *testing.T) {
+	maker := &MtestMake{
+		runner: &MserverRunner{
+			logger: log.New(ioutil.Discard, "", 0),
+			scenarios: []*config.Scenario{
+				{
+					Name:  "usecase",
+					Steps: []*config.Step{mockStep("fail", "fail", "vol1", nil)},
+				},
+			},
+		},
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	h := &recordingHandler{}
+	mt.RegisterEventHandler(h)
+
+	if _, err := mt.Start(); err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	expected := []EventType{
+		EVENT_RUN_STARTED,
+		EVENT_USECASE_STARTED,
+		EVENT_STEP_STARTED,
+		EVENT_STEP_FINISHED,
+		EVENT_USECASE_FINISHED,
+		EVENT_RUN_FINISHED,
+	}
+	if !reflect.DeepEqual(h.types(), expected) {
+		t.Fatalf("bad events:\nwant: %v\n got: %v", expected, h.types())
+	}
+
+	last := h.events[len(h.events)-1]
+	if last.Runner != MTEST_MSERVER_RUNNER_NAME || last.Status != STATUS_FAILED {
+		t.Fatalf("bad run event: %#v", last)
+	}
+}
+
+func TestMtest_StartRepeatedly(t *testing.T) {
+	maker := &MtestMake{
+		runner: &MserverRunner{
+			logger: log.New(ioutil.Discard, "", 0),
+			scenarios: []*config.Scenario{
+				{
+					Name:  "usecase",
+					Steps: []*config.Step{mockStep("create", "create", "vol1", nil)},
+				},
+			},
+		},
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	for i := 0; i < 3; i++ {
+		reports, err := mt.Start()
+		if err != nil {
+			t.Fatalf("run %d: err: %s", i, err)
+		}
+
+		if len(reports) != 1 || !reports[0].Success {
+			t.Fatalf("run %d: bad reports: %#v", i, reports)
+		}
+	}
+
+	if mt.IsRunning() {
+		t.Fatalf("expected mtest to not be running")
+	}
+}
@@ -0,0 +1,220 @@
+package mtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+)
+
+// StepPlan is a step as it would be executed
+type StepPlan struct {
+	// Name of the step
+	Step string
+
+	// The driver & executor of the step
+	Driver   string
+	Executor string
+
+	// The request that would be sent, with the references to the
+	// earlier steps & the driver's defaults resolved
+	Request driver.Request
+
+	// References that can be resolved only when the steps are
+	// executed, e.g. ${backup.BackupURL}
+	Unresolved []string `json:",omitempty"`
+
+	// Deadline, attempts & expectations of the step
+	Timeout     string   `json:",omitempty"`
+	Attempts    int      `json:",omitempty"`
+	Assert      []string `json:",omitempty"`
+	ExpectError string   `json:",omitempty"`
+
+	// Info of the driver, e.g. the defaults of the EBS driver
+	Info map[string]string `json:",omitempty"`
+}
+
+// Plan is a use case as it would be run by a runner
+type Plan struct {
+	Runner  string
+	Usecase string
+	Tags    []string `json:",omitempty"`
+
+	// Notes on how the use case would be run, e.g. its load
+	Notes []string `json:",omitempty"`
+
+	Steps []*StepPlan
+}
+
+// `PlanningRunner` is a Runner that can plan its use cases without
+// running them. Drivers are initialized for a dry run while planning.
+type PlanningRunner interface {
+	Runner
+
+	// Plan provides the plans of the use cases that would be run
+	Plan() ([]*Plan, error)
+}
+
+// Plan provides the plans of the use cases of the runner, without
+// running them
+func (t *Mtest) Plan() ([]*Plan, error) {
+	pr, ok := t.runner.(PlanningRunner)
+	if !ok {
+		return nil, fmt.Errorf("Runner '%s' does not support a dry run", t.runner.Name())
+	}
+
+	return pr.Plan()
+}
+
+// Plan resolves each step of the scenario against the outputs of the
+// earlier steps & plans it via its executor. Nothing is executed.
+//
+// NOTE: The drivers of the executor should have been initialized for
+// a dry run, else planning may contact their services.
+func (e *ScenarioExec) Plan(s *config.Scenario) (*Plan, error) {
+	outputs := map[string]interface{}{
+		UNIQUE_REF: uniqueValue(),
+	}
+
+	plan := &Plan{
+		Runner:  e.runner,
+		Usecase: s.Name,
+		Tags:    s.Tags,
+	}
+
+	for _, step := range s.Steps {
+		req, unresolved, err := planRequest(step, outputs)
+		if err != nil {
+			return nil, fmt.Errorf("%s: step '%s': %s", s.Name, step.Name, err)
+		}
+
+		exec, err := e.executor(step)
+		if err != nil {
+			return nil, fmt.Errorf("%s: step '%s': %s", s.Name, step.Name, err)
+		}
+
+		if p, ok := exec.(driver.Planner); ok {
+			if req, err = p.Plan(req); err != nil {
+				return nil, fmt.Errorf("%s: step '%s': %s", s.Name, step.Name, err)
+			}
+		}
+
+		plan.Steps = append(plan.Steps, &StepPlan{
+			Step:        step.Name,
+			Driver:      step.Driver,
+			Executor:    step.Executor,
+			Request:     req,
+			Unresolved:  unresolved,
+			Timeout:     step.Timeout,
+			Attempts:    step.Attempts,
+			Assert:      step.Assert,
+			ExpectError: step.ExpectError,
+			Info:        e.driverInfo(step.Driver),
+		})
+
+		outputs[step.Name] = stepOutput(req, nil)
+	}
+
+	return plan, nil
+}
+
+// planRequest builds the request of a step like resolveRequest, except
+// that the references to the values that are known only on execution
+// are retained as is & are returned. A reference to a step that is not
+// an earlier step is an error.
+func planRequest(step *config.Step, outputs map[string]interface{}) (driver.Request, []string, error) {
+	var (
+		unresolved []string
+		rErr       error
+	)
+
+	resolve := func(s string) string {
+		return refRegex.ReplaceAllStringFunc(s, func(ref string) string {
+			path := strings.Split(strings.TrimSpace(ref[2:len(ref)-1]), ".")
+
+			if _, known := outputs[path[0]]; !known {
+				if rErr == nil {
+					rErr = fmt.Errorf("Reference '%s' is not to an earlier step", ref)
+				}
+				return ref
+			}
+
+			val, found := lookupValue(outputs, path)
+			if !found {
+				unresolved = append(unresolved, ref)
+				return ref
+			}
+
+			return fmt.Sprint(val)
+		})
+	}
+
+	req := driver.Request{
+		Name:    resolve(step.Request),
+		Options: make(map[string]string, len(step.Options)),
+	}
+
+	for k, v := range step.Options {
+		req.Options[k] = resolve(v)
+	}
+
+	return req, unresolved, rErr
+}
+
+// String provides the plan in a readable multi line form
+func (p *Plan) String() string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%s %s", p.Runner, p.Usecase)
+	if len(p.Tags) > 0 {
+		fmt.Fprintf(&b, " [%s]", strings.Join(p.Tags, ", "))
+	}
+	b.WriteString("\n")
+
+	for _, n := range p.Notes {
+		fmt.Fprintf(&b, "  %s\n", n)
+	}
+
+	for i, st := range p.Steps {
+		fmt.Fprintf(&b, "  %d. %s: %s (%s)\n", i+1, st.Step, st.Executor, st.Driver)
+		fmt.Fprintf(&b, "       Name: %s\n", st.Request.Name)
+
+		for _, k := range sortedKeys(st.Request.Options) {
+			fmt.Fprintf(&b, "       %s: %s\n", k, st.Request.Options[k])
+		}
+
+		if st.Timeout != "" {
+			fmt.Fprintf(&b, "       timeout: %s\n", st.Timeout)
+		}
+		if st.Attempts > 1 {
+			fmt.Fprintf(&b, "       attempts: %d\n", st.Attempts)
+		}
+		for _, a := range st.Assert {
+			fmt.Fprintf(&b, "       assert: %s\n", a)
+		}
+		if st.ExpectError != "" {
+			fmt.Fprintf(&b, "       expect error: %s\n", st.ExpectError)
+		}
+		if len(st.Unresolved) > 0 {
+			fmt.Fprintf(&b, "       resolved on execution: %s\n", strings.Join(st.Unresolved, ", "))
+		}
+		for _, k := range sortedKeys(st.Info) {
+			fmt.Fprintf(&b, "       driver %s: %s\n", k, st.Info[k])
+		}
+	}
+
+	return strings.TrimRight(b.String(), "\n")
+}
+
+// sortedKeys provides the keys of the map in their sorted order
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,150 @@
+package mtest
+
+import (
+	"io/ioutil"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+)
+
+const plannerDriverName = "mtest.planner"
+
+// plannerDriver is a MtestDriver whose executors can be planned only.
+// It records the config it was initialized with.
+type plannerDriver struct {
+	config map[string]string
+}
+
+type plannerExecutor struct{}
+
+var plannerConfigs []map[string]string
+
+func init() {
+	driver.Register(plannerDriverName, func(root string, config map[string]string) (driver.MtestDriver, error) {
+		plannerConfigs = append(plannerConfigs, config)
+		return &plannerDriver{config: config}, nil
+	})
+}
+
+func (d *plannerDriver) Name() string {
+	return plannerDriverName
+}
+
+func (d *plannerDriver) Info() (map[string]string, error) {
+	return map[string]string{"DefaultSize": "4G"}, nil
+}
+
+func (d *plannerDriver) Executors(hints ...string) (map[string]driver.Executor, error) {
+	execs := make(map[string]driver.Executor)
+	for _, hint := range hints {
+		execs[hint] = &plannerExecutor{}
+	}
+	return execs, nil
+}
+
+func (e *plannerExecutor) Exec(req driver.Request) (*driver.Response, error) {
+	panic("executed in a dry run")
+}
+
+// Plan defaults the size of the request
+func (e *plannerExecutor) Plan(req driver.Request) (driver.Request, error) {
+	if req.Options["Size"] == "" {
+		req.Options["Size"] = "4G"
+	}
+	return req, nil
+}
+
+func TestScenarioExec_Plan(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+	exec.DryRun = true
+
+	step := func(name, reqName string, opts map[string]string) *config.Step {
+		return &config.Step{
+			Name:     name,
+			Driver:   plannerDriverName,
+			Executor: "create",
+			Request:  reqName,
+			Options:  opts,
+		}
+	}
+
+	scenario := &config.Scenario{
+		Name: "plan",
+		Tags: []string{"smoke"},
+		Steps: []*config.Step{
+			step("create", "vol1", nil),
+			step("snap", "snap-of-${create.Name}", map[string]string{
+				"Size":     "${create.Size}",
+				"VolumeID": "${create.VolumeID}",
+			}),
+		},
+	}
+
+	plannerConfigs = nil
+	plan, err := exec.Plan(scenario)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(plannerConfigs) != 1 || plannerConfigs[0][driver.DRY_RUN] != "true" {
+		t.Fatalf("expected the driver to be initialized for a dry run, got %v", plannerConfigs)
+	}
+
+	if plan.Runner != "test.runner" || plan.Usecase != "plan" || len(plan.Steps) != 2 {
+		t.Fatalf("bad plan: %#v", plan)
+	}
+
+	if plan.Steps[0].Request.Options["Size"] != "4G" {
+		t.Fatalf("expected the size to be planned, got %v", plan.Steps[0].Request.Options)
+	}
+
+	snap := plan.Steps[1]
+	expected := driver.Request{
+		Name: "snap-of-vol1",
+		Options: map[string]string{
+			"Size":     "${create.Size}",
+			"VolumeID": "${create.VolumeID}",
+		},
+	}
+	if !reflect.DeepEqual(snap.Request, expected) {
+		t.Fatalf("bad request:\nwant: %#v\n got: %#v", expected, snap.Request)
+	}
+
+	// The outputs of a step are known only on its execution
+	if len(snap.Unresolved) != 2 {
+		t.Fatalf("bad unresolved: %v", snap.Unresolved)
+	}
+
+	if snap.Info["DefaultSize"] != "4G" {
+		t.Fatalf("bad info: %v", snap.Info)
+	}
+
+	out := plan.String()
+	for _, s := range []string{"test.runner plan [smoke]", "2. snap: create (mtest.planner)", "Name: snap-of-vol1", "Size: 4G"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("expected %q in:\n%s", s, out)
+		}
+	}
+}
+
+func TestScenarioExec_PlanInvalidRef(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+	exec.DryRun = true
+
+	scenario := &config.Scenario{
+		Name: "plan",
+		Steps: []*config.Step{
+			{Name: "create", Driver: plannerDriverName, Executor: "create", Request: "${later.Name}"},
+			{Name: "later", Driver: plannerDriverName, Executor: "create", Request: "vol1"},
+		},
+	}
+
+	_, err := exec.Plan(scenario)
+	if err == nil || !strings.Contains(err.Error(), "not to an earlier step") {
+		t.Fatalf("expected an error on the reference, got %v", err)
+	}
+}
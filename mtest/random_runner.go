@@ -0,0 +1,957 @@
+package mtest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+	"github.com/openebs/mtest/driver/ebs"
+)
+
+const (
+	// Name of the random testing runner
+	MTEST_RANDOM_RUNNER_NAME = "random.runner"
+
+	// No of operations of a random sequence, if not configured
+	DEFAULT_RANDOM_OPS = 20
+
+	// Max no of runs made to shrink a failed random sequence, if not
+	// configured
+	DEFAULT_RANDOM_MAX_SHRINK_RUNS = 50
+)
+
+const (
+	// Kinds of the operations of a random sequence
+	RANDOM_OP_CREATE_VOLUME   = "create-volume"
+	RANDOM_OP_RESTORE_VOLUME  = "restore-volume"
+	RANDOM_OP_REMOVE_VOLUME   = "remove-volume"
+	RANDOM_OP_CREATE_SNAPSHOT = "create-snapshot"
+	RANDOM_OP_REMOVE_SNAPSHOT = "remove-snapshot"
+	RANDOM_OP_CREATE_BACKUP   = "create-backup"
+	RANDOM_OP_REMOVE_BACKUP   = "remove-backup"
+)
+
+// RandomOp is an operation of a random sequence. The names are those of
+// the sequence's model, e.g. vol-1, & are prefixed by a value that is
+// unique to each execution of the sequence.
+type RandomOp struct {
+	Kind     string
+	Volume   string `json:",omitempty"`
+	Snapshot string `json:",omitempty"`
+	Backup   string `json:",omitempty"`
+}
+
+// String provides the operation in a readable form, e.g.
+// create-backup backup-3 of vol-1/snap-2
+func (o *RandomOp) String() string {
+	switch o.Kind {
+	case RANDOM_OP_CREATE_SNAPSHOT, RANDOM_OP_REMOVE_SNAPSHOT:
+		return fmt.Sprintf("%s %s/%s", o.Kind, o.Volume, o.Snapshot)
+	case RANDOM_OP_CREATE_BACKUP:
+		return fmt.Sprintf("%s %s of %s/%s", o.Kind, o.Backup, o.Volume, o.Snapshot)
+	case RANDOM_OP_RESTORE_VOLUME:
+		return fmt.Sprintf("%s %s from %s", o.Kind, o.Volume, o.Backup)
+	case RANDOM_OP_REMOVE_BACKUP:
+		return fmt.Sprintf("%s %s", o.Kind, o.Backup)
+	}
+
+	return fmt.Sprintf("%s %s", o.Kind, o.Volume)
+}
+
+// RandomResult is the outcome of a random sequence. This is the message
+// of the sequence's report.
+type RandomResult struct {
+	// Seed from which the sequence was generated. The same seed
+	// generates the same sequence.
+	Seed int64
+
+	// Operations of the sequence
+	Ops []*RandomOp
+
+	// The failed operation starting at 1, 0 if none failed, & its
+	// error
+	FailedOp int    `json:",omitempty"`
+	Error    string `json:",omitempty"`
+
+	// Minimal is the shortest sequence found by shrinking the failed
+	// sequence that still fails. This is a minimal reproduction of the
+	// failure.
+	Minimal []*RandomOp `json:",omitempty"`
+
+	// No of runs made to shrink the failed sequence
+	ShrinkRuns int `json:",omitempty"`
+}
+
+// String provides a single line & readable form of the result
+func (r *RandomResult) String() string {
+	if r.FailedOp == 0 {
+		return fmt.Sprintf("seed %d, %d ops", r.Seed, len(r.Ops))
+	}
+
+	s := fmt.Sprintf("seed %d, op %d of %d failed: %s", r.Seed, r.FailedOp, len(r.Ops), r.Error)
+	if len(r.Minimal) > 0 {
+		s += fmt.Sprintf(", reproduced by %d ops", len(r.Minimal))
+	}
+
+	return s
+}
+
+// RandomRunner runs random but valid sequences of volume, snapshot &
+// backup operations against a driver that aligns to the EBS driver's
+// executors. Each sequence is generated from a seed against a local
+// model of the volumes, snapshots & backups, so that an operation is
+// picked only if its preconditions hold, e.g. a snapshot is created of
+// an existing volume only.
+//
+// The driver is checked against the model after each operation. A
+// failed sequence is shrunk to a minimal reproduction by running it
+// again without its operations, one at a time, as long as it still
+// fails. The seed is logged & reported, so that any sequence can be
+// replayed exactly.
+//
+// NOTE: The volumes that are left by a sequence & the EBS snapshots of
+// its snapshots are deleted once the sequence completes. A backup shares
+// the EBS snapshot of its snapshot & hence goes along with it.
+type RandomRunner struct {
+	logger *log.Logger
+	Progress
+
+	// Driver that executes the operations
+	driverName string
+
+	// Seed of the first sequence & the no of sequences
+	seed      int64
+	sequences int
+
+	// No of operations of each sequence
+	ops int
+
+	// Max no of runs made to shrink a failed sequence, no shrinking
+	// if 0
+	maxShrinkRuns int
+
+	// Options of the requests that create the volumes
+	volumeOptions map[string]string
+
+	// Deadline of the entire run, no deadline if 0
+	runTimeout time.Duration
+
+	// Default deadline of each step, no deadline if 0
+	stepTimeout time.Duration
+
+	// Version & revision of mtest that are set on the reports
+	version  string
+	revision string
+
+	// Provides the config of a driver
+	driverConfig func(name string) map[string]string
+
+	// handler, if set, handles the report of each sequence
+	handler ReportHandler
+
+	// events, if set, gets the events of the sequences
+	events *EventBroker
+}
+
+func init() {
+	// Register by passing the name of this runner
+	// and its factory function definition.
+	RegisterRunner(MTEST_RANDOM_RUNNER_NAME, NewRandomRunMaker)
+}
+
+// NewRandomRunMaker returns an instance of MtestMake that aligns to
+// MtestMaker interface. The sequences are as per the random block of
+// the mtest config, if any. A seed is picked if it is not configured.
+func NewRandomRunMaker(logWriter io.Writer, mconfig *config.MtestConfig) (MtestMaker, error) {
+
+	if logWriter == nil {
+		return nil, fmt.Errorf("Log writer is required to create a RandomRunner")
+	}
+
+	runner := &RandomRunner{
+		logger:        log.New(logWriter, "", log.LstdFlags|log.Lmicroseconds),
+		driverName:    ebs.DRIVER_NAME,
+		seed:          time.Now().UnixNano(),
+		sequences:     1,
+		ops:           DEFAULT_RANDOM_OPS,
+		maxShrinkRuns: DEFAULT_RANDOM_MAX_SHRINK_RUNS,
+	}
+
+	if mconfig == nil {
+		return &MtestMake{
+			runner: runner,
+		}, nil
+	}
+
+	if r := mconfig.Random; r != nil {
+		if r.Driver != "" {
+			runner.driverName = r.Driver
+		}
+		if r.Seed != 0 {
+			runner.seed = r.Seed
+		}
+		if r.Sequences > 0 {
+			runner.sequences = r.Sequences
+		}
+		if r.Ops > 0 {
+			runner.ops = r.Ops
+		}
+		if r.MaxShrinkRuns < 0 {
+			runner.maxShrinkRuns = 0
+		} else if r.MaxShrinkRuns > 0 {
+			runner.maxShrinkRuns = r.MaxShrinkRuns
+		}
+		runner.volumeOptions = r.Options
+	}
+
+	runner.version = mconfig.VersionString()
+	runner.revision = mconfig.Revision
+	runner.driverConfig = mconfig.DriverConfig
+
+	var err error
+	if runner.runTimeout, err = parseTimeout(mconfig.RunTimeout); err != nil {
+		return nil, err
+	}
+
+	if runner.stepTimeout, err = parseTimeout(mconfig.StepTimeout); err != nil {
+		return nil, err
+	}
+
+	return &MtestMake{
+		runner: runner,
+	}, nil
+}
+
+func (r *RandomRunner) Name() string {
+	return MTEST_RANDOM_RUNNER_NAME
+}
+
+func (r *RandomRunner) Logger() *log.Logger {
+	return r.logger
+}
+
+// IsParallel is false, as the operations of a sequence depend on
+// their earlier ones
+func (r *RandomRunner) IsParallel() bool {
+	return false
+}
+
+// SetReportHandler sets the handler which gets the report of each
+// sequence as soon as the sequence completes
+func (r *RandomRunner) SetReportHandler(h ReportHandler) {
+	r.handler = h
+}
+
+// SetEventBroker sets the broker which gets the events of the sequences
+// & their operations as they progress. The runs that shrink a failed
+// sequence are not published.
+func (r *RandomRunner) SetEventBroker(b *EventBroker) {
+	r.events = b
+}
+
+// Run runs the random sequences
+func (r *RandomRunner) Run() ([]*Report, error) {
+	return r.RunContext(context.Background())
+}
+
+// RunContext runs the random sequences. The sequence in progress is
+// aborted & is not shrunk when the context is done.
+func (r *RandomRunner) RunContext(ctx context.Context) ([]*Report, error) {
+	r.Start()
+	defer r.Stop()
+
+	if r.runTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.runTimeout)
+		defer cancel()
+	}
+
+	r.logger.Printf("[INFO] random: running %d sequence(s) of %d ops from seed %d, replay via -seed=%d",
+		r.sequences, r.ops, r.seed, r.seed)
+
+	e := NewScenarioExec(MTEST_RANDOM_RUNNER_NAME, r.logger)
+	e.StepTimeout = r.stepTimeout
+	e.Version = r.version
+	e.Revision = r.revision
+	e.DriverConfig = r.driverConfig
+	e.Events = r.events
+
+	reports := make([]*Report, 0, r.sequences)
+	for i := 0; i < r.sequences; i++ {
+		report := r.runSequence(ctx, e, r.seed+int64(i))
+		if r.handler != nil {
+			r.handler(report)
+		}
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// Plan provides the plans of the sequences. The drivers are initialized
+// for a dry run & hence nothing is executed.
+func (r *RandomRunner) Plan() ([]*Plan, error) {
+	exec := NewScenarioExec(MTEST_RANDOM_RUNNER_NAME, r.logger)
+	exec.DriverConfig = r.driverConfig
+	exec.DryRun = true
+
+	plans := make([]*Plan, 0, r.sequences)
+	for i := 0; i < r.sequences; i++ {
+		seed := r.seed + int64(i)
+		ops := generateRandomOps(seed, r.ops)
+
+		plan, err := exec.Plan(&config.Scenario{
+			Name:  randomUsecase(seed),
+			Steps: r.steps(ops, "${"+UNIQUE_REF+"}-"),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		plan.Notes = []string{fmt.Sprintf("seed %d, replay via -seed=%d", seed, seed)}
+		plans = append(plans, plan)
+	}
+
+	return plans, nil
+}
+
+// randomUsecase provides the use case name of the sequence of the seed
+func randomUsecase(seed int64) string {
+	return fmt.Sprintf("random.seed.%d", seed)
+}
+
+// runSequence runs the sequence of the seed & reports its result. A
+// failed sequence is shrunk, unless it was aborted.
+func (r *RandomRunner) runSequence(ctx context.Context, e *ScenarioExec, seed int64) *Report {
+	ops := generateRandomOps(seed, r.ops)
+	result := &RandomResult{
+		Seed: seed,
+		Ops:  ops,
+	}
+
+	report := &Report{
+		Runner:   MTEST_RANDOM_RUNNER_NAME,
+		Usecase:  randomUsecase(seed),
+		Message:  result,
+		Started:  time.Now(),
+		Version:  r.version,
+		Revision: r.revision,
+	}
+
+	e.publish(&Event{
+		Type:    EVENT_USECASE_STARTED,
+		Usecase: report.Usecase,
+	})
+
+	run := r.execute(ctx, e, ops, report.Usecase)
+	report.Attempts = run.attempts
+	report.Retries = run.retries
+	report.Request = &run.req
+	report.Info = e.driverInfo(r.driverName)
+
+	if run.err == nil {
+		report.Status = STATUS_OK
+		report.Success = true
+	} else {
+		result.FailedOp = run.failed + 1
+		result.Error = run.err.Error()
+		report.Status = run.status
+
+		r.logger.Printf("[WARN] random: seed %d: op %d (%s) failed: %s",
+			seed, result.FailedOp, ops[run.failed], run.err)
+
+		if run.status == STATUS_FAILED && r.maxShrinkRuns > 0 {
+			result.Minimal, result.ShrinkRuns = r.shrink(ctx, e, ops[:run.failed+1], run.signature(ops))
+
+			r.logger.Printf("[WARN] random: seed %d: reproduced by %d ops after %d shrink run(s): %s",
+				seed, len(result.Minimal), result.ShrinkRuns, joinRandomOps(result.Minimal))
+		}
+	}
+
+	report.Ended = time.Now()
+	report.Duration = report.Ended.Sub(report.Started)
+	e.usecaseFinished(report)
+
+	return report
+}
+
+// joinRandomOps provides the operations in a single line
+func joinRandomOps(ops []*RandomOp) string {
+	s := make([]string, 0, len(ops))
+	for _, op := range ops {
+		s = append(s, op.String())
+	}
+
+	return strings.Join(s, ", ")
+}
+
+// randomRun is the outcome of an execution of a sequence
+type randomRun struct {
+	// Index of the failed operation, -1 if none failed, its error &
+	// its status
+	failed int
+	err    error
+	status string
+
+	// Prefix of the names of the volumes, snapshots & backups that is
+	// unique to the execution
+	prefix string
+
+	// The request & attempts of the last executed operation
+	req      driver.Request
+	attempts int
+
+	// No of retries of all the executed operations
+	retries int
+}
+
+// execute executes the operations with names that are unique to this
+// execution & checks the invariants after each operation. Execution
+// stops at the first failure. The volumes & EBS snapshots that are left
+// are deleted at the end.
+//
+// The events of the operations are published against the use case, if
+// it is set.
+func (r *RandomRunner) execute(ctx context.Context, e *ScenarioExec, ops []*RandomOp, usecase string) *randomRun {
+	prefix := uniqueValue() + "-"
+	steps := r.steps(ops, prefix)
+
+	model := newRandomModel()
+	urls := make(map[string]string)
+	outputs := make(map[string]interface{})
+
+	defer r.cleanup(e, model, prefix, urls)
+
+	run := &randomRun{failed: -1, prefix: prefix}
+	for i, step := range steps {
+		if usecase != "" {
+			e.publish(&Event{
+				Type:     EVENT_STEP_STARTED,
+				Usecase:  usecase,
+				Step:     step.Name,
+				Executor: step.Executor,
+			})
+		}
+
+		started := time.Now()
+		sctx, cancel := e.stepContext(ctx, step)
+
+		resp, req, attempts, err := e.runStep(sctx, step, outputs)
+		run.req, run.attempts = req, attempts
+		if attempts > 1 {
+			run.retries += attempts - 1
+		}
+
+		if err == nil {
+			if usecase != "" {
+				e.resourceChanged(usecase, step, req, resp)
+			}
+
+			model.apply(ops[i])
+			outputs[step.Name] = stepOutput(req, resp)
+
+			// The URL of the EBS snapshot is that of the backups
+			// of the snapshot
+			if resp != nil && (ops[i].Kind == RANDOM_OP_CREATE_SNAPSHOT || ops[i].Kind == RANDOM_OP_CREATE_BACKUP) {
+				if url, exists := resp.Values[ebs.OPT_BACKUP_URL]; exists {
+					urls[randomSnapshotKey(ops[i].Volume, ops[i].Snapshot)] = fmt.Sprint(url)
+				}
+			}
+
+			err = r.checkInvariants(sctx, e, model, prefix, ops[i], urls)
+		} else if ops[i].Kind == RANDOM_OP_CREATE_VOLUME || ops[i].Kind == RANDOM_OP_RESTORE_VOLUME {
+			// The volume may have been created partially & is
+			// removed along with the others
+			model.apply(ops[i])
+		}
+
+		status := STATUS_OK
+		if err != nil {
+			status = failedStatus(sctx)
+		}
+		cancel()
+
+		if usecase != "" {
+			msg := ""
+			if err != nil {
+				msg = fmt.Sprintf("%s: %s", ops[i], err)
+			}
+			e.stepFinished(usecase, step, status, msg, attempts, time.Since(started))
+		}
+
+		if err != nil {
+			run.failed, run.err, run.status = i, err, status
+			return run
+		}
+	}
+
+	return run
+}
+
+// signature identifies the failure of the run by the kind of the failed
+// operation & its error. The names that are unique to the execution are
+// generalized, so that the failures of different executions of the same
+// bug match.
+func (run *randomRun) signature(ops []*RandomOp) string {
+	if run.err == nil {
+		return ""
+	}
+
+	return ops[run.failed].Kind + ": " + strings.Replace(run.err.Error(), run.prefix, "", -1)
+}
+
+// steps provides the steps that execute the operations. The names of
+// the volumes, snapshots & backups are prefixed by the given prefix.
+func (r *RandomRunner) steps(ops []*RandomOp, prefix string) []*config.Step {
+	// Steps that created the backups, which provide the backup URLs
+	backupSteps := make(map[string]string)
+
+	steps := make([]*config.Step, 0, len(ops))
+	for i, op := range ops {
+		step := &config.Step{
+			Name:    fmt.Sprintf("op-%d", i+1),
+			Driver:  r.driverName,
+			Options: make(map[string]string),
+		}
+
+		switch op.Kind {
+		case RANDOM_OP_CREATE_VOLUME:
+			step.Executor = ebs.EBS_VOLUME_CREATE_EXEC
+			step.Request = prefix + op.Volume
+			for k, v := range r.volumeOptions {
+				step.Options[k] = v
+			}
+		case RANDOM_OP_RESTORE_VOLUME:
+			step.Executor = ebs.EBS_VOLUME_CREATE_EXEC
+			step.Request = prefix + op.Volume
+			step.Options[ebs.OPT_BACKUP_URL] = fmt.Sprintf("${%s.%s}", backupSteps[op.Backup], ebs.OPT_BACKUP_URL)
+		case RANDOM_OP_REMOVE_VOLUME:
+			step.Executor = ebs.EBS_VOLUME_REMOVE_EXEC
+			step.Request = prefix + op.Volume
+		case RANDOM_OP_CREATE_SNAPSHOT:
+			step.Executor = ebs.EBS_SNAP_CREATE_EXEC
+			step.Request = prefix + op.Snapshot
+			step.Options[ebs.OPT_VOLUME_NAME] = prefix + op.Volume
+		case RANDOM_OP_REMOVE_SNAPSHOT:
+			step.Executor = ebs.EBS_SNAP_REMOVE_EXEC
+			step.Request = prefix + op.Snapshot
+			step.Options[ebs.OPT_VOLUME_NAME] = prefix + op.Volume
+		case RANDOM_OP_CREATE_BACKUP:
+			step.Executor = ebs.EBS_BACKUP_CREATE_EXEC
+			step.Request = prefix + op.Backup
+			step.Options[ebs.OPT_SNAPSHOT_ID] = prefix + op.Snapshot
+			step.Options[ebs.OPT_VOLUME_ID] = prefix + op.Volume
+			backupSteps[op.Backup] = step.Name
+		case RANDOM_OP_REMOVE_BACKUP:
+			step.Executor = ebs.EBS_BACKUP_REMOVE_EXEC
+			step.Request = prefix + op.Backup
+			step.Options[ebs.OPT_BACKUP_URL] = fmt.Sprintf("${%s.%s}", backupSteps[op.Backup], ebs.OPT_BACKUP_URL)
+		}
+
+		steps = append(steps, step)
+	}
+
+	return steps
+}
+
+// exec executes the request via the executor of the runner's driver
+func (r *RandomRunner) exec(ctx context.Context, e *ScenarioExec, executor string, req driver.Request) (*driver.Response, error) {
+	exec, err := e.executor(&config.Step{
+		Driver:   r.driverName,
+		Executor: executor,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return driver.ExecContext(ctx, exec, req)
+}
+
+// checkInvariants checks the driver against the model. The volumes &
+// the snapshots of each volume that are listed by the driver should be
+// those of the model, while a created backup should be readable. Only
+// the volumes of this execution, i.e. with its prefix, are compared, as
+// the driver may have others. The URLs of the EBS snapshots are keyed by
+// their snapshots.
+func (r *RandomRunner) checkInvariants(ctx context.Context, e *ScenarioExec, m *randomModel, prefix string, op *RandomOp, urls map[string]string) error {
+	resp, err := r.exec(ctx, e, ebs.EBS_VOLUME_LIST_EXEC, driver.Request{})
+	if err != nil {
+		return fmt.Errorf("invariant: failed to list volumes: %s", err)
+	}
+
+	if err := compareRandomNames("volumes", prefix, resp, m.volumeNames()); err != nil {
+		return err
+	}
+
+	for _, v := range m.volumeNames() {
+		resp, err := r.exec(ctx, e, ebs.EBS_SNAPSHOT_LIST_EXEC, driver.Request{
+			Options: map[string]string{
+				ebs.OPT_VOLUME_NAME: prefix + v,
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("invariant: failed to list snapshots of %s: %s", v, err)
+		}
+
+		if err := compareRandomNames("snapshots of "+v, prefix, resp, m.snapshotNames(v)); err != nil {
+			return err
+		}
+	}
+
+	if op.Kind == RANDOM_OP_CREATE_BACKUP {
+		_, err := r.exec(ctx, e, ebs.EBS_BACKUP_READ_EXEC, driver.Request{
+			Options: map[string]string{
+				ebs.OPT_BACKUP_URL: urls[m.backups[op.Backup]],
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("invariant: failed to read backup %s: %s", op.Backup, err)
+		}
+	}
+
+	return nil
+}
+
+// compareRandomNames compares the names of the response's values that
+// have the prefix with the expected names
+func compareRandomNames(what, prefix string, resp *driver.Response, expected []string) error {
+	listed := []string{}
+	if resp != nil {
+		for name := range resp.Values {
+			if strings.HasPrefix(name, prefix) {
+				listed = append(listed, strings.TrimPrefix(name, prefix))
+			}
+		}
+	}
+	sort.Strings(listed)
+
+	if strings.Join(listed, ",") != strings.Join(expected, ",") {
+		return fmt.Errorf("invariant: %s: expected %v, listed %v", what, expected, listed)
+	}
+
+	return nil
+}
+
+// cleanup removes the volumes of the model & deletes its EBS snapshots,
+// which outlive their volumes & snapshots. This removes the backups too,
+// as they share the EBS snapshots. An EBS snapshot whose URL is not
+// known, e.g. as its creation failed midway, is left as is. Failures
+// are only warned, as the outcome of the sequence is already known.
+func (r *RandomRunner) cleanup(e *ScenarioExec, m *randomModel, prefix string, urls map[string]string) {
+	remove := func(what, executor string, req driver.Request) {
+		ctx, cancel := e.stepContext(context.Background(), &config.Step{})
+		defer cancel()
+
+		if _, err := r.exec(ctx, e, executor, req); err != nil {
+			r.logger.Printf("[WARN] random: failed to remove %s: %s", what, err)
+		}
+	}
+
+	for _, v := range m.volumeNames() {
+		remove("volume "+prefix+v, ebs.EBS_VOLUME_REMOVE_EXEC, driver.Request{Name: prefix + v})
+	}
+
+	for _, key := range m.ebsSnapshotKeys() {
+		if url, exists := urls[key]; exists {
+			remove("EBS snapshot "+url, ebs.EBS_BACKUP_REMOVE_EXEC, driver.Request{
+				Options: map[string]string{
+					ebs.OPT_BACKUP_URL: url,
+				},
+			})
+		}
+	}
+}
+
+// shrink runs the failed operations again without each of them, one at
+// a time. A run that still fails with the given signature, i.e. the
+// same kind of operation fails with the same error, replaces the
+// operations with those up to its failure, after which shrinking starts
+// over. A run that fails otherwise hit a different bug & is ignored.
+// Shrinking stops when no operation can be dropped, the max runs are
+// made or the context is done. The operations that remain are returned along with
+// the no of runs made.
+func (r *RandomRunner) shrink(ctx context.Context, e *ScenarioExec, ops []*RandomOp, signature string) ([]*RandomOp, int) {
+	runs := 0
+	for {
+		shrunk := false
+
+		for i := 0; i < len(ops) && runs < r.maxShrinkRuns && ctx.Err() == nil; i++ {
+			candidate := make([]*RandomOp, 0, len(ops)-1)
+			candidate = append(candidate, ops[:i]...)
+			candidate = append(candidate, ops[i+1:]...)
+
+			// Dropping an operation can invalidate the later ones,
+			// e.g. a snapshot of a volume that is not created
+			if len(candidate) == 0 || !validRandomOps(candidate) {
+				continue
+			}
+
+			runs++
+			run := r.execute(ctx, e, candidate, "")
+			if run.status == STATUS_FAILED && run.signature(candidate) == signature {
+				ops = candidate[:run.failed+1]
+				shrunk = true
+				break
+			}
+		}
+
+		if !shrunk {
+			return ops, runs
+		}
+	}
+}
+
+// generateRandomOps generates a sequence of n operations from the seed.
+// Each operation is picked amongst those whose preconditions hold as
+// per the model, which makes the sequence valid. The same seed always
+// generates the same sequence.
+func generateRandomOps(seed int64, n int) []*RandomOp {
+	rng := rand.New(rand.NewSource(seed))
+	m := newRandomModel()
+
+	ops := make([]*RandomOp, 0, n)
+	for len(ops) < n {
+		kinds := m.kinds()
+		op := m.newOp(kinds[rng.Intn(len(kinds))], rng)
+		m.apply(op)
+		ops = append(ops, op)
+	}
+
+	return ops
+}
+
+// validRandomOps checks if the preconditions of each operation hold
+// after its earlier operations
+func validRandomOps(ops []*RandomOp) bool {
+	m := newRandomModel()
+	for _, op := range ops {
+		if !m.allows(op) {
+			return false
+		}
+		m.apply(op)
+	}
+
+	return true
+}
+
+// randomModel is the expected state of the volumes, snapshots & backups
+// of a sequence. A snapshot is backed by an EBS snapshot, which outlives
+// the snapshot & its volume. The backups of a snapshot share its EBS
+// snapshot, hence removing a backup deletes the EBS snapshot & thereby
+// the other backups of the snapshot. The snapshot is still listed by its
+// volume, but can not be backed up any more.
+type randomModel struct {
+	// Snapshots of each volume
+	volumes map[string]map[string]struct{}
+
+	// EBS snapshots that are not deleted, keyed by their snapshots
+	ebsSnapshots map[string]struct{}
+
+	// Backups along with the key of the EBS snapshot they share
+	backups map[string]string
+
+	// Last no used in the names of the volumes, snapshots & backups
+	last int
+}
+
+func newRandomModel() *randomModel {
+	return &randomModel{
+		volumes:      make(map[string]map[string]struct{}),
+		ebsSnapshots: make(map[string]struct{}),
+		backups:      make(map[string]string),
+	}
+}
+
+// randomSnapshotKey provides the key of the snapshot of the volume
+func randomSnapshotKey(volume, snapshot string) string {
+	return volume + "/" + snapshot
+}
+
+// kinds provides the kinds of the operations whose preconditions can be
+// met, in a fixed order
+func (m *randomModel) kinds() []string {
+	kinds := []string{RANDOM_OP_CREATE_VOLUME}
+
+	if len(m.volumes) > 0 {
+		kinds = append(kinds, RANDOM_OP_REMOVE_VOLUME, RANDOM_OP_CREATE_SNAPSHOT)
+	}
+
+	if len(m.snapshotKeys(false)) > 0 {
+		kinds = append(kinds, RANDOM_OP_REMOVE_SNAPSHOT)
+	}
+
+	if len(m.snapshotKeys(true)) > 0 {
+		kinds = append(kinds, RANDOM_OP_CREATE_BACKUP)
+	}
+
+	if len(m.backups) > 0 {
+		kinds = append(kinds, RANDOM_OP_REMOVE_BACKUP, RANDOM_OP_RESTORE_VOLUME)
+	}
+
+	return kinds
+}
+
+// newOp provides an operation of the kind whose preconditions hold. The
+// volumes, snapshots & backups that are operated upon are picked via
+// the given source.
+func (m *randomModel) newOp(kind string, rng *rand.Rand) *RandomOp {
+	op := &RandomOp{Kind: kind}
+
+	switch kind {
+	case RANDOM_OP_CREATE_VOLUME:
+		op.Volume = m.newName("vol")
+	case RANDOM_OP_RESTORE_VOLUME:
+		op.Backup = pickRandomName(rng, m.backupNames())
+		op.Volume = m.newName("vol")
+	case RANDOM_OP_REMOVE_VOLUME:
+		op.Volume = pickRandomName(rng, m.volumeNames())
+	case RANDOM_OP_CREATE_SNAPSHOT:
+		op.Volume = pickRandomName(rng, m.volumeNames())
+		op.Snapshot = m.newName("snap")
+	case RANDOM_OP_REMOVE_SNAPSHOT, RANDOM_OP_CREATE_BACKUP:
+		snaps := m.snapshotKeys(kind == RANDOM_OP_CREATE_BACKUP)
+
+		parts := strings.SplitN(pickRandomName(rng, snaps), "/", 2)
+		op.Volume, op.Snapshot = parts[0], parts[1]
+		if kind == RANDOM_OP_CREATE_BACKUP {
+			op.Backup = m.newName("backup")
+		}
+	case RANDOM_OP_REMOVE_BACKUP:
+		op.Backup = pickRandomName(rng, m.backupNames())
+	}
+
+	return op
+}
+
+// newName provides a name that is not used by the sequence so far
+func (m *randomModel) newName(kind string) string {
+	m.last++
+	return fmt.Sprintf("%s-%d", kind, m.last)
+}
+
+// allows checks if the preconditions of the operation hold
+func (m *randomModel) allows(op *RandomOp) bool {
+	_, volExists := m.volumes[op.Volume]
+	_, snapExists := m.volumes[op.Volume][op.Snapshot]
+	_, ebsSnapExists := m.ebsSnapshots[randomSnapshotKey(op.Volume, op.Snapshot)]
+	_, backupExists := m.backups[op.Backup]
+
+	switch op.Kind {
+	case RANDOM_OP_CREATE_VOLUME:
+		return !volExists
+	case RANDOM_OP_RESTORE_VOLUME:
+		return !volExists && backupExists
+	case RANDOM_OP_REMOVE_VOLUME:
+		return volExists
+	case RANDOM_OP_CREATE_SNAPSHOT:
+		return volExists && !snapExists
+	case RANDOM_OP_REMOVE_SNAPSHOT:
+		return snapExists
+	case RANDOM_OP_CREATE_BACKUP:
+		return snapExists && ebsSnapExists && !backupExists
+	case RANDOM_OP_REMOVE_BACKUP:
+		return backupExists
+	}
+
+	return false
+}
+
+// apply updates the model with the outcome of the operation
+func (m *randomModel) apply(op *RandomOp) {
+	switch op.Kind {
+	case RANDOM_OP_CREATE_VOLUME, RANDOM_OP_RESTORE_VOLUME:
+		m.volumes[op.Volume] = make(map[string]struct{})
+	case RANDOM_OP_REMOVE_VOLUME:
+		delete(m.volumes, op.Volume)
+	case RANDOM_OP_CREATE_SNAPSHOT:
+		m.volumes[op.Volume][op.Snapshot] = struct{}{}
+		m.ebsSnapshots[randomSnapshotKey(op.Volume, op.Snapshot)] = struct{}{}
+	case RANDOM_OP_REMOVE_SNAPSHOT:
+		delete(m.volumes[op.Volume], op.Snapshot)
+	case RANDOM_OP_CREATE_BACKUP:
+		m.backups[op.Backup] = randomSnapshotKey(op.Volume, op.Snapshot)
+	case RANDOM_OP_REMOVE_BACKUP:
+		key := m.backups[op.Backup]
+		delete(m.ebsSnapshots, key)
+		for b, k := range m.backups {
+			if k == key {
+				delete(m.backups, b)
+			}
+		}
+	}
+}
+
+// volumeNames provides the names of the volumes in their sorted order
+func (m *randomModel) volumeNames() []string {
+	names := make([]string, 0, len(m.volumes))
+	for v := range m.volumes {
+		names = append(names, v)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// snapshotNames provides the names of the snapshots of the volume in
+// their sorted order
+func (m *randomModel) snapshotNames(volume string) []string {
+	names := make([]string, 0, len(m.volumes[volume]))
+	for s := range m.volumes[volume] {
+		names = append(names, s)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// snapshotKeys provides the keys of the snapshots of the volumes in their
+// sorted order. If backupable is set, only the snapshots whose EBS
+// snapshots are not deleted are provided.
+func (m *randomModel) snapshotKeys(backupable bool) []string {
+	var keys []string
+	for _, v := range m.volumeNames() {
+		for _, s := range m.snapshotNames(v) {
+			key := randomSnapshotKey(v, s)
+			if _, exists := m.ebsSnapshots[key]; exists || !backupable {
+				keys = append(keys, key)
+			}
+		}
+	}
+
+	return keys
+}
+
+// ebsSnapshotKeys provides the keys of the EBS snapshots that are not
+// deleted in their sorted order
+func (m *randomModel) ebsSnapshotKeys() []string {
+	keys := make([]string, 0, len(m.ebsSnapshots))
+	for key := range m.ebsSnapshots {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// backupNames provides the names of the backups in their sorted order
+func (m *randomModel) backupNames() []string {
+	names := make([]string, 0, len(m.backups))
+	for b := range m.backups {
+		names = append(names, b)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// pickRandomName picks one of the names via the given source
+func pickRandomName(rng *rand.Rand, names []string) string {
+	return names[rng.Intn(len(names))]
+}
@@ -0,0 +1,339 @@
+package mtest
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+	"github.com/openebs/mtest/driver/ebs"
+)
+
+const memDriverName = "mtest.mem"
+
+// memDriver keeps the volumes & snapshots in memory & aligns to the
+// executors of the EBS driver. The backups of a snapshot share its
+// EBS snapshot, i.e. its URL. If its config has a bug, the second
+// snapshot of a volume is silently not created.
+type memDriver struct {
+	bug bool
+
+	m       sync.Mutex
+	volumes map[string]map[string]struct{}
+
+	// EBS snapshots by their URLs
+	ebsSnapshots map[string]struct{}
+}
+
+type memExecutor func(req driver.Request) (*driver.Response, error)
+
+// memDrivers are the instances of memDriver in their creation order
+var memDrivers []*memDriver
+
+func init() {
+	driver.Register(memDriverName, func(root string, config map[string]string) (driver.MtestDriver, error) {
+		d := &memDriver{
+			bug:          config["bug"] == "true",
+			volumes:      make(map[string]map[string]struct{}),
+			ebsSnapshots: make(map[string]struct{}),
+		}
+		memDrivers = append(memDrivers, d)
+		return d, nil
+	})
+}
+
+func (e memExecutor) Exec(req driver.Request) (*driver.Response, error) {
+	return e(req)
+}
+
+func (d *memDriver) Name() string {
+	return memDriverName
+}
+
+func (d *memDriver) Info() (map[string]string, error) {
+	return nil, nil
+}
+
+func (d *memDriver) Executors(hints ...string) (map[string]driver.Executor, error) {
+	execs := make(map[string]driver.Executor)
+	for _, hint := range hints {
+		hint := hint
+		execs[hint] = memExecutor(func(req driver.Request) (*driver.Response, error) {
+			d.m.Lock()
+			defer d.m.Unlock()
+
+			values, err := d.exec(hint, req)
+			if err != nil {
+				return nil, err
+			}
+			return &driver.Response{Values: values}, nil
+		})
+	}
+	return execs, nil
+}
+
+func (d *memDriver) exec(hint string, req driver.Request) (map[string]interface{}, error) {
+	values := make(map[string]interface{})
+	vol := req.Options[ebs.OPT_VOLUME_NAME]
+
+	switch hint {
+	case ebs.EBS_VOLUME_CREATE_EXEC:
+		if _, exists := d.volumes[req.Name]; exists {
+			return nil, fmt.Errorf("volume %s exists", req.Name)
+		}
+		if url, restore := req.Options[ebs.OPT_BACKUP_URL]; restore {
+			if _, exists := d.ebsSnapshots[url]; !exists {
+				return nil, fmt.Errorf("backup %s not found", url)
+			}
+		}
+		d.volumes[req.Name] = make(map[string]struct{})
+	case ebs.EBS_VOLUME_REMOVE_EXEC:
+		if _, exists := d.volumes[req.Name]; !exists {
+			return nil, fmt.Errorf("volume %s not found", req.Name)
+		}
+		delete(d.volumes, req.Name)
+	case ebs.EBS_VOLUME_LIST_EXEC:
+		for v := range d.volumes {
+			values[v] = map[string]interface{}{}
+		}
+	case ebs.EBS_SNAP_CREATE_EXEC:
+		snaps, exists := d.volumes[vol]
+		if !exists {
+			return nil, fmt.Errorf("volume %s not found", vol)
+		}
+		if !d.bug || len(snaps) == 0 {
+			snaps[req.Name] = struct{}{}
+
+			url := "mem://" + vol + "/" + req.Name
+			d.ebsSnapshots[url] = struct{}{}
+			values[ebs.OPT_BACKUP_URL] = url
+		}
+	case ebs.EBS_SNAP_REMOVE_EXEC:
+		if _, exists := d.volumes[vol][req.Name]; !exists {
+			return nil, fmt.Errorf("snapshot %s not found", req.Name)
+		}
+		delete(d.volumes[vol], req.Name)
+	case ebs.EBS_SNAPSHOT_LIST_EXEC:
+		for s := range d.volumes[vol] {
+			values[s] = map[string]interface{}{}
+		}
+	case ebs.EBS_BACKUP_CREATE_EXEC:
+		vol, snap := req.Options[ebs.OPT_VOLUME_ID], req.Options[ebs.OPT_SNAPSHOT_ID]
+		if _, exists := d.volumes[vol][snap]; !exists {
+			return nil, fmt.Errorf("snapshot %s not found", snap)
+		}
+		url := "mem://" + vol + "/" + snap
+		if _, exists := d.ebsSnapshots[url]; !exists {
+			return nil, fmt.Errorf("EBS snapshot of %s is deleted", snap)
+		}
+		values[ebs.OPT_BACKUP_URL] = url
+	case ebs.EBS_BACKUP_READ_EXEC, ebs.EBS_BACKUP_REMOVE_EXEC:
+		url := req.Options[ebs.OPT_BACKUP_URL]
+		if _, exists := d.ebsSnapshots[url]; !exists {
+			return nil, fmt.Errorf("backup %s not found", url)
+		}
+		if hint == ebs.EBS_BACKUP_REMOVE_EXEC {
+			delete(d.ebsSnapshots, url)
+		}
+	default:
+		return nil, fmt.Errorf("unknown executor %s", hint)
+	}
+
+	return values, nil
+}
+
+func newTestRandomRunner(t *testing.T, random *config.Random, bug bool) *RandomRunner {
+	random.Driver = memDriverName
+
+	maker, err := NewRandomRunMaker(ioutil.Discard, &config.MtestConfig{Random: random})
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	r := maker.(*MtestMake).runner.(*RandomRunner)
+	r.logger = log.New(ioutil.Discard, "", 0)
+	r.driverConfig = func(name string) map[string]string {
+		return map[string]string{"bug": fmt.Sprint(bug)}
+	}
+	return r
+}
+
+func TestGenerateRandomOps(t *testing.T) {
+	ops := generateRandomOps(42, 200)
+	if len(ops) != 200 {
+		t.Fatalf("expected 200 ops, got %d", len(ops))
+	}
+
+	if !validRandomOps(ops) {
+		t.Fatalf("expected a valid sequence, got %s", joinRandomOps(ops))
+	}
+
+	if !reflect.DeepEqual(ops, generateRandomOps(42, 200)) {
+		t.Fatalf("expected the same sequence from the same seed")
+	}
+
+	if reflect.DeepEqual(ops, generateRandomOps(43, 200)) {
+		t.Fatalf("expected different sequences from different seeds")
+	}
+
+	kinds := make(map[string]bool)
+	for _, op := range ops {
+		kinds[op.Kind] = true
+	}
+	if len(kinds) != 7 {
+		t.Fatalf("expected all the kinds of ops, got %v", kinds)
+	}
+}
+
+func TestValidRandomOps(t *testing.T) {
+	ops := []*RandomOp{
+		{Kind: RANDOM_OP_CREATE_VOLUME, Volume: "vol-1"},
+		{Kind: RANDOM_OP_CREATE_SNAPSHOT, Volume: "vol-1", Snapshot: "snap-2"},
+		{Kind: RANDOM_OP_CREATE_BACKUP, Volume: "vol-1", Snapshot: "snap-2", Backup: "backup-3"},
+		{Kind: RANDOM_OP_REMOVE_VOLUME, Volume: "vol-1"},
+		{Kind: RANDOM_OP_RESTORE_VOLUME, Volume: "vol-4", Backup: "backup-3"},
+	}
+	if !validRandomOps(ops) {
+		t.Fatalf("expected a valid sequence")
+	}
+
+	// A snapshot of a volume that is not created
+	if validRandomOps(ops[1:]) {
+		t.Fatalf("expected an invalid sequence")
+	}
+
+	// A snapshot of a removed volume
+	if validRandomOps(append(ops[:4:4], ops[1])) {
+		t.Fatalf("expected an invalid sequence")
+	}
+
+	// Backups of a snapshot share its EBS snapshot, which is deleted
+	// along with any of the backups
+	shared := []*RandomOp{
+		{Kind: RANDOM_OP_CREATE_VOLUME, Volume: "vol-1"},
+		{Kind: RANDOM_OP_CREATE_SNAPSHOT, Volume: "vol-1", Snapshot: "snap-2"},
+		{Kind: RANDOM_OP_CREATE_BACKUP, Volume: "vol-1", Snapshot: "snap-2", Backup: "backup-3"},
+		{Kind: RANDOM_OP_CREATE_BACKUP, Volume: "vol-1", Snapshot: "snap-2", Backup: "backup-4"},
+		{Kind: RANDOM_OP_REMOVE_BACKUP, Backup: "backup-3"},
+	}
+	if !validRandomOps(shared) {
+		t.Fatalf("expected a valid sequence")
+	}
+
+	for _, op := range []*RandomOp{
+		{Kind: RANDOM_OP_RESTORE_VOLUME, Volume: "vol-5", Backup: "backup-4"},
+		{Kind: RANDOM_OP_REMOVE_BACKUP, Backup: "backup-4"},
+		{Kind: RANDOM_OP_CREATE_BACKUP, Volume: "vol-1", Snapshot: "snap-2", Backup: "backup-5"},
+	} {
+		if validRandomOps(append(shared[:5:5], op)) {
+			t.Fatalf("expected %s to be invalid after the EBS snapshot is deleted", op)
+		}
+	}
+
+	// The snapshot is still removable
+	if !validRandomOps(append(shared[:5:5], &RandomOp{Kind: RANDOM_OP_REMOVE_SNAPSHOT, Volume: "vol-1", Snapshot: "snap-2"})) {
+		t.Fatalf("expected a valid sequence")
+	}
+}
+
+func TestRandomRunner_Run(t *testing.T) {
+	r := newTestRandomRunner(t, &config.Random{Seed: 7, Sequences: 3, Ops: 40}, false)
+
+	reports, err := r.Run()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if len(reports) != 3 {
+		t.Fatalf("expected 3 reports, got %d", len(reports))
+	}
+
+	for i, rpt := range reports {
+		if !rpt.Success {
+			t.Fatalf("expected success, got %s", rpt)
+		}
+
+		res := rpt.Message.(*RandomResult)
+		if res.Seed != int64(7+i) || len(res.Ops) != 40 {
+			t.Fatalf("bad result: %s", res)
+		}
+		if rpt.Usecase != fmt.Sprintf("random.seed.%d", 7+i) {
+			t.Fatalf("bad use case: %s", rpt.Usecase)
+		}
+	}
+
+	// The volumes & EBS snapshots of the sequences are deleted
+	d := memDrivers[len(memDrivers)-1]
+	if len(d.volumes) != 0 || len(d.ebsSnapshots) != 0 {
+		t.Fatalf("expected nothing to be left, got %v & %v", d.volumes, d.ebsSnapshots)
+	}
+}
+
+func TestRandomRunner_Shrink(t *testing.T) {
+	r := newTestRandomRunner(t, &config.Random{Seed: 1, Sequences: 5, Ops: 40}, true)
+
+	reports, err := r.Run()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	var failed *RandomResult
+	for _, rpt := range reports {
+		if !rpt.Success {
+			failed = rpt.Message.(*RandomResult)
+			break
+		}
+	}
+	if failed == nil {
+		t.Fatalf("expected a sequence to fail")
+	}
+
+	if failed.FailedOp == 0 || failed.Error == "" || failed.ShrinkRuns == 0 {
+		t.Fatalf("bad result: %s", failed)
+	}
+
+	if len(failed.Minimal) == 0 || len(failed.Minimal) > failed.FailedOp || !validRandomOps(failed.Minimal) {
+		t.Fatalf("bad minimal reproduction: %s", joinRandomOps(failed.Minimal))
+	}
+
+	// The minimal reproduction fails as is & alike the sequence
+	e := NewScenarioExec(MTEST_RANDOM_RUNNER_NAME, r.logger)
+	e.DriverConfig = r.driverConfig
+	run := r.execute(context.Background(), e, failed.Minimal, "")
+	if run.err == nil || run.failed != len(failed.Minimal)-1 {
+		t.Fatalf("expected the minimal reproduction to fail at its last op, got %d: %v", run.failed, run.err)
+	}
+
+	original := r.execute(context.Background(), e, failed.Ops[:failed.FailedOp], "")
+	if run.signature(failed.Minimal) != original.signature(failed.Ops) {
+		t.Fatalf("expected the minimal reproduction to fail alike\nwant: %s\n got: %s",
+			original.signature(failed.Ops), run.signature(failed.Minimal))
+	}
+
+	// The runs that fail otherwise are not taken as reproductions
+	ops := failed.Ops[:failed.FailedOp]
+	minimal, runs := r.shrink(context.Background(), e, ops, "create-volume: some other bug")
+	if runs == 0 || !reflect.DeepEqual(minimal, ops) {
+		t.Fatalf("expected no shrinking after %d run(s), got %s", runs, joinRandomOps(minimal))
+	}
+
+	// The sequence is replayed exactly via its seed
+	replay := newTestRandomRunner(t, &config.Random{Seed: failed.Seed, Ops: 40, MaxShrinkRuns: -1}, true)
+	reports, err = replay.Run()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	res := reports[0].Message.(*RandomResult)
+	if !reflect.DeepEqual(res.Ops, failed.Ops) || res.FailedOp != failed.FailedOp || res.Error != failed.Error {
+		t.Fatalf("expected the replay to fail alike\nwant: %s\n got: %s", failed, res)
+	}
+	if len(res.Minimal) != 0 || res.ShrinkRuns != 0 {
+		t.Fatalf("expected no shrinking, got %s", res)
+	}
+}
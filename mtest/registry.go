@@ -0,0 +1,72 @@
+package mtest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/openebs/mtest/config"
+)
+
+// RunnerFactory is the function that builds a MtestMaker for each
+// Runner. The runner's logs are written to the provided log writer
+// while the runner is configured from the provided mtest config.
+//
+// Each runner must implement this function and register itself
+// through RegisterRunner().
+type RunnerFactory func(logWriter io.Writer, mconfig *config.MtestConfig) (MtestMaker, error)
+
+var (
+	factories map[string]RunnerFactory
+)
+
+func init() {
+	makeFactories()
+}
+
+func makeFactories() {
+	if len(factories) == 0 {
+		factories = make(map[string]RunnerFactory)
+	}
+}
+
+// RegisterRunner registers runners, i.e. add implemented runners via
+// `RunnerFactory` to the known runner list.
+func RegisterRunner(name string, factory RunnerFactory) error {
+
+	// This voids the ordering of init() calls of this .go file
+	// & the runners' .go files.
+	makeFactories()
+
+	_, exists := factories[name]
+
+	if exists {
+		return fmt.Errorf("Runner %s has already been registered", name)
+	}
+
+	factories[name] = factory
+	return nil
+}
+
+// GetRunner would be called each time when a MtestMaker of a
+// registered Runner is needed.
+func GetRunner(name string, logWriter io.Writer, mconfig *config.MtestConfig) (MtestMaker, error) {
+	factory, exists := factories[name]
+
+	if !exists {
+		return nil, fmt.Errorf("Runner '%v' is not supported!", name)
+	}
+
+	return factory(logWriter, mconfig)
+}
+
+// Runners returns the sorted names of the registered runners.
+func Runners() []string {
+	names := make([]string, 0, len(factories))
+	for name := range factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
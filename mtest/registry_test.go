@@ -0,0 +1,42 @@
+package mtest
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegisterRunner(t *testing.T) {
+	if err := RegisterRunner(MTEST_MSERVER_RUNNER_NAME, NewMserverRunMaker); err == nil {
+		t.Fatalf("expected error on registering a runner twice, got nothing")
+	}
+
+	found := false
+	for _, name := range Runners() {
+		if name == MTEST_MSERVER_RUNNER_NAME {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected %s in %v", MTEST_MSERVER_RUNNER_NAME, Runners())
+	}
+}
+
+func TestGetRunner(t *testing.T) {
+	if _, err := GetRunner("unicorn.runner", &bytes.Buffer{}, nil); err == nil {
+		t.Fatalf("expected error, got nothing")
+	}
+
+	maker, err := GetRunner(MTEST_MSERVER_RUNNER_NAME, &bytes.Buffer{}, nil)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	mt, err := maker.Make()
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	if mt.runner.Name() != MTEST_MSERVER_RUNNER_NAME {
+		t.Fatalf("bad runner: %s", mt.runner.Name())
+	}
+}
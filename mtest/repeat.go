@@ -0,0 +1,106 @@
+package mtest
+
+import "fmt"
+
+const (
+	// Classification of a use case that passed in all of its runs
+	// at the first attempt of each of its steps
+	CLASS_STABLE = "STABLE"
+
+	// Classification of a use case that failed in all of its runs
+	CLASS_FAILING = "FAILING"
+
+	// Classification of a use case that failed in some of its runs,
+	// or that passed only after retries of a step
+	CLASS_FLAKY = "FLAKY"
+)
+
+// PassRate is the outcome of a use case across repeated runs.
+type PassRate struct {
+	Runner  string
+	Usecase string
+
+	// No of runs of the use case
+	Runs int
+
+	// No of runs in which the use case passed
+	Passed int
+
+	// No of runs in which the use case passed only after a step
+	// was retried
+	Retried int
+
+	// No of failed runs per status
+	Failures map[string]int
+
+	// Passed runs as a fraction of all the runs
+	Rate float64
+
+	// One of STABLE, FAILING or FLAKY
+	Class string
+}
+
+// String provides a single line & readable form of the pass rate
+func (p *PassRate) String() string {
+	return fmt.Sprintf("%s %s [%s]: %d/%d passed (%.1f%%), %d retried, failures: %v",
+		p.Runner, p.Usecase, p.Class, p.Passed, p.Runs, p.Rate*100, p.Retried, p.Failures)
+}
+
+// PassRates aggregates the reports of the repeated runs into the pass
+// rate of each use case. The use cases are in the order they were
+// first run.
+func PassRates(runs [][]*Report) []*PassRate {
+	type key struct {
+		runner  string
+		usecase string
+	}
+
+	var rates []*PassRate
+	index := make(map[key]*PassRate)
+
+	for _, reports := range runs {
+		for _, r := range reports {
+			if r == nil {
+				continue
+			}
+
+			k := key{r.Runner, r.Usecase}
+			p, exists := index[k]
+			if !exists {
+				p = &PassRate{
+					Runner:   r.Runner,
+					Usecase:  r.Usecase,
+					Failures: make(map[string]int),
+				}
+				index[k] = p
+				rates = append(rates, p)
+			}
+
+			p.Runs++
+			if !r.Success {
+				p.Failures[r.Status]++
+				continue
+			}
+
+			p.Passed++
+			if r.Retries > 0 {
+				p.Retried++
+			}
+		}
+	}
+
+	for _, p := range rates {
+		p.Rate = float64(p.Passed) / float64(p.Runs)
+
+		switch {
+		case p.Passed == 0:
+			p.Class = CLASS_FAILING
+		case p.Passed < p.Runs || p.Retried > 0:
+			p.Class = CLASS_FLAKY
+		default:
+			p.Class = CLASS_STABLE
+		}
+	}
+
+	return rates
+}
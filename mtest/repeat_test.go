@@ -0,0 +1,52 @@
+package mtest
+
+import "testing"
+
+func TestPassRates(t *testing.T) {
+	// The retried use case is retried at an earlier step than its last
+	run := func(stable, flaky, failing bool, retries int) []*Report {
+		return []*Report{
+			{Runner: "r", Usecase: "stable", Success: stable, Status: STATUS_OK, Attempts: 1},
+			{Runner: "r", Usecase: "flaky", Success: flaky, Status: STATUS_TIMEOUT, Attempts: 1},
+			{Runner: "r", Usecase: "failing", Success: failing, Status: STATUS_FAILED, Attempts: 1},
+			{Runner: "r", Usecase: "retried", Success: true, Status: STATUS_OK, Attempts: 1, Retries: retries},
+		}
+	}
+
+	rates := PassRates([][]*Report{
+		run(true, true, false, 0),
+		run(true, false, false, 1),
+		run(true, true, false, 0),
+		run(true, true, false, 0),
+	})
+
+	if len(rates) != 4 {
+		t.Fatalf("expected 4 use cases, got %d", len(rates))
+	}
+
+	expected := []struct {
+		Usecase string
+		Passed  int
+		Class   string
+	}{
+		{"stable", 4, CLASS_STABLE},
+		{"flaky", 3, CLASS_FLAKY},
+		{"failing", 0, CLASS_FAILING},
+		{"retried", 4, CLASS_FLAKY},
+	}
+
+	for i, e := range expected {
+		p := rates[i]
+		if p.Usecase != e.Usecase || p.Runs != 4 || p.Passed != e.Passed || p.Class != e.Class {
+			t.Fatalf("bad pass rate %d: %#v", i, p)
+		}
+	}
+
+	if rates[1].Rate != 0.75 || rates[1].Failures[STATUS_TIMEOUT] != 1 {
+		t.Fatalf("bad flaky pass rate: %#v", rates[1])
+	}
+
+	if rates[3].Retried != 1 {
+		t.Fatalf("bad retried pass rate: %#v", rates[3])
+	}
+}
@@ -0,0 +1,667 @@
+package mtest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+	"github.com/openebs/mtest/driver/ebs"
+	"github.com/openebs/mtest/util"
+)
+
+// refRegex matches a reference to the output of an earlier step,
+// e.g. ${create.Name} or ${snap.snap.EBSID}
+var refRegex = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// UNIQUE_REF is the builtin reference that resolves to a value which
+// is unique to each execution of a scenario, e.g. vol-${unique}. This
+// lets a scenario be executed repeatedly without name conflicts.
+const UNIQUE_REF = "unique"
+
+// DefaultScenarios provides the scenarios that are run when none have
+// been configured. It creates a single volume.
+func DefaultScenarios() []*config.Scenario {
+	return []*config.Scenario{
+		{
+			Name: MSERVER_VOLUME_CREATE_USECASE,
+			Tags: []string{"volume", "smoke"},
+			Steps: []*config.Step{
+				{
+					Name:     "create",
+					Driver:   ebs.DRIVER_NAME,
+					Executor: ebs.EBS_VOLUME_CREATE_EXEC,
+					Request:  "vol1",
+				},
+			},
+		},
+	}
+}
+
+// DefaultSoakScenarios provides the scenarios that are run in cycles
+// while soaking when none have been configured. It creates a volume,
+// snapshots it & removes both of them. The names are unique to each
+// cycle, so that a failed cycle does not affect the later ones.
+func DefaultSoakScenarios() []*config.Scenario {
+	return []*config.Scenario{
+		{
+			Name: MSERVER_VOLUME_SOAK_USECASE,
+			Tags: []string{"volume", "snapshot", "soak"},
+			Steps: []*config.Step{
+				{
+					Name:     "create",
+					Driver:   ebs.DRIVER_NAME,
+					Executor: ebs.EBS_VOLUME_CREATE_EXEC,
+					Request:  "vol-${unique}",
+				},
+				{
+					Name:     "snap",
+					Driver:   ebs.DRIVER_NAME,
+					Executor: ebs.EBS_SNAP_CREATE_EXEC,
+					Request:  "snap-${unique}",
+					Options: map[string]string{
+						ebs.OPT_VOLUME_NAME: "${create.Name}",
+					},
+				},
+				{
+					Name:     "unsnap",
+					Driver:   ebs.DRIVER_NAME,
+					Executor: ebs.EBS_SNAP_REMOVE_EXEC,
+					Request:  "${snap.Name}",
+					Options: map[string]string{
+						ebs.OPT_VOLUME_NAME: "${create.Name}",
+					},
+				},
+				{
+					Name:     "remove",
+					Driver:   ebs.DRIVER_NAME,
+					Executor: ebs.EBS_VOLUME_REMOVE_EXEC,
+					Request:  "${create.Name}",
+				},
+			},
+		},
+	}
+}
+
+// ScenarioExec executes the steps of scenarios in their declared
+// order i.e. a **chain of execution**.
+//
+// The outputs of each executed step are made available to the later
+// steps of the same scenario via ${<step>.<key>} references in the
+// request name & options.
+//
+// NOTE: Drivers are initialized once & are shared across scenarios.
+type ScenarioExec struct {
+	// Worker identifies the executor in the logs when use cases
+	// are being run in parallel
+	Worker string
+
+	// StepTimeout is the deadline of a step that does not
+	// specify its own timeout. No deadline is set if 0.
+	StepTimeout time.Duration
+
+	// RetryInterval is the wait between the attempts of a step
+	RetryInterval time.Duration
+
+	// Version & Revision of mtest that are set on the reports
+	Version  string
+	Revision string
+
+	// Latencies, if set, records the latency of each execution of
+	// a step against the step's executor. The time that the execution
+	// waited on other executions, e.g. for the attach lock of an EC2
+	// instance, is excluded.
+	Latencies *Latencies
+
+	// Waits, if set, records the time that each execution of a step
+	// waited on other executions against the step's executor
+	Waits *Latencies
+
+	// DriverConfig, if set, provides the config of a driver when
+	// the driver is initialized, e.g. the faults it should inject
+	DriverConfig func(name string) map[string]string
+
+	// DryRun initializes the drivers for a dry run. The scenarios
+	// can then be planned but not run.
+	DryRun bool
+
+	// Checkpoint, if set, records the progress of the scenarios. A
+	// scenario that has passed as per the checkpoint is not run again,
+	// while others continue after their last completed step.
+	Checkpoint *Checkpoint
+
+	// Events, if set, gets the events of the scenarios, i.e. the start
+	// & end of each scenario & step & the resources created or deleted
+	// by the steps
+	Events *EventBroker
+
+	// StepExecutor, if set, provides the executor of a step in place
+	// of the executor of the step's driver, e.g. one that executes the
+	// step on another node
+	StepExecutor func(step *config.Step) (driver.Executor, error)
+
+	runner  string
+	logger  *log.Logger
+	m       sync.Mutex
+	drivers map[string]driver.MtestDriver
+}
+
+// NewScenarioExec returns a new instance of ScenarioExec
+func NewScenarioExec(runner string, logger *log.Logger) *ScenarioExec {
+	return &ScenarioExec{
+		runner:        runner,
+		logger:        logger,
+		drivers:       make(map[string]driver.MtestDriver),
+		RetryInterval: DEFAULT_STEP_RETRY_INTERVAL,
+	}
+}
+
+// Run executes all the steps of the scenario & returns the report of
+// the scenario. Execution stops at the first failed step. A step fails
+// if its executor fails or any of its assertions fail. A step that
+// expects an error fails if its executor does not fail with it.
+//
+// A step that does not complete within its deadline is reported as
+// TIMEOUT, while a step that is aborted due to cancellation of the
+// context is reported as CANCELLED.
+//
+// The report carries the request, attempts & driver info of the last
+// executed step, so that a failed report can be triaged as is.
+func (e *ScenarioExec) Run(ctx context.Context, s *config.Scenario) *Report {
+	outputs := map[string]interface{}{
+		UNIQUE_REF: uniqueValue(),
+	}
+
+	done := 0
+	if e.Checkpoint != nil {
+		if r := e.Checkpoint.Report(s); r != nil {
+			e.logf("INFO", "%s: passed in the resumed run, not run again", s.Name)
+			e.usecaseFinished(r)
+			return r
+		}
+
+		if done = e.Checkpoint.Restore(s, outputs); done > 0 {
+			e.logf("INFO", "%s: resuming after step '%s'", s.Name, s.Steps[done-1].Name)
+		}
+	}
+
+	report := &Report{
+		Runner:   e.runner,
+		Usecase:  s.Name,
+		Started:  time.Now(),
+		Version:  e.Version,
+		Revision: e.Revision,
+	}
+
+	e.publish(&Event{
+		Type:    EVENT_USECASE_STARTED,
+		Usecase: s.Name,
+	})
+
+	for _, step := range s.Steps[done:] {
+		e.logf("INFO", "%s: starting step '%s' with executor '%s'", s.Name, step.Name, step.Executor)
+		e.publish(&Event{
+			Type:     EVENT_STEP_STARTED,
+			Usecase:  s.Name,
+			Step:     step.Name,
+			Executor: step.Executor,
+		})
+
+		started := time.Now()
+		sctx, cancel := e.stepContext(ctx, step)
+		resp, req, attempts, err := e.runStep(sctx, step, outputs)
+		status := failedStatus(sctx)
+		cancel()
+
+		report.Request = &req
+		report.Attempts = attempts
+		if attempts > 1 {
+			report.Retries += attempts - 1
+		}
+		report.Info = e.driverInfo(step.Driver)
+
+		if err == nil {
+			e.resourceChanged(s.Name, step, req, resp)
+		}
+
+		out, status, msg := e.completeStep(s, step, req, resp, err, status, outputs, report)
+		e.stepFinished(s.Name, step, status, msg, attempts, time.Since(started))
+
+		if status != STATUS_OK {
+			report.Message = msg
+			report.Status = status
+			return e.complete(report)
+		}
+
+		outputs[step.Name] = out
+		e.checkpoint(s, step, outputs)
+	}
+
+	report.Message = outputs
+	report.Status = STATUS_OK
+	report.Success = true
+	e.complete(report)
+
+	if e.Checkpoint != nil {
+		if err := e.Checkpoint.Passed(s, report); err != nil {
+			e.logf("WARN", "%s: failed to checkpoint: %s", s.Name, err)
+		}
+	}
+
+	return report
+}
+
+// completeStep evaluates the outcome of the executed step against its
+// expected error & assertions, whose results are added to the report.
+// The output of a passed step is returned, else the status & message of
+// its failure.
+func (e *ScenarioExec) completeStep(s *config.Scenario, step *config.Step, req driver.Request, resp *driver.Response, err error, status string, outputs map[string]interface{}, report *Report) (map[string]interface{}, string, string) {
+	// A failure is what a negative step is after, unless the
+	// step could not complete within its deadline
+	if step.ExpectError != "" && status == STATUS_FAILED {
+		result := assertError(step, err)
+		report.Assertions = append(report.Assertions, result)
+
+		if !result.Passed {
+			e.logf("ERR", "%s: %s", s.Name, result)
+			return nil, STATUS_FAILED, result.String()
+		}
+
+		e.logf("INFO", "%s: step '%s' failed as expected: %s", s.Name, step.Name, err)
+		out := stepOutput(req, resp)
+		out["Error"] = err.Error()
+		return out, STATUS_OK, ""
+	}
+
+	if err != nil {
+		e.logf("ERR", "%s: step '%s' failed with status %s: %s", s.Name, step.Name, status, err)
+		return nil, status, fmt.Sprintf("step '%s': %s", step.Name, err.Error())
+	}
+
+	out := stepOutput(req, resp)
+	results, err := assertOutput(step, out, outputs)
+	report.Assertions = append(report.Assertions, results...)
+	if err != nil {
+		e.logf("ERR", "%s: step '%s' failed: %s", s.Name, step.Name, err)
+		return nil, STATUS_FAILED, fmt.Sprintf("step '%s': %s", step.Name, err.Error())
+	}
+
+	for _, result := range results {
+		if !result.Passed {
+			e.logf("ERR", "%s: %s", s.Name, result)
+			return nil, STATUS_FAILED, result.String()
+		}
+	}
+
+	e.logf("INFO", "%s: step '%s' completed", s.Name, step.Name)
+	return out, STATUS_OK, ""
+}
+
+// checkpoint records the completion of the step, if checkpoints are
+// being kept. A failure to checkpoint does not fail the scenario.
+func (e *ScenarioExec) checkpoint(s *config.Scenario, step *config.Step, outputs map[string]interface{}) {
+	if e.Checkpoint == nil {
+		return
+	}
+
+	if err := e.Checkpoint.StepCompleted(s, step.Name, outputs); err != nil {
+		e.logf("WARN", "%s: failed to checkpoint step '%s': %s", s.Name, step.Name, err)
+	}
+}
+
+// complete marks the end of the report
+func (e *ScenarioExec) complete(report *Report) *Report {
+	report.Ended = time.Now()
+	report.Duration = report.Ended.Sub(report.Started)
+	e.usecaseFinished(report)
+	return report
+}
+
+// publish publishes the event of the runner & worker, if events are
+// being published
+func (e *ScenarioExec) publish(ev *Event) {
+	if e.Events == nil {
+		return
+	}
+
+	ev.Runner = e.runner
+	ev.Worker = e.Worker
+	e.Events.Publish(ev)
+}
+
+// usecaseFinished publishes the end of the use case of the report
+func (e *ScenarioExec) usecaseFinished(report *Report) {
+	e.publish(usecaseFinishedEvent(report))
+}
+
+// stepFinished publishes the end of the step of the use case
+func (e *ScenarioExec) stepFinished(usecase string, step *config.Step, status, msg string, attempts int, d time.Duration) {
+	e.publish(&Event{
+		Type:     EVENT_STEP_FINISHED,
+		Usecase:  usecase,
+		Step:     step.Name,
+		Executor: step.Executor,
+		Status:   status,
+		Error:    msg,
+		Attempts: attempts,
+		Duration: d,
+	})
+}
+
+// resourceChanged publishes the creation or deletion of the resource by
+// the successful execution of the step, if its executor does so
+func (e *ScenarioExec) resourceChanged(usecase string, step *config.Step, req driver.Request, resp *driver.Response) {
+	res, typ, ok := stepResource(step, req, resp)
+	if !ok {
+		return
+	}
+
+	e.publish(&Event{
+		Type:     typ,
+		Usecase:  usecase,
+		Step:     step.Name,
+		Executor: step.Executor,
+		Resource: res,
+	})
+}
+
+// logf logs the message at the given level. The message is tagged
+// with the worker, if any.
+//
+// NOTE: The level is placed first as the log level filter expects it so.
+func (e *ScenarioExec) logf(level, format string, v ...interface{}) {
+	msg := fmt.Sprintf(format, v...)
+	if e.Worker != "" {
+		msg = e.Worker + ": " + msg
+	}
+
+	e.logger.Printf("[%s] %s", level, msg)
+}
+
+// stepContext derives the context of the step which has the
+// step's deadline, if any.
+func (e *ScenarioExec) stepContext(ctx context.Context, step *config.Step) (context.Context, context.CancelFunc) {
+	timeout := e.StepTimeout
+	if step.Timeout != "" {
+		// The timeout has been validated while parsing the config
+		if d, err := time.ParseDuration(step.Timeout); err == nil {
+			timeout = d
+		}
+	}
+
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+
+	return context.WithTimeout(ctx, timeout)
+}
+
+// failedStatus provides the status of a failed step based
+// on the step's context
+func failedStatus(ctx context.Context) string {
+	switch ctx.Err() {
+	case context.DeadlineExceeded:
+		return STATUS_TIMEOUT
+	case context.Canceled:
+		return STATUS_CANCELLED
+	}
+
+	return STATUS_FAILED
+}
+
+// runStep resolves the step's request against the outputs of the earlier
+// steps & executes it till it succeeds or its attempts are exhausted. The
+// no of attempts made is returned along with the response.
+func (e *ScenarioExec) runStep(ctx context.Context, step *config.Step, outputs map[string]interface{}) (*driver.Response, driver.Request, int, error) {
+	req, err := resolveRequest(step, outputs)
+	if err != nil {
+		return nil, req, 0, err
+	}
+
+	exec, err := e.executor(step)
+	if err != nil {
+		return nil, req, 0, err
+	}
+
+	max := step.Attempts
+	if max <= 0 {
+		max = 1
+	}
+
+	var resp *driver.Response
+	attempt := 0
+	for attempt < max {
+		// Do not even start if the run has been stopped or the
+		// step's deadline has been exceeded
+		if cErr := ctx.Err(); cErr != nil {
+			if err == nil {
+				err = cErr
+			}
+			break
+		}
+
+		if attempt > 0 {
+			e.logf("WARN", "step '%s': retrying after attempt %d failed: %s", step.Name, attempt, err)
+			if wErr := e.waitBeforeRetry(ctx); wErr != nil {
+				break
+			}
+		}
+
+		attempt++
+		execCtx, wait := driver.WithWaitTimer(ctx)
+		start := time.Now()
+		resp, err = driver.ExecContext(execCtx, exec, req)
+		if e.Latencies != nil {
+			e.Latencies.Record(step.Executor, time.Since(start)-wait.Waited(), err)
+		}
+		if e.Waits != nil {
+			e.Waits.Record(step.Executor, wait.Waited(), err)
+		}
+		if err == nil || isExpectedError(step, err) {
+			break
+		}
+	}
+
+	return resp, req, attempt, err
+}
+
+// waitBeforeRetry waits for the retry interval or till the context
+// is done, whichever is earlier
+func (e *ScenarioExec) waitBeforeRetry(ctx context.Context) error {
+	if e.RetryInterval <= 0 {
+		return ctx.Err()
+	}
+
+	t := time.NewTimer(e.RetryInterval)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// executor gets the executor of the step from its driver, unless the
+// steps have an executor of their own
+func (e *ScenarioExec) executor(step *config.Step) (driver.Executor, error) {
+	if e.StepExecutor != nil {
+		return e.StepExecutor(step)
+	}
+
+	d, err := e.driver(step.Driver)
+	if err != nil {
+		return nil, err
+	}
+
+	execs, err := d.Executors(step.Executor)
+	if err != nil {
+		return nil, err
+	}
+
+	exec, exists := execs[step.Executor]
+	if !exists {
+		return nil, fmt.Errorf("Executor '%s' not found in driver '%s'", step.Executor, step.Driver)
+	}
+
+	return exec, nil
+}
+
+// driver gets an initialized driver instance
+func (e *ScenarioExec) driver(name string) (driver.MtestDriver, error) {
+	e.m.Lock()
+	defer e.m.Unlock()
+
+	if d, exists := e.drivers[name]; exists {
+		return d, nil
+	}
+
+	config := make(map[string]string)
+	if e.DriverConfig != nil {
+		config = e.DriverConfig(name)
+	}
+
+	if e.DryRun {
+		if config == nil {
+			config = make(map[string]string)
+		}
+		config[driver.DRY_RUN] = "true"
+	}
+
+	d, err := driver.GetDriver(name, "", config)
+	if err != nil {
+		return nil, err
+	}
+
+	e.drivers[name] = d
+	return d, nil
+}
+
+// driverInfo provides the info of an initialized driver. Nil is
+// returned if the driver could not be initialized.
+func (e *ScenarioExec) driverInfo(name string) map[string]string {
+	e.m.Lock()
+	d, exists := e.drivers[name]
+	e.m.Unlock()
+
+	if !exists {
+		return nil
+	}
+
+	info, err := d.Info()
+	if err != nil {
+		e.logf("WARN", "failed to get info of driver '%s': %s", name, err)
+		return nil
+	}
+
+	return info
+}
+
+// uniqueValue provides a short value that is unique across the
+// executions of scenarios
+func uniqueValue() string {
+	return strings.Replace(util.NewUUID(), "-", "", -1)[:8]
+}
+
+// stepOutput builds the output of an executed step. The request name
+// is available as Name along with the values of the response.
+func stepOutput(req driver.Request, resp *driver.Response) map[string]interface{} {
+	out := map[string]interface{}{
+		"Name": req.Name,
+	}
+
+	if resp != nil {
+		for k, v := range resp.Values {
+			out[k] = v
+		}
+	}
+
+	return out
+}
+
+// resolveRequest builds the driver.Request of a step after substituting
+// the references to outputs of earlier steps
+func resolveRequest(step *config.Step, outputs map[string]interface{}) (driver.Request, error) {
+	req := driver.Request{
+		Options: make(map[string]string, len(step.Options)),
+	}
+
+	name, err := interpolate(step.Request, outputs)
+	if err != nil {
+		return req, err
+	}
+	req.Name = name
+
+	for k, v := range step.Options {
+		val, err := interpolate(v, outputs)
+		if err != nil {
+			return req, err
+		}
+		req.Options[k] = val
+	}
+
+	return req, nil
+}
+
+// interpolate replaces all the ${...} references in the given string
+func interpolate(s string, outputs map[string]interface{}) (string, error) {
+	var rErr error
+
+	result := refRegex.ReplaceAllStringFunc(s, func(ref string) string {
+		path := strings.TrimSpace(ref[2 : len(ref)-1])
+
+		val, found := lookupValue(outputs, strings.Split(path, "."))
+		if !found {
+			if rErr == nil {
+				rErr = fmt.Errorf("Unresolved reference '%s'", ref)
+			}
+			return ref
+		}
+
+		return fmt.Sprint(val)
+	})
+
+	return result, rErr
+}
+
+// lookupValue walks the given path through maps & struct fields
+func lookupValue(v interface{}, path []string) (interface{}, bool) {
+	for _, key := range path {
+		rv := reflect.ValueOf(v)
+		for rv.Kind() == reflect.Ptr || rv.Kind() == reflect.Interface {
+			if rv.IsNil() {
+				return nil, false
+			}
+			rv = rv.Elem()
+		}
+
+		switch rv.Kind() {
+		case reflect.Map:
+			if rv.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			mv := rv.MapIndex(reflect.ValueOf(key).Convert(rv.Type().Key()))
+			if !mv.IsValid() {
+				return nil, false
+			}
+			v = mv.Interface()
+		case reflect.Struct:
+			fv := rv.FieldByName(key)
+			if !fv.IsValid() || !fv.CanInterface() {
+				return nil, false
+			}
+			v = fv.Interface()
+		default:
+			return nil, false
+		}
+	}
+
+	return v, true
+}
@@ -0,0 +1,354 @@
+package mtest
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/openebs/mtest/config"
+	"github.com/openebs/mtest/driver"
+	"github.com/openebs/mtest/driver/mock"
+)
+
+// mockStep provides a step that is executed by the executor of the hint
+// of the mock driver
+func mockStep(name, hint, reqName string, opts map[string]string) *config.Step {
+	return &config.Step{
+		Name:     name,
+		Driver:   mock.DRIVER_NAME,
+		Executor: hint,
+		Request:  reqName,
+		Options:  opts,
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	type snap struct {
+		EBSID string
+	}
+
+	outputs := map[string]interface{}{
+		"create": map[string]interface{}{
+			"Name": "vol1",
+			"snap": snap{EBSID: "snap-123"},
+		},
+	}
+
+	cases := []struct {
+		In  string
+		Out string
+		Err bool
+	}{
+		{"plain", "plain", false},
+		{"${create.Name}", "vol1", false},
+		{"copy-of-${ create.Name }", "copy-of-vol1", false},
+		{"${create.snap.EBSID}", "snap-123", false},
+		{"${create.Missing}", "", true},
+		{"${remove.Name}", "", true},
+	}
+
+	for _, tc := range cases {
+		out, err := interpolate(tc.In, outputs)
+		if (err != nil) != tc.Err {
+			t.Fatalf("%q: unexpected error state: %v", tc.In, err)
+		}
+
+		if !tc.Err && out != tc.Out {
+			t.Fatalf("%q: want %q, got %q", tc.In, tc.Out, out)
+		}
+	}
+}
+
+func TestScenarioExec_Run(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+
+	scenario := &config.Scenario{
+		Name: "chain",
+		Steps: []*config.Step{
+			mockStep("create", "create", "vol1", map[string]string{"Size": "4G"}),
+			mockStep("snap", "snap", "snap-of-${create.Name}", map[string]string{"Size": "${create.Size}"}),
+		},
+	}
+
+	report := exec.Run(context.Background(), scenario)
+	if !report.Success || report.Status != "OK" {
+		t.Fatalf("bad report: %#v", report)
+	}
+
+	outputs := report.Message.(map[string]interface{})
+	snap := outputs["snap"].(map[string]interface{})
+
+	expected := map[string]interface{}{
+		"Name": "snap-of-vol1",
+		"Hint": "snap",
+		"Size": "4G",
+	}
+	if !reflect.DeepEqual(snap, expected) {
+		t.Fatalf("bad outputs:\nwant: %#v\n got: %#v", expected, snap)
+	}
+}
+
+func TestScenarioExec_RunStopsOnFailure(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+
+	scenario := &config.Scenario{
+		Name: "chain",
+		Steps: []*config.Step{
+			mockStep("create", "fail", "vol1", nil),
+			mockStep("never", "create", "${create.Name}", nil),
+		},
+	}
+
+	report := exec.Run(context.Background(), scenario)
+	if report.Success || report.Status != "FAILED" {
+		t.Fatalf("bad report: %#v", report)
+	}
+
+	if report.Message != "step 'create': mock failure of vol1" {
+		t.Fatalf("bad message: %v", report.Message)
+	}
+}
+
+func TestScenarioExec_RunTimeout(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+	exec.StepTimeout = time.Hour
+
+	step := mockStep("stuck", "block", "vol1", nil)
+	step.Timeout = "10ms"
+
+	report := exec.Run(context.Background(), &config.Scenario{
+		Name:  "stuck",
+		Steps: []*config.Step{step},
+	})
+
+	if report.Success || report.Status != STATUS_TIMEOUT {
+		t.Fatalf("bad report: %#v", report)
+	}
+}
+
+func TestScenarioExec_RunAttempts(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+	exec.RetryInterval = 0
+	exec.Version = "0.1.0-dev"
+	exec.Revision = "abc123"
+
+	step := mockStep("create", "flaky", "vol1", map[string]string{"Size": "1G"})
+	step.Attempts = 3
+
+	report := exec.Run(context.Background(), &config.Scenario{
+		Name:  "flaky",
+		Steps: []*config.Step{step},
+	})
+
+	if !report.Success || report.Attempts != 2 {
+		t.Fatalf("bad report: %#v", report)
+	}
+
+	expected := &driver.Request{Name: "vol1", Options: map[string]string{"Size": "1G"}}
+	if !reflect.DeepEqual(report.Request, expected) {
+		t.Fatalf("bad request: %#v", report.Request)
+	}
+
+	if report.Info["Region"] != mock.MOCK_REGION {
+		t.Fatalf("bad info: %#v", report.Info)
+	}
+
+	if report.Version != "0.1.0-dev" || report.Revision != "abc123" {
+		t.Fatalf("bad version: %s %s", report.Version, report.Revision)
+	}
+
+	if report.Started.IsZero() || report.Ended.Before(report.Started) {
+		t.Fatalf("bad times: %v %v", report.Started, report.Ended)
+	}
+
+	if report.Duration != report.Ended.Sub(report.Started) {
+		t.Fatalf("bad duration: %v", report.Duration)
+	}
+}
+
+func TestScenarioExec_RunRetries(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+	exec.RetryInterval = 0
+
+	flaky := mockStep("create", "flaky", "vol1", nil)
+	flaky.Attempts = 3
+
+	report := exec.Run(context.Background(), &config.Scenario{
+		Name:  "retried",
+		Steps: []*config.Step{flaky, mockStep("snap", "snap", "snap1", nil)},
+	})
+
+	// The retry of the first step is counted after the last step
+	if !report.Success || report.Attempts != 1 || report.Retries != 1 {
+		t.Fatalf("bad report: %#v", report)
+	}
+}
+
+func TestScenarioExec_RunAttemptsExhausted(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+	exec.RetryInterval = 0
+
+	step := mockStep("create", "fail", "vol1", nil)
+	step.Attempts = 3
+
+	report := exec.Run(context.Background(), &config.Scenario{
+		Name:  "failing",
+		Steps: []*config.Step{step},
+	})
+
+	if report.Success || report.Status != STATUS_FAILED || report.Attempts != 3 {
+		t.Fatalf("bad report: %#v", report)
+	}
+
+	if report.Request == nil || report.Request.Name != "vol1" {
+		t.Fatalf("bad request: %#v", report.Request)
+	}
+}
+
+func TestScenarioExec_RunUnique(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+
+	scenario := &config.Scenario{
+		Name:  "unique",
+		Steps: []*config.Step{mockStep("create", "create", "vol-${unique}", nil)},
+	}
+
+	name := func() string {
+		report := exec.Run(context.Background(), scenario)
+		if !report.Success {
+			t.Fatalf("bad report: %#v", report)
+		}
+		return report.Request.Name
+	}
+
+	first, second := name(), name()
+	if first == second || !strings.HasPrefix(first, "vol-") || len(first) != len("vol-")+8 {
+		t.Fatalf("expected unique names, got %q & %q", first, second)
+	}
+}
+
+func TestScenarioExec_RunAssertions(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+
+	read := mockStep("read", "read", "${create.Name}", map[string]string{
+		"State": "available",
+		"Size":  "4294967296",
+	})
+	read.Assert = []string{
+		`State == "available"`,
+		"Size >= 4G",
+		"Name == ${create.Name}",
+		"Hint contains rea",
+	}
+
+	report := exec.Run(context.Background(), &config.Scenario{
+		Name: "assert",
+		Steps: []*config.Step{
+			mockStep("create", "create", "vol1", nil),
+			read,
+		},
+	})
+
+	if !report.Success || len(report.Assertions) != 4 {
+		t.Fatalf("bad report: %#v", report)
+	}
+
+	if a := report.Assertions[2]; !a.Passed || a.Assertion != `Name == "vol1"` || a.Actual != "vol1" {
+		t.Fatalf("bad assertion result: %#v", a)
+	}
+}
+
+func TestScenarioExec_RunAssertionFails(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+
+	read := mockStep("read", "read", "vol1", map[string]string{"Type": "gp2"})
+	read.Assert = []string{"Type == io1", "Missing == x"}
+
+	report := exec.Run(context.Background(), &config.Scenario{
+		Name: "assert",
+		Steps: []*config.Step{
+			read,
+			mockStep("never", "create", "vol2", nil),
+		},
+	})
+
+	if report.Success || report.Status != STATUS_FAILED {
+		t.Fatalf("bad report: %#v", report)
+	}
+
+	if report.Message != `step 'read': Type == "io1" failed, got "gp2"` {
+		t.Fatalf("bad message: %v", report.Message)
+	}
+
+	if len(report.Assertions) != 2 || report.Assertions[1].Actual != "<not found>" {
+		t.Fatalf("bad assertion results: %v", report.Assertions)
+	}
+}
+
+func TestScenarioExec_RunExpectError(t *testing.T) {
+	exec := NewScenarioExec("test.runner", log.New(ioutil.Discard, "", 0))
+	exec.RetryInterval = 0
+
+	negative := mockStep("remove", "fail", "vol1", nil)
+	negative.ExpectError = "mock failure"
+	negative.Attempts = 3
+
+	report := exec.Run(context.Background(), &config.Scenario{
+		Name: "negative",
+		Steps: []*config.Step{
+			negative,
+			mockStep("after", "create", "${remove.Error}", nil),
+		},
+	})
+
+	if !report.Success || report.Attempts != 1 {
+		t.Fatalf("expected the expected error to pass without retries: %#v", report)
+	}
+
+	if len(report.Assertions) != 1 || !report.Assertions[0].Passed {
+		t.Fatalf("bad assertion results: %v", report.Assertions)
+	}
+
+	// A step that succeeds when it should have failed
+	positive := mockStep("remove", "remove", "vol1", nil)
+	positive.ExpectError = "not found"
+
+	report = exec.Run(context.Background(), &config.Scenario{
+		Name:  "negative",
+		Steps: []*config.Step{positive},
+	})
+
+	if report.Success || report.Message != `step 'remove': error contains "not found" failed, got "<no error>"` {
+		t.Fatalf("bad report: %#v", report)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	cases := []struct {
+		Actual   string
+		Op       string
+		Expected string
+		Result   bool
+		Err      bool
+	}{
+		{"available", "==", "available", true, false},
+		{"available", "!=", "creating", true, false},
+		{"4294967296", ">=", "4G", true, false},
+		{"1073741824", ">", "4G", false, false},
+		{"100", "<", "250", true, false},
+		{"10.5", "<=", "10.5", true, false},
+		{"io1", "contains", "o1", true, false},
+		{"io1", ">", "gp2", false, true},
+	}
+
+	for _, tc := range cases {
+		result, err := compare(tc.Actual, tc.Op, tc.Expected)
+		if (err != nil) != tc.Err || result != tc.Result {
+			t.Fatalf("%s %s %s: want %v, got %v (err %v)", tc.Actual, tc.Op, tc.Expected, tc.Result, result, err)
+		}
+	}
+}
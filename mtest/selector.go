@@ -0,0 +1,132 @@
+package mtest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/openebs/mtest/config"
+)
+
+// Selector selects the use cases to be run by their names & tags.
+// A nil Selector selects all the use cases.
+type Selector struct {
+	run  *regexp.Regexp
+	skip *regexp.Regexp
+	tags []string
+}
+
+// NewSelector returns the selector of the use cases as per the
+// run, skip & tags of the config. Nil is returned if the config
+// does not select any particular use cases.
+func NewSelector(mconfig *config.MtestConfig) (*Selector, error) {
+	if mconfig == nil || (mconfig.Run == "" && mconfig.Skip == "" && len(mconfig.Tags) == 0) {
+		return nil, nil
+	}
+
+	s := &Selector{}
+
+	var err error
+	if mconfig.Run != "" {
+		if s.run, err = regexp.Compile(mconfig.Run); err != nil {
+			return nil, fmt.Errorf("Invalid run pattern '%s': %s", mconfig.Run, err)
+		}
+	}
+
+	if mconfig.Skip != "" {
+		if s.skip, err = regexp.Compile(mconfig.Skip); err != nil {
+			return nil, fmt.Errorf("Invalid skip pattern '%s': %s", mconfig.Skip, err)
+		}
+	}
+
+	for _, t := range mconfig.Tags {
+		if t = strings.TrimSpace(t); t != "" {
+			s.tags = append(s.tags, t)
+		}
+	}
+
+	return s, nil
+}
+
+// Selects returns true if the use case of the name & tags should
+// be run. A use case is run if its name matches the run pattern,
+// does not match the skip pattern & it has any of the tags.
+func (s *Selector) Selects(name string, tags []string) bool {
+	if s == nil {
+		return true
+	}
+
+	if s.run != nil && !s.run.MatchString(name) {
+		return false
+	}
+
+	if s.skip != nil && s.skip.MatchString(name) {
+		return false
+	}
+
+	if len(s.tags) == 0 {
+		return true
+	}
+
+	for _, want := range s.tags {
+		for _, t := range tags {
+			if t == want {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// String provides the selection in the form of the run flags
+func (s *Selector) String() string {
+	if s == nil {
+		return "all"
+	}
+
+	var parts []string
+	if s.run != nil {
+		parts = append(parts, "-run="+s.run.String())
+	}
+	if s.skip != nil {
+		parts = append(parts, "-skip="+s.skip.String())
+	}
+	if len(s.tags) > 0 {
+		parts = append(parts, "-tags="+strings.Join(s.tags, ","))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Scenarios provides the selected scenarios
+func (s *Selector) Scenarios(scenarios []*config.Scenario) []*config.Scenario {
+	if s == nil {
+		return scenarios
+	}
+
+	var selected []*config.Scenario
+	for _, sc := range scenarios {
+		if s.Selects(sc.Name, sc.Tags) {
+			selected = append(selected, sc)
+		}
+	}
+
+	return selected
+}
+
+// Loads provides the selected load tests
+func (s *Selector) Loads(loads []*config.Load) []*config.Load {
+	if s == nil {
+		return loads
+	}
+
+	var selected []*config.Load
+	for _, l := range loads {
+		if s.Selects(l.Name, l.Tags) {
+			selected = append(selected, l)
+		}
+	}
+
+	return selected
+}
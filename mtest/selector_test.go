@@ -0,0 +1,86 @@
+package mtest
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/openebs/mtest/config"
+)
+
+func TestSelector_Selects(t *testing.T) {
+	cases := []struct {
+		Run  string
+		Skip string
+		Tags []string
+		Want []string
+	}{
+		{"", "", nil, []string{"volume-create", "snapshot-create", "snapshot-restore", "backup-create"}},
+		{"^snapshot-", "", nil, []string{"snapshot-create", "snapshot-restore"}},
+		{"^snapshot-", "restore", nil, []string{"snapshot-create"}},
+		{"", "", []string{"backup", "smoke"}, []string{"volume-create", "snapshot-restore", "backup-create"}},
+		{"create", "", []string{"backup"}, []string{"backup-create"}},
+	}
+
+	usecases := []struct {
+		Name string
+		Tags []string
+	}{
+		{"volume-create", []string{"volume", "smoke"}},
+		{"snapshot-create", []string{"snapshot"}},
+		{"snapshot-restore", []string{"snapshot", "backup"}},
+		{"backup-create", []string{"backup"}},
+	}
+
+	for _, tc := range cases {
+		s, err := NewSelector(&config.MtestConfig{Run: tc.Run, Skip: tc.Skip, Tags: tc.Tags})
+		if err != nil {
+			t.Fatalf("err: %s", err)
+		}
+
+		var got []string
+		for _, u := range usecases {
+			if s.Selects(u.Name, u.Tags) {
+				got = append(got, u.Name)
+			}
+		}
+
+		if !reflect.DeepEqual(got, tc.Want) {
+			t.Fatalf("%s: want %v, got %v", s, tc.Want, got)
+		}
+	}
+}
+
+func TestNewSelector_Invalid(t *testing.T) {
+	_, err := NewSelector(&config.MtestConfig{Run: "snap("})
+	if err == nil || !strings.Contains(err.Error(), "Invalid run pattern") {
+		t.Fatalf("expected invalid run pattern error, got %v", err)
+	}
+}
+
+func TestNewMserverRunMaker_Selection(t *testing.T) {
+	mconfig := &config.MtestConfig{
+		Scenarios: []*config.Scenario{
+			{Name: "a", Tags: []string{"smoke"}, Steps: []*config.Step{mockStep("s", "ok", "vol1", nil)}},
+			{Name: "b", Steps: []*config.Step{mockStep("s", "ok", "vol1", nil)}},
+		},
+		Tags: []string{"smoke"},
+	}
+
+	maker, err := NewMserverRunMaker(ioutil.Discard, mconfig)
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+
+	scenarios := maker.(*MtestMake).runner.(*MserverRunner).scenarios
+	if len(scenarios) != 1 || scenarios[0].Name != "a" {
+		t.Fatalf("bad selection: %v", scenarios)
+	}
+
+	mconfig.Tags = []string{"nightly"}
+	_, err = NewMserverRunMaker(ioutil.Discard, mconfig)
+	if err == nil || !strings.Contains(err.Error(), "No use cases match the selection -tags=nightly") {
+		t.Fatalf("expected no match error, got %v", err)
+	}
+}
@@ -0,0 +1,185 @@
+package mtest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LatencyBuckets are the upper bounds of the buckets of the latency
+// histograms. Latencies above the last bound are in an unbounded bucket.
+var LatencyBuckets = []time.Duration{
+	100 * time.Millisecond,
+	250 * time.Millisecond,
+	500 * time.Millisecond,
+	1 * time.Second,
+	2500 * time.Millisecond,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	1 * time.Minute,
+	5 * time.Minute,
+}
+
+// LatencyBucket is a bucket of a latency histogram
+type LatencyBucket struct {
+	// Upper bound of the bucket, 0 for the unbounded bucket
+	UpTo time.Duration
+
+	// No of latencies within the bucket i.e. above the upper bound
+	// of the previous bucket & up to the upper bound of this one
+	Count int
+}
+
+// OpStats are the latency statistics of an operation, e.g. the
+// executions of an executor.
+type OpStats struct {
+	// Name of the operation
+	Op string
+
+	// No of completed operations including the failed ones
+	Count int
+
+	// No of failed operations
+	Failures int
+
+	// Latency percentiles, mean & the max latency
+	P50  time.Duration
+	P90  time.Duration
+	P99  time.Duration
+	Mean time.Duration
+	Max  time.Duration
+
+	// Latency histogram with the buckets of LatencyBuckets followed by
+	// the unbounded bucket
+	Histogram []*LatencyBucket
+}
+
+// String provides a single line & readable form of the statistics
+func (s *OpStats) String() string {
+	return fmt.Sprintf("%s: %d ops, %d failures, p50 %v, p90 %v, p99 %v, max %v",
+		s.Op, s.Count, s.Failures, s.P50, s.P90, s.P99, s.Max)
+}
+
+// Latencies records the latencies of operations. It is safe to use
+// across multiple goroutines.
+type Latencies struct {
+	m        sync.Mutex
+	order    []string
+	samples  map[string][]time.Duration
+	failures map[string]int
+}
+
+// NewLatencies returns a new instance of Latencies
+func NewLatencies() *Latencies {
+	return &Latencies{
+		samples:  make(map[string][]time.Duration),
+		failures: make(map[string]int),
+	}
+}
+
+// Record records the latency of a completed operation
+func (l *Latencies) Record(op string, d time.Duration, err error) {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	if _, exists := l.samples[op]; !exists {
+		l.order = append(l.order, op)
+	}
+
+	l.samples[op] = append(l.samples[op], d)
+	if err != nil {
+		l.failures[op]++
+	}
+}
+
+// Stats provides the statistics of each operation in the order the
+// operations were first recorded
+func (l *Latencies) Stats() []*OpStats {
+	l.m.Lock()
+	defer l.m.Unlock()
+
+	stats := make([]*OpStats, 0, len(l.order))
+	for _, op := range l.order {
+		sorted := make([]time.Duration, len(l.samples[op]))
+		copy(sorted, l.samples[op])
+		sort.Sort(durations(sorted))
+
+		var total time.Duration
+		for _, d := range sorted {
+			total += d
+		}
+
+		stats = append(stats, &OpStats{
+			Op:        op,
+			Count:     len(sorted),
+			Failures:  l.failures[op],
+			P50:       percentile(sorted, 50),
+			P90:       percentile(sorted, 90),
+			P99:       percentile(sorted, 99),
+			Mean:      total / time.Duration(len(sorted)),
+			Max:       sorted[len(sorted)-1],
+			Histogram: histogram(sorted),
+		})
+	}
+
+	return stats
+}
+
+// String provides the statistics of all the operations separated by ';'
+func (l *Latencies) String() string {
+	stats := l.Stats()
+
+	parts := make([]string, 0, len(stats))
+	for _, s := range stats {
+		parts = append(parts, s.String())
+	}
+
+	return strings.Join(parts, "; ")
+}
+
+// percentile provides the nearest rank percentile of the sorted samples
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+
+	rank := int(p/100*float64(len(sorted))+0.5) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+
+	return sorted[rank]
+}
+
+// histogram buckets the sorted samples as per LatencyBuckets
+func histogram(sorted []time.Duration) []*LatencyBucket {
+	buckets := make([]*LatencyBucket, 0, len(LatencyBuckets)+1)
+	for _, upTo := range LatencyBuckets {
+		buckets = append(buckets, &LatencyBucket{UpTo: upTo})
+	}
+	unbounded := &LatencyBucket{}
+	buckets = append(buckets, unbounded)
+
+	i := 0
+	for _, d := range sorted {
+		for i < len(LatencyBuckets) && d > LatencyBuckets[i] {
+			i++
+		}
+		buckets[i].Count++
+	}
+
+	return buckets
+}
+
+// durations sorts the durations in the increasing order
+type durations []time.Duration
+
+func (d durations) Len() int           { return len(d) }
+func (d durations) Less(i, j int) bool { return d[i] < d[j] }
+func (d durations) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
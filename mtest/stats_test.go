@@ -0,0 +1,70 @@
+package mtest
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestLatencies_Stats(t *testing.T) {
+	l := NewLatencies()
+
+	// 1ms..100ms for create, in the reverse order
+	for i := 100; i > 0; i-- {
+		var err error
+		if i%10 == 0 {
+			err = fmt.Errorf("failed")
+		}
+		l.Record("create", time.Duration(i)*time.Millisecond, err)
+	}
+	l.Record("remove", 5*time.Millisecond, nil)
+
+	stats := l.Stats()
+	if len(stats) != 2 || stats[0].Op != "create" || stats[1].Op != "remove" {
+		t.Fatalf("bad stats: %v", stats)
+	}
+
+	create := stats[0]
+	if create.Count != 100 || create.Failures != 10 {
+		t.Fatalf("bad counts: %v", create)
+	}
+
+	if create.P50 != 50*time.Millisecond || create.P90 != 90*time.Millisecond ||
+		create.P99 != 99*time.Millisecond || create.Max != 100*time.Millisecond {
+		t.Fatalf("bad percentiles: %v", create)
+	}
+
+	if create.Mean != 50500*time.Microsecond {
+		t.Fatalf("bad mean: %v", create.Mean)
+	}
+
+	// 1ms..100ms are in the first bucket
+	if len(create.Histogram) != len(LatencyBuckets)+1 || create.Histogram[0].Count != 100 {
+		t.Fatalf("bad histogram: %v", create.Histogram)
+	}
+
+	remove := stats[1]
+	if remove.P50 != 5*time.Millisecond || remove.P99 != 5*time.Millisecond {
+		t.Fatalf("bad percentiles: %v", remove)
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	buckets := histogram([]time.Duration{
+		50 * time.Millisecond,
+		100 * time.Millisecond,
+		101 * time.Millisecond,
+		3 * time.Second,
+		time.Hour,
+	})
+
+	counts := make(map[time.Duration]int)
+	for _, b := range buckets {
+		counts[b.UpTo] = b.Count
+	}
+
+	if counts[100*time.Millisecond] != 2 || counts[250*time.Millisecond] != 1 ||
+		counts[5*time.Second] != 1 || counts[0] != 1 {
+		t.Fatalf("bad histogram: %v", counts)
+	}
+}
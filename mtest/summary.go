@@ -0,0 +1,81 @@
+package mtest
+
+import (
+	"fmt"
+	"time"
+)
+
+// Summary is the outcome of a run derived from the reports of its
+// use cases.
+type Summary struct {
+	// No of use cases that were run
+	Total int
+
+	// No of use cases per status
+	Passed    int
+	Failed    int
+	TimedOut  int
+	Cancelled int
+
+	// Overall run status i.e. all the use cases were successful
+	Success bool
+
+	// Start & end times of the run i.e. the earliest start & the
+	// latest end of its use cases
+	Started time.Time
+	Ended   time.Time
+
+	// Wall clock time taken by the run
+	Duration time.Duration
+}
+
+// Summarize builds the summary of the provided reports.
+func Summarize(reports []*Report) *Summary {
+	s := &Summary{}
+
+	for _, r := range reports {
+		s.add(r)
+	}
+
+	s.Success = s.Total == s.Passed
+	return s
+}
+
+// add adds the report of a use case to the summary
+func (s *Summary) add(r *Report) {
+	if r == nil {
+		return
+	}
+
+	s.Total++
+
+	switch {
+	case r.Success:
+		s.Passed++
+	case r.Status == STATUS_TIMEOUT:
+		s.TimedOut++
+	case r.Status == STATUS_CANCELLED:
+		s.Cancelled++
+	default:
+		s.Failed++
+	}
+
+	if !r.Started.IsZero() && (s.Started.IsZero() || r.Started.Before(s.Started)) {
+		s.Started = r.Started
+	}
+
+	if r.Ended.After(s.Ended) {
+		s.Ended = r.Ended
+	}
+
+	s.Success = s.Total == s.Passed
+	if !s.Started.IsZero() {
+		s.Duration = s.Ended.Sub(s.Started)
+	}
+}
+
+// String provides a single line & readable form of the summary
+func (s *Summary) String() string {
+	return fmt.Sprintf("%d use case(s): %d passed, %d failed, %d timed out, %d cancelled (%v)",
+		s.Total, s.Passed, s.Failed, s.TimedOut, s.Cancelled, s.Duration)
+}
@@ -0,0 +1,36 @@
+package mtest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarize(t *testing.T) {
+	start := time.Now()
+
+	reports := []*Report{
+		{Success: true, Status: STATUS_OK, Started: start, Ended: start.Add(2 * time.Second)},
+		{Status: STATUS_FAILED, Started: start.Add(time.Second), Ended: start.Add(5 * time.Second)},
+		{Status: STATUS_TIMEOUT, Started: start, Ended: start.Add(3 * time.Second)},
+		{Status: STATUS_CANCELLED, Started: start, Ended: start.Add(time.Second)},
+		nil,
+	}
+
+	s := Summarize(reports)
+
+	if s.Total != 4 || s.Passed != 1 || s.Failed != 1 || s.TimedOut != 1 || s.Cancelled != 1 {
+		t.Fatalf("bad counts: %#v", s)
+	}
+
+	if s.Success {
+		t.Fatalf("expected the run to be unsuccessful")
+	}
+
+	if s.Duration != 5*time.Second {
+		t.Fatalf("bad duration: %v", s.Duration)
+	}
+
+	if !Summarize(reports[:1]).Success {
+		t.Fatalf("expected the run to be successful")
+	}
+}